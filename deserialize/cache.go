@@ -0,0 +1,123 @@
+package deserialize
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	cborPkg "github.com/pasqal-io/godasse/deserialize/cbor"
+	jsonPkg "github.com/pasqal-io/godasse/deserialize/json"
+	json5Pkg "github.com/pasqal-io/godasse/deserialize/json5"
+	"github.com/pasqal-io/godasse/deserialize/kvlist"
+	yamlPkg "github.com/pasqal-io/godasse/deserialize/yaml"
+	"golang.org/x/text/language"
+)
+
+// Compiled deserializers, by `cacheKey`.
+//
+// Compiling a deserializer walks the entire type through reflection, so
+// we memoize the result to make repeated calls to e.g. `MakeMapDeserializer`
+// for the same type cheap.
+//
+// Note that drivers may be stateful while building a deserializer (e.g. the
+// KVList driver keeps track of the structure it has entered), but not while
+// deserializing. A cache miss always compiles with a fresh driver and only
+// the compiled closure is shared.
+var deserializerCache sync.Map
+
+// The dynamic types of the drivers provided by this package.
+//
+// The configuration of these drivers is entirely captured by `cacheKey`,
+// e.g. `useNumber`. Other drivers may carry configuration that we cannot
+// see, so we don't cache the deserializers they build.
+var presetDrivers = map[reflect.Type]struct{}{
+	reflect.TypeOf(jsonPkg.Driver()):  {},
+	reflect.TypeOf(json5Pkg.Driver()): {},
+	reflect.TypeOf(yamlPkg.Driver()):  {},
+	reflect.TypeOf(cborPkg.Driver()):  {},
+	reflect.TypeOf(kvlist.Driver()):   {},
+}
+
+// The key for `deserializerCache`.
+//
+// This contains every option that affects the compiled deserializer.
+type cacheKey struct {
+	typ  reflect.Type
+	path string
+
+	// The renaming tag names, joined with ",".
+	renamingTagNames string
+
+	// The dynamic type of the driver, one of `presetDrivers`.
+	driver reflect.Type
+
+	disallowUnknownFields bool
+	envelope              string
+	boolsAsNumbers        bool
+	strictIntegers        bool
+	useNumber             bool
+	rejectDuplicateKeys   bool
+	listSeparator         string
+	locale                language.Tag
+
+	acceptIndexedObjectsAsArrays bool
+	customCallTimeout            time.Duration
+	allowFlattenCollisions       bool
+	accumulateErrors             bool
+}
+
+// Compute the key for `deserializerCache`.
+//
+// Returns `false` if the deserializer should not be cached, i.e. if it
+// uses custom parsers, named parsers, schemas, codecs, transforms or a
+// custom driver, which we cannot compare.
+func (options innerOptions) cacheKey(path string, typ reflect.Type) (cacheKey, bool) {
+	if len(options.parsers) != 0 || len(options.namedParsers) != 0 || len(options.schemas) != 0 || len(options.codecs) != 0 || len(options.transforms) != 0 {
+		return cacheKey{}, false
+	}
+	driver := reflect.TypeOf(options.unmarshaler)
+	if _, ok := presetDrivers[driver]; !ok {
+		return cacheKey{}, false
+	}
+	return cacheKey{
+		typ:                   typ,
+		path:                  path,
+		renamingTagNames:      strings.Join(options.renamingTagNames, ","),
+		driver:                driver,
+		disallowUnknownFields: options.disallowUnknownFields,
+		envelope:              options.envelope,
+		boolsAsNumbers:        options.boolsAsNumbers,
+		strictIntegers:        options.strictIntegers,
+		useNumber:             options.useNumber,
+		rejectDuplicateKeys:   options.rejectDuplicateKeys,
+		listSeparator:         options.listSeparator,
+		locale:                options.locale,
+
+		acceptIndexedObjectsAsArrays: options.acceptIndexedObjectsAsArrays,
+		customCallTimeout:            options.customCallTimeout,
+		allowFlattenCollisions:       options.allowFlattenCollisions,
+		accumulateErrors:             options.accumulateErrors,
+	}, true
+}
+
+// Compile a deserializer for the outer struct, reusing a previously compiled
+// deserializer if possible.
+func makeCachedOuterStructDeserializerFromReflect(path string, options innerOptions, container reflect.Value, typ reflect.Type) (*mapDeserializer[any], error) {
+	key, cacheable := options.cacheKey(path, typ)
+	if cacheable {
+		if cached, ok := deserializerCache.Load(key); ok {
+			if result, ok := cached.(*mapDeserializer[any]); ok {
+				return result, nil
+			}
+		}
+	}
+	result, err := makeOuterStructDeserializerFromReflect(path, options, container, typ)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		deserializerCache.Store(key, result)
+	}
+	return result, nil
+}
@@ -0,0 +1,219 @@
+// Code specific to deserializing CBOR (RFC 8949).
+//
+// CBOR is a binary format with the data model of JSON, plus byte strings
+// and integers that are distinct from floating-point numbers. Byte strings
+// are deserialized into `[]byte` fields, integers into any integer field
+// large enough to hold them.
+package cbor
+
+import (
+	"encoding"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pasqal-io/godasse/deserialize/shared"
+)
+
+// The deserialization driver for CBOR.
+type driver struct{}
+
+func Driver() shared.Driver {
+	return driver{}
+}
+
+// A CBOR value.
+type Value struct {
+	wrapped any
+}
+
+// A CBOR map.
+type CBOR map[string]any
+
+func (v Value) AsDict() (shared.Dict, bool) {
+	switch t := v.wrapped.(type) {
+	case CBOR:
+		return t, true
+	case map[string]any:
+		var cbor CBOR = t
+		return cbor, true
+	case map[any]any:
+		// CBOR supports non-string keys, e.g. `{1: "abc"}`. Since our
+		// dictionaries are keyed by strings, we convert them back.
+		cbor := make(CBOR, len(t))
+		for k, v := range t {
+			cbor[fmt.Sprint(k)] = v
+		}
+		return cbor, true
+	case nil:
+		var cbor CBOR = map[string]any{}
+		return cbor, true
+	default:
+		return nil, false
+	}
+}
+func (v Value) AsSlice() ([]shared.Value, bool) {
+	// We can't simply cast to `[]any`, as this doesn't work for e.g. `[]byte`.
+	reflected := reflect.ValueOf(v.wrapped)
+	if !reflected.IsValid() {
+		return nil, false
+	}
+	switch reflected.Type().Kind() {
+	case reflect.Array:
+		fallthrough
+	case reflect.Slice:
+		length := reflected.Len()
+		result := make([]shared.Value, length)
+		for i := 0; i < length; i++ {
+			value := reflected.Index(i)
+			result[i] = Value{wrapped: value.Interface()}
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+func (v Value) Interface() any {
+	return v.wrapped
+}
+
+var _ shared.Value = Value{} //nolint:exhaustruct
+
+func (cbor CBOR) Lookup(key string) (shared.Value, bool) {
+	if val, ok := cbor[key]; ok {
+		value := Value{
+			wrapped: val,
+		}
+		return value, true
+	}
+	return nil, false
+}
+func (cbor CBOR) AsValue() shared.Value {
+	return Value{
+		wrapped: cbor,
+	}
+}
+func (cbor CBOR) Keys() []string {
+	keys := make([]string, 0)
+	for k := range cbor {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ shared.Dict = CBOR{} //nolint:exhaustruct
+
+// The type of a CBOR/Dictionary.
+var dictionary = reflect.TypeOf(make(CBOR, 0))
+
+// The interface for `cbor.Unmarshaler`.
+var unmarshaler = reflect.TypeOf(new(cbor.Unmarshaler)).Elem()
+var binaryUnmarshaler = reflect.TypeOf(new(encoding.BinaryUnmarshaler)).Elem()
+var textUnmarshaler = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
+
+// Determine whether we should call the driver to unmarshal values
+// of this type.
+//
+// For CBOR, this is the case if:
+// - `typ` represents a dictionary; and/or
+// - `typ` implements `cbor.Unmarshaler`, `encoding.BinaryUnmarshaler`
+// or `encoding.TextUnmarshaler`.
+//
+// You probably won't ever need to call this method.
+func (driver) ShouldUnmarshal(typ reflect.Type) bool {
+	if typ.ConvertibleTo(dictionary) {
+		return true
+	}
+	ptr := reflect.PointerTo(typ)
+	return ptr.ConvertibleTo(unmarshaler) || ptr.ConvertibleTo(binaryUnmarshaler) || ptr.ConvertibleTo(textUnmarshaler)
+}
+
+// Perform unmarshaling.
+//
+// If `out` is empty, `in` is a CBOR-encoded document, e.g. the argument of
+// `DeserializeBytes`. Otherwise, `in` is a value we have already decoded,
+// e.g. a byte string, to convert into the type of `out`.
+//
+// You probably won't ever need to call this method.
+func (u driver) Unmarshal(in any, out *any) (err error) {
+	if *out == nil {
+		buf, ok := in.([]byte)
+		if !ok {
+			return fmt.Errorf("expected a CBOR document, got %s", in)
+		}
+		if err = cbor.Unmarshal(buf, out); err != nil {
+			return fmt.Errorf("failed to unmarshal CBOR: \n\t * %w", err)
+		}
+		return nil
+	}
+
+	// Unwrap Value.
+	if value, ok := in.(Value); ok {
+		return u.Unmarshal(value.wrapped, out)
+	}
+
+	// If `out` already contains a pointer, e.g. `*time.Time`, deserialize
+	// into that pointer. Otherwise, deserialize into `out` itself.
+	var target any = out
+	if reflect.TypeOf(*out).Kind() == reflect.Pointer {
+		target = *out
+	}
+
+	// Sadly, at this stage, we need to reserialize.
+	buf, err := cbor.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("internal error while deserializing: \n\t * %w", err)
+	}
+	// Note: `cbor.Unmarshal` takes care of `cbor.Unmarshaler` and `encoding.BinaryUnmarshaler`.
+	err = cbor.Unmarshal(buf, target)
+	if err == nil {
+		return nil
+	}
+	// Text strings, e.g. UUIDs, may need UnmarshalText.
+	if text, ok := in.(string); ok {
+		if textUnmarshaler, ok := target.(encoding.TextUnmarshaler); ok {
+			err2 := textUnmarshaler.UnmarshalText([]byte(text))
+			if err2 == nil {
+				// Success! Let's use that result.
+				return nil
+			}
+			return fmt.Errorf("failed to unmarshal '%s' either from CBOR or from text: \n\t * %w\n\t * and %w", text, err, err2)
+		}
+	}
+	return fmt.Errorf("failed to unmarshal %v: \n\t * %w", in, err)
+}
+
+// Perform unmarshaling from a stream.
+//
+// You probably won't ever need to call this method.
+func (driver) UnmarshalReader(in io.Reader, out *any) error {
+	if err := cbor.NewDecoder(in).Decode(out); err != nil {
+		return fmt.Errorf("failed to unmarshal stream: \n\t * %w", err)
+	}
+	return nil
+}
+
+// Perform marshaling.
+//
+// You probably won't ever need to call this method.
+func (driver) Marshal(in any) ([]byte, error) {
+	return cbor.Marshal(in) //nolint:wrapcheck
+}
+
+func (driver) WrapValue(wrapped any) shared.Value {
+	return Value{
+		wrapped: wrapped,
+	}
+}
+
+func (driver) Enter(string, reflect.Type) error {
+	// No particular protocol to follow.
+	return nil
+}
+func (driver) Exit(reflect.Type) {
+	// No particular protocol to follow.
+}
+
+var _ shared.MarshalingDriver = driver{} // Type assertion.
+var _ shared.StreamingDriver = driver{}  // Type assertion.
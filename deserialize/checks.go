@@ -0,0 +1,203 @@
+package deserialize
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+const (
+	decimalBase     = 10
+	luhnMinLength   = 2
+	isbn10Length    = 10
+	isbn10Modulus   = 11
+	isbn13Length    = 13
+	isbn13Weight    = 3
+	ibanMinLength   = 15
+	ibanMaxLength   = 34
+	ibanHeaderSize  = 4
+	ibanCountrySize = 2
+	ibanModulus     = 97
+)
+
+// Built-in format checks, selectable with tag `check`, e.g. `check:"luhn"`.
+var formatChecks = map[string]func(string) error{
+	"luhn":   checkLuhn,
+	"isbn":   checkISBN,
+	"isbn10": checkISBN10,
+	"isbn13": checkISBN13,
+	"iban":   checkIBAN,
+}
+
+// Prepare the check to apply to string values, as specified by tag `check`.
+//
+// Several checks may be specified, e.g. `check:"isbn10,isbn13"`, in which
+// case all of them must pass and all failures are reported.
+//
+// Returns `nil` if there is nothing to check.
+func makeFormatCheck(fieldPath string, tags *tagsPkg.Tags) (func(reflect.Value) error, error) {
+	names, ok := tags.Lookup("check")
+	if !ok {
+		return nil, nil
+	}
+	checks := make([]func(string) error, len(names))
+	for i, name := range names {
+		check, ok := formatChecks[name]
+		if !ok {
+			return nil, fmt.Errorf("at %s, invalid `check` value %q, expected one of luhn, isbn, isbn10, isbn13, iban", fieldPath, name)
+		}
+		checks[i] = check
+	}
+	return func(value reflect.Value) error {
+		violations := []error{}
+		for i, check := range checks {
+			if err := check(value.String()); err != nil {
+				violations = append(violations, fmt.Errorf("failed check %s\n\t * %w", names[i], err))
+			}
+		}
+		return joinViolations(violations)
+	}, nil
+}
+
+// Remove the separators commonly used to make numbers human-readable.
+func stripSeparators(source string) string {
+	return strings.NewReplacer(" ", "", "-", "").Replace(source)
+}
+
+// Check a number (e.g. a credit card number) using the Luhn algorithm.
+//
+// Spaces and dashes are ignored.
+func checkLuhn(source string) error {
+	digits := stripSeparators(source)
+	if len(digits) < luhnMinLength {
+		return fmt.Errorf("expected at least %d digits", luhnMinLength)
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		c := digits[i]
+		if c < '0' || c > '9' {
+			return fmt.Errorf("expected only digits, got %q", c)
+		}
+		digit := int(c - '0')
+		if double {
+			digit *= 2
+			if digit >= decimalBase {
+				digit -= decimalBase - 1
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	if sum%decimalBase != 0 {
+		return errors.New("invalid checksum")
+	}
+	return nil
+}
+
+// Check an ISBN, either ISBN-10 or ISBN-13.
+//
+// Spaces and dashes are ignored.
+func checkISBN(source string) error {
+	if len(stripSeparators(source)) == isbn10Length {
+		return checkISBN10(source)
+	}
+	return checkISBN13(source)
+}
+
+// Check an ISBN-10.
+//
+// Spaces and dashes are ignored.
+func checkISBN10(source string) error {
+	isbn := stripSeparators(source)
+	if len(isbn) != isbn10Length {
+		return fmt.Errorf("expected %d characters, got %d", isbn10Length, len(isbn))
+	}
+	sum := 0
+	for i := 0; i < isbn10Length; i++ {
+		c := isbn[i]
+		var digit int
+		switch {
+		case c >= '0' && c <= '9':
+			digit = int(c - '0')
+		case i == isbn10Length-1 && (c == 'X' || c == 'x'):
+			digit = decimalBase
+		default:
+			return fmt.Errorf("unexpected character %q", c)
+		}
+		sum += (isbn10Length - i) * digit
+	}
+	if sum%isbn10Modulus != 0 {
+		return errors.New("invalid checksum")
+	}
+	return nil
+}
+
+// Check an ISBN-13.
+//
+// Spaces and dashes are ignored.
+func checkISBN13(source string) error {
+	isbn := stripSeparators(source)
+	if len(isbn) != isbn13Length {
+		return fmt.Errorf("expected %d digits, got %d", isbn13Length, len(isbn))
+	}
+	sum := 0
+	for i := 0; i < isbn13Length; i++ {
+		c := isbn[i]
+		if c < '0' || c > '9' {
+			return fmt.Errorf("expected only digits, got %q", c)
+		}
+		weight := 1
+		if i%2 == 1 {
+			weight = isbn13Weight
+		}
+		sum += weight * int(c-'0')
+	}
+	if sum%decimalBase != 0 {
+		return errors.New("invalid checksum")
+	}
+	return nil
+}
+
+// Check an IBAN, using the mod-97 algorithm.
+//
+// Spaces are ignored.
+func checkIBAN(source string) error {
+	iban := strings.ReplaceAll(source, " ", "")
+	if len(iban) < ibanMinLength || len(iban) > ibanMaxLength {
+		return fmt.Errorf("expected between %d and %d characters, got %d", ibanMinLength, ibanMaxLength, len(iban))
+	}
+	for i := 0; i < ibanCountrySize; i++ {
+		if iban[i] < 'A' || iban[i] > 'Z' {
+			return errors.New("expected a country code")
+		}
+	}
+	for i := ibanCountrySize; i < ibanHeaderSize; i++ {
+		if iban[i] < '0' || iban[i] > '9' {
+			return errors.New("expected check digits")
+		}
+	}
+	// Move the first four characters to the end, convert letters to numbers
+	// (A = 10, ..., Z = 35) and compute the remainder modulo 97.
+	rearranged := iban[ibanHeaderSize:] + iban[:ibanHeaderSize]
+	remainder := 0
+	for i := 0; i < len(rearranged); i++ {
+		c := rearranged[i]
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*decimalBase + int(c-'0')) % ibanModulus
+		case c >= 'A' && c <= 'Z':
+			// Letters are converted to two-digit numbers.
+			remainder = (remainder*decimalBase*decimalBase + int(c-'A') + decimalBase) % ibanModulus
+		default:
+			return fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	if remainder != 1 {
+		return errors.New("invalid checksum")
+	}
+	return nil
+}
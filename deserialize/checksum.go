@@ -0,0 +1,160 @@
+package deserialize
+
+import (
+	"bytes"
+	"crypto/md5"  //nolint:gosec
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"reflect"
+	"slices"
+	"strings"
+
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// The hash algorithms supported by tag `hash`.
+//
+// md5 and sha1 are only meant to check payloads produced by legacy systems.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+}
+
+// The error returned when a checksum does not match the fields it covers.
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// A checksum specified with tags `checksumOf` and `hash`, e.g.
+// `checksumOf:"amount,currency" hash:"sha256"`.
+type checksumCheck struct {
+	// The native name of the field containing the checksum.
+	fieldNativeName string
+
+	// The human-readable path to the field, used for error-reporting.
+	fieldPath string
+
+	// The public names of the fields covered by the checksum, in order.
+	sources []string
+
+	// The native names of the fields covered by the checksum, resolved
+	// once all fields are known.
+	sourceNativeNames []string
+
+	// The hash algorithm.
+	algorithm func() hash.Hash
+
+	// If `true`, the checksum is stored as raw bytes. Otherwise, as a hex string.
+	isBytes bool
+}
+
+// Parse the checksum specified with tags `checksumOf` and `hash`.
+//
+// Returns `nil` if there is no such tag.
+//
+// Sources are not resolved yet, see `resolve`.
+func parseChecksum(fieldPath string, fieldNativeName string, fieldType reflect.Type, tags *tagsPkg.Tags) (*checksumCheck, error) {
+	sources := tags.ChecksumOf()
+	algorithmName := tags.Hash()
+	if sources == nil {
+		if algorithmName != nil {
+			return nil, fmt.Errorf("at %s, tag `hash` may only be used with tag `checksumOf`", fieldPath)
+		}
+		return nil, nil
+	}
+	if algorithmName == nil {
+		return nil, fmt.Errorf("at %s, tag `checksumOf` requires tag `hash`, e.g. `hash:\"sha256\"`", fieldPath)
+	}
+	algorithm, ok := checksumAlgorithms[*algorithmName]
+	if !ok {
+		names := make([]string, 0, len(checksumAlgorithms))
+		for name := range checksumAlgorithms {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return nil, fmt.Errorf("at %s, invalid `hash` value %s, expected one of %s", fieldPath, *algorithmName, strings.Join(names, ", "))
+	}
+	isBytes := fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Uint8
+	if fieldType.Kind() != reflect.String && !isBytes {
+		return nil, fmt.Errorf("at %s, tag `checksumOf` may only be used on strings or []byte, got %s", fieldPath, fieldType)
+	}
+	return &checksumCheck{
+		fieldNativeName:   fieldNativeName,
+		fieldPath:         fieldPath,
+		sources:           sources,
+		sourceNativeNames: make([]string, len(sources)),
+		algorithm:         algorithm,
+		isBytes:           isBytes,
+	}, nil
+}
+
+// Resolve the fields covered by the checksum.
+//
+// `nativeNames` maps the public name of each (non-flattened) field of the struct to its native name.
+func (check *checksumCheck) resolve(nativeNames map[string]string) error {
+	for i, source := range check.sources {
+		nativeName, ok := nativeNames[source]
+		if !ok {
+			return fmt.Errorf("at %s, invalid `checksumOf` value, there is no field %s in this struct", check.fieldPath, source)
+		}
+		if nativeName == check.fieldNativeName {
+			return fmt.Errorf("at %s, invalid `checksumOf` value, a checksum cannot cover itself", check.fieldPath)
+		}
+		check.sourceNativeNames[i] = nativeName
+	}
+	return nil
+}
+
+// Recompute the checksum once the struct has been populated and compare it
+// with the value of the field.
+//
+// The checksum covers the values of the fields, in order, separated by `\n`.
+// Strings and `[]byte` are hashed as-is, other values as formatted by `fmt.Sprint`,
+// `nil` pointers as empty strings.
+func (check *checksumCheck) check(result reflect.Value) error {
+	hasher := check.algorithm()
+	for i, nativeName := range check.sourceNativeNames {
+		if i != 0 {
+			_, _ = hasher.Write([]byte{'\n'})
+		}
+		_, _ = hasher.Write(checksumBytes(result.FieldByName(nativeName)))
+	}
+	expected := hasher.Sum(nil)
+
+	field := result.FieldByName(check.fieldNativeName)
+	if check.isBytes {
+		if !bytes.Equal(field.Bytes(), expected) {
+			return errChecksumMismatch
+		}
+		return nil
+	}
+	if !strings.EqualFold(field.String(), hex.EncodeToString(expected)) {
+		return errChecksumMismatch
+	}
+	return nil
+}
+
+// Represent a value as bytes, to be hashed.
+func checksumBytes(value reflect.Value) []byte {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch {
+	case value.Kind() == reflect.String:
+		return []byte(value.String())
+	case value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.Uint8:
+		return value.Bytes()
+	default:
+		return []byte(fmt.Sprint(value))
+	}
+}
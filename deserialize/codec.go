@@ -0,0 +1,54 @@
+package deserialize
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+	"github.com/pasqal-io/godasse/validation"
+)
+
+// Construct a dynamically-typed deserializer for a field decoded by a codec.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `fieldType` the dynamic type for the field being compiled;
+//   - `codecName` the name of the codec in `options.codecs`;
+//   - `tags` the table of tags for this field.
+func makeCodecDeserializer(fieldPath string, fieldType reflect.Type, codecName string, options innerOptions, tags *tagsPkg.Tags, wasPreinitialized bool) (reflectDeserializer, error) {
+	codec, ok := options.codecs[codecName]
+	if !ok || codec == nil {
+		return nil, fmt.Errorf("at %s, unknown codec %s, please register it in `Options.Codecs`", fieldPath, codecName)
+	}
+	if tags.Default() != nil || tags.MethodName() != nil {
+		return nil, fmt.Errorf("at %s, field is decoded with codec %s, it cannot have a `default` or `orMethod`", fieldPath, codecName)
+	}
+	canValidate, err := implementsValidator(fieldType)
+	if err != nil {
+		return nil, err
+	}
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue == nil && wasPreinitialized {
+			// No value? That's ok, we got a value from preinitialization.
+			return nil
+		}
+		decoded, err := codec.Decode(inValue, fieldType)
+		if err != nil {
+			return fmt.Errorf("at %s, expected to be able to decode a %s with codec %s:\n\t * %w", fieldPath, typeName(fieldType), codecName, err)
+		}
+		if !decoded.IsValid() || !decoded.Type().AssignableTo(fieldType) {
+			return fmt.Errorf("at %s, codec %s did not produce a %s", fieldPath, codecName, typeName(fieldType))
+		}
+		if canValidate {
+			resultPtr := reflect.New(fieldType)
+			resultPtr.Elem().Set(decoded)
+			err = call.validate(fieldPath, resultPtr.Interface())
+			if err != nil {
+				return validation.WrapError(fieldPath, err)
+			}
+		}
+		outPtr.Set(decoded)
+		return nil
+	}
+	return result, nil
+}
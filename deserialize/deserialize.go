@@ -46,12 +46,34 @@
 //     the value (this is the only way to provide default values for private fields);
 //   - if a tag `default:"XXX"` is specified, we use this value when a field is not specified
 //     (by opposition, Go would silently insert zero values);
+//   - if a tag `defaultEnv:"XXX"` is specified, we use the content of environment variable
+//     XXX, if it is set, when a field is not specified;
 //   - if a tag `orMethod:"XXX"` is specified, we attempt to call the corresponding method
 //     when a field is not specified (by opposition, Go would silently insert zero values);
+//   - if a tag `optional:""` is specified, we leave the field to its zero value when it is
+//     not specified, as Go would, instead of failing;
 //   - if a tag `initialized:""` is specified, we will not complain
+//   - if a tag `readOnly:"true"` is specified, we reject any input for this field, which
+//     may only be set through `default`, `orMethod` or `Initializer`;
+//   - if a tag `layout:"XXX"` is specified on a `time.Time`, we parse string inputs (including
+//     `default`) with `time.Parse(XXX, ...)`, e.g. `layout:"2006-01-02"` for date-only values;
+//   - if a tag `base:"XXX"` is specified on an integer, we parse string inputs in base XXX,
+//     e.g. `base:"16"` accepts both `0xff` and `ff`, `base:"0"` detects the base from the prefix;
+//   - if a tag `parsers:"XXX,YYY"` is specified, we parse string inputs (including `default`)
+//     with parsers XXX, then YYY, until one succeeds, e.g. `parsers:"rfc3339,unix,date"`,
+//     see `Options.NamedParsers`;
+//   - if a tag `checksumOf:"XXX,YYY" hash:"ZZZ"` is specified on a string or `[]byte`, we recompute
+//     the checksum of fields XXX and YYY with algorithm ZZZ (e.g. `sha256`) and reject mismatches;
+//   - if a tag `when:"XXX"` is specified, we only deserialize the field if value XXX of the
+//     bag of values (see `DeserializeDictWithValues`) is `true`, otherwise we ignore any input
+//     for this field (or reject it, with `when:"XXX,reject"`);
 //   - if a data structure supports `Validator`, we run validation during deserialization
 //     and fail if validation rejects the value (by opposition, in Go, you need to run any
 //     validation step manually, after deserialization completes);
+//   - if a struct supports `shared.DynamicFields`, we also deserialize the keys declared
+//     at runtime by its `Schema()`;
+//   - if the top-level data structure supports `Finalizer`, we run `Finalize()` once the
+//     entire tree has been built and validated;
 //   - we attempt to detect errors early and fail when setting up the deserializer, instead
 //     of ignoring errors and/or failing during deserialization.
 //
@@ -64,19 +86,40 @@
 package deserialize
 
 import (
+	"cmp"
+	"context"
+	"encoding"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"maps"
+	"math"
+	"os"
 	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
+	cborPkg "github.com/pasqal-io/godasse/deserialize/cbor"
+	"github.com/pasqal-io/godasse/deserialize/env"
 	"github.com/pasqal-io/godasse/deserialize/internal"
 	jsonPkg "github.com/pasqal-io/godasse/deserialize/json"
+	json5Pkg "github.com/pasqal-io/godasse/deserialize/json5"
 	"github.com/pasqal-io/godasse/deserialize/kvlist"
 	"github.com/pasqal-io/godasse/deserialize/shared"
 	"github.com/pasqal-io/godasse/deserialize/tags"
 	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+	yamlPkg "github.com/pasqal-io/godasse/deserialize/yaml"
 	"github.com/pasqal-io/godasse/validation"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"golang.org/x/text/language"
 )
 
 // -------- Public API --------
@@ -93,6 +136,21 @@ type Options struct {
 	// If you leave this blank, defaults to "json".
 	MainTagName string
 
+	// Additional tags used for renamings, if `MainTagName` is
+	// not specified for a field.
+	//
+	// Precedence: for each field, we use the first tag present,
+	// looking at `MainTagName` first, then `FallbackTagNames`
+	// in order. If none of these tags is present, we use the
+	// name of the field.
+	//
+	// For instance, with `MainTagName: "query"` and
+	// `FallbackTagNames: []string{"json"}`, a field
+	// tagged `json:"foo"` is read from key `foo`, while
+	// a field tagged `query:"bar" json:"foo"` is read
+	// from key `bar`.
+	FallbackTagNames []string
+
 	// Human-readable information on the nature of data
 	// you'll be deserializing with this deserializer.
 	//
@@ -108,7 +166,210 @@ type Options struct {
 
 	// An unmarshaler, used to deserialize values when they
 	// are provided as []byte or string.
+	//
+	// Called once per deserializer built. Drivers may keep track
+	// of state while building a deserializer, so this should return
+	// a fresh driver each time.
 	Unmarshaler Unmarshaler
+
+	// If `true`, reject any dictionary containing keys that do
+	// not match any field of the struct.
+	//
+	// Keys matching flattened or anonymous fields are considered
+	// as recognized by the outer struct. Structs that implement
+	// their own unmarshaling (e.g. `UnmarshalDict` or `UnmarshalJSON`)
+	// are not checked.
+	//
+	// For KVList deserializers, keys are checked after grouping dotted
+	// keys into nested structs, e.g. `address.cty` is reported as an
+	// unknown field of `address`. Env deserializers require `EnvPrefix`,
+	// as only variables with this prefix are checked.
+	//
+	// Defaults to `false`.
+	DisallowUnknownFields bool
+
+	// If non-empty, the name of a key wrapping the payload, e.g. "data"
+	// for payloads shaped as `{"data": {...}, "meta": {...}}`.
+	//
+	// The top-level deserializer extracts the contents of this key and
+	// deserializes them, ignoring any other key. If the key is missing or
+	// does not contain an object, deserialization fails.
+	//
+	// Not supported by KVList deserializers.
+	Envelope string
+
+	// If `true`, accept boolean inputs for numeric fields,
+	// converting `true` to `1` and `false` to `0`.
+	//
+	// Defaults to `false`.
+	BoolsAsNumbers bool
+
+	// If `true`, reject numbers with a fractional part for integer
+	// fields, e.g. `2.5` for an `int`, instead of truncating them.
+	//
+	// Integral numbers such as `3.0` are still accepted.
+	//
+	// Defaults to `false`.
+	StrictIntegers bool
+
+	// If `true`, decode numbers without loss of precision, e.g. as
+	// `json.Number` rather than `float64` for JSON.
+	//
+	// This is needed to read integers above 2^53, such as large IDs,
+	// which would otherwise be rounded. Fields of type `any` receive
+	// the decoded number, e.g. a `json.Number`.
+	//
+	// Only supported by unmarshalers that implement `shared.NumberDriver`,
+	// e.g. JSON.
+	//
+	// Defaults to `false`.
+	UseNumber bool
+
+	// If `true`, reject objects that contain the same key several times,
+	// e.g. `{"id": 1, "id": 2}`, instead of keeping the last value.
+	//
+	// The error names the duplicated key and its path in the input.
+	//
+	// Only supported by unmarshalers that implement `shared.DuplicateKeysDriver`,
+	// e.g. JSON.
+	//
+	// Defaults to `false`.
+	RejectDuplicateKeys bool
+
+	// If non-empty, for KVList deserializers, a separator used to split
+	// single values into lists, e.g. `a,b,c` into `["a", "b", "c"]`.
+	//
+	// This is useful for query strings, as many clients send `tags=a,b,c`
+	// rather than `tags=a&tags=b&tags=c`. Repeated keys are not split.
+	//
+	// Only applies to fields of type slice or array, unless they
+	// implement `TextUnmarshaler`.
+	//
+	// Defaults to no splitting.
+	ListSeparator string
+
+	// For Env deserializers, a prefix prepended to the name of every
+	// variable, e.g. with `EnvPrefix: "MYAPP_"`, a field tagged
+	// `env:"PORT"` is read from variable `MYAPP_PORT`.
+	EnvPrefix string
+
+	// Custom parsers, used to parse values of a given type when they
+	// are provided as strings, e.g. `time.ParseDuration` for `time.Duration`.
+	//
+	// These parsers take precedence over the built-in parsers for
+	// primitive types. The value returned by the parser must be
+	// convertible to the type.
+	Parsers map[reflect.Type]shared.Parser
+
+	// Custom parsers, by name, used by fields tagged with e.g.
+	// `parsers:"rfc3339,unix"`, tried in order on string inputs until
+	// one of them succeeds.
+	//
+	// These parsers take precedence over the built-in parsers `rfc3339`,
+	// `unix` (seconds since the epoch) and `date` (`2006-01-02`), which
+	// all produce a `time.Time`. The value returned by the parser must
+	// be convertible to the type of the field.
+	NamedParsers map[string]shared.Parser
+
+	// JSON Schemas, by name, used by fields tagged with `schema:"name"`.
+	//
+	// The value of such fields is validated against the schema before
+	// being deserialized.
+	Schemas map[string]*jsonschema.Schema
+
+	// Codecs, by name, used by fields tagged with `codec:"name"`.
+	//
+	// A codec takes over deserialization of the field entirely, which
+	// makes it possible to decode types without implementing any
+	// interface on them.
+	Codecs map[string]shared.Codec
+
+	// String transforms, by name, used by fields tagged with e.g.
+	// `transform:"trim|lower"`, applied in order to string inputs.
+	//
+	// These transforms take precedence over the built-in transforms
+	// `trim`, `lower`, `upper` and `collapseSpaces`.
+	Transforms map[string]func(string) string
+
+	// If `true`, `DeserializeDictPooled` and `DeserializeBytesPooled`
+	// reuse top-level values from a `sync.Pool` instead of allocating
+	// a new value for each call.
+	//
+	// The caller MUST call `Release()` once it is done with the value
+	// and MUST NOT keep any reference to the value, or to anything it
+	// contains, after that: the value is zeroed and handed to another
+	// call. Any such reference (e.g. a slice or a pointer extracted
+	// from the value and stored elsewhere) will observe the changes.
+	//
+	// Only used by `MakeMapDeserializer`.
+	//
+	// Defaults to `false`.
+	Pool bool
+
+	// If specified, numbers provided as strings (e.g. in query strings)
+	// are parsed as formatted for this locale, e.g. `1.234,5` with
+	// `language.German` or `1 234,5` with `language.French`.
+	//
+	// This does not apply to numbers already decoded as numbers (e.g.
+	// JSON numbers), nor to `default` tags, which are always written
+	// as Go literals.
+	//
+	// Dates are not localized, as `golang.org/x/text` does not provide
+	// calendar data (e.g. localized month names).
+	//
+	// Defaults to `language.Und`, i.e. no localization.
+	Locale language.Tag
+
+	// If `true`, slice and array fields also accept objects keyed by
+	// index, e.g. `{"0": "a", "1": "b"}` for `["a", "b"]`, as produced
+	// by some serializers (e.g. PHP's for arrays with missing indices).
+	//
+	// Elements are sorted by numeric key. Keys that are not non-negative
+	// integers are rejected.
+	//
+	// Defaults to `false`.
+	AcceptIndexedObjectsAsArrays bool
+
+	// If non-0, a deadline for each call to a custom method, i.e. the
+	// methods specified with `orMethod` and `Initialize()` or
+	// `InitializeContext()`, which may e.g. fetch defaults from a service.
+	//
+	// If a call takes longer, deserialization fails with an error
+	// `custom method <name> at <path> timed out`. `InitializeContext()`
+	// receives a context that is cancelled at that point.
+	//
+	// Caveat: Go cannot interrupt a goroutine, so the call keeps running
+	// in the background until it returns. Meanwhile, it may still modify
+	// the value it was called on. Methods that can block for a long time
+	// should use `InitializeContext()` and respect cancellation.
+	//
+	// Defaults to `0`, i.e. no timeout. Custom methods are then called
+	// without spawning goroutines.
+	CustomCallTimeout time.Duration
+
+	// If `true`, accept structs in which a flattened or anonymous field
+	// reads the same key as another field, e.g. two embedded structs
+	// that both have a field `Name`. The value of that key is then
+	// deserialized into each of these fields.
+	//
+	// Otherwise, such collisions are reported when building the
+	// deserializer, as they are most likely a mistake.
+	//
+	// Defaults to `false`.
+	AllowFlattenCollisions bool
+
+	// If `true`, when some fields of a struct fail to deserialize, keep
+	// deserializing the other fields, then report all the errors at once.
+	//
+	// The `Validate()` method of the struct, if any, then runs on the
+	// partially-built value, in which failed fields are left to their zero
+	// value, and its error is reported along with the errors of the fields,
+	// unless it is the same error (per `errors.Is`) as one of them, e.g. a
+	// shared sentinel error. Validators must therefore be ready
+	// to see incomplete values.
+	//
+	// Defaults to `false`, i.e. stop at the first error.
+	AccumulateErrors bool
 }
 
 // The de facto JSON type in Go.
@@ -126,6 +387,52 @@ func JSONOptions(root string) Options {
 	}
 }
 
+// A preset fit for consuming JSON5, i.e. JSON extended with comments,
+// trailing commas, unquoted keys, etc.
+//
+// The tag name is `json`.
+//
+// Params:
+//   - root A human-readable root (e.g. the name of the endpoint). Used only
+//     for error reporting. `""` is a perfectly acceptable root.
+func JSON5Options(root string) Options {
+	return Options{
+		MainTagName: JSON,
+		RootPath:    root,
+		Unmarshaler: json5Pkg.Driver,
+	}
+}
+
+// A preset fit for consuming YAML.
+//
+// The tag name is `yaml`.
+//
+// Params:
+//   - root A human-readable root (e.g. the name of the endpoint). Used only
+//     for error reporting. `""` is a perfectly acceptable root.
+func YAMLOptions(root string) Options {
+	return Options{
+		MainTagName: "yaml",
+		RootPath:    root,
+		Unmarshaler: yamlPkg.Driver,
+	}
+}
+
+// A preset fit for consuming CBOR.
+//
+// The tag name is `cbor`.
+//
+// Params:
+//   - root A human-readable root (e.g. the name of the endpoint). Used only
+//     for error reporting. `""` is a perfectly acceptable root.
+func CBOROptions(root string) Options {
+	return Options{
+		MainTagName: "cbor",
+		RootPath:    root,
+		Unmarshaler: cborPkg.Driver,
+	}
+}
+
 // A preset fit for consuming Queries.
 //
 // The tag name is `query`.
@@ -156,64 +463,167 @@ func PathOptions(root string) Options {
 	}
 }
 
+// A preset fit for consuming environment variables.
+//
+// The tag name is `env`. Lists are separated by `,`.
+//
+// Params:
+//   - prefix A prefix prepended to the name of every variable, e.g. `MYAPP_`.
+//     `""` is a perfectly acceptable prefix.
+func EnvOptions(prefix string) Options {
+	return Options{
+		MainTagName:   "env",
+		Unmarshaler:   env.Driver,
+		ListSeparator: env.DefaultSeparator,
+		EnvPrefix:     prefix,
+	}
+}
+
 // A deserializer from strings or buffers.
 type BytesDeserializer[To any] interface {
 	DeserializeString(string) (*To, error)
 	DeserializeBytes([]byte) (*To, error)
+	// Deserialize from a stream, e.g. the body of a HTTP request.
+	//
+	// If the driver supports it, this does not buffer the entire stream.
+	DeserializeReader(io.Reader) (*To, error)
 }
 
 // A deserializers from dictionaries
 //
 // Use this to deserialize e.g. JSON bodies.
+//
+// Once built, a deserializer is safe for concurrent use by multiple goroutines:
+// drivers are only stateful while building the deserializer and any state
+// needed while deserializing is allocated per call.
 type MapDeserializer[To any] interface {
 	BytesDeserializer[To]
 	// Deserialize a single value from a dict.
 	DeserializeDict(shared.Dict) (*To, error)
+	// Deserialize a single value from a dict into a value provided by
+	// the caller, overwriting its previous contents.
+	//
+	// Use this to reuse the same value across calls, e.g. to avoid
+	// allocating a large struct for each request. If deserialization
+	// fails, the value may have been partially overwritten.
+	DeserializeDictInto(shared.Dict, *To) error
+	// Deserialize a single value from a dict, making a bag of values
+	// available to any `validation.Values` in the tree.
+	DeserializeDictWithValues(shared.Dict, map[string]any) (*To, error)
+	// Deserialize a single value from a dict, making a context available
+	// to any `validation.ContextInitializer` or `validation.ContextValidator`
+	// in the tree.
+	DeserializeDictContext(context.Context, shared.Dict) (*To, error)
+	// Deserialize a single value from bytes, making a context available
+	// to any `validation.ContextInitializer` or `validation.ContextValidator`
+	// in the tree.
+	DeserializeBytesContext(context.Context, []byte) (*To, error)
+	// Deserialize a single value from several dicts, by order of priority,
+	// e.g. flags, then environment, then configuration file, then defaults.
+	//
+	// Each field is read from the first dict that contains it. Values are
+	// not merged: a nested struct is read as a whole from the first dict
+	// that contains it.
+	DeserializeLayered([]shared.Dict) (*To, error)
 	// Deserialize a list of values from a list of values.
 	DeserializeList([]shared.Value) ([]To, error)
+	// Deserialize a list of values from a list of values, without
+	// stopping at the first error.
+	DeserializeListResults([]shared.Value) []Result[To]
+	// Deserialize a single value from a dict, reusing values if
+	// `Options.Pool` is set. Call `Release()` once you are done.
+	DeserializeDictPooled(shared.Dict) (Pooled[To], error)
+	// Deserialize a single value from bytes, reusing values if
+	// `Options.Pool` is set. Call `Release()` once you are done.
+	DeserializeBytesPooled([]byte) (Pooled[To], error)
+}
+
+// The result of deserializing one entry from a list.
+//
+// Exactly one of `Value` and `Err` is non-nil.
+type Result[To any] struct {
+	// The index of the entry in the list.
+	Index int
+
+	// The deserialized value, if deserialization succeeded.
+	Value *To
+
+	// The error, if deserialization failed.
+	Err error
 }
 type MapReflectDeserializer interface {
 	// Deserialize a single value from a dict.
 	DeserializeDictTo(shared.Dict, *reflect.Value) error
+	// Deserialize a list of values from a list of values, appending
+	// them to a slice.
+	DeserializeListTo([]shared.Value, *reflect.Value) error
 }
 
 // A deserializer from key, lists of values.
 //
 // Use this to deserialize e.g. query strings.
+//
+// As `MapDeserializer`, this is safe for concurrent use once built.
 type KVListDeserializer[To any] interface {
 	DeserializeKVList(kvlist.KVList) (*To, error)
+	// Deserialize into a value provided by the caller, overwriting its
+	// previous contents, see `MapDeserializer.DeserializeDictInto`.
+	DeserializeKVListInto(kvlist.KVList, *To) error
+	// Deserialize a list of values from indexed keys, e.g.
+	// `item[0].name=foo&item[0].price=3&item[1].name=bar`.
+	//
+	// Keys MUST have the shape `prefix[index].field`, where `prefix` is the
+	// same for all keys (possibly empty) and `index` is a non-negative integer.
+	// Other keys are ignored.
+	//
+	// Indices do not need to be contiguous or ordered: entries are returned
+	// by increasing index, skipping missing indices, so `item[3]` and `item[7]`
+	// produce a list of two elements.
+	DeserializeKVListSlice(kvlist.KVList) ([]To, error)
+	// Deserialize from a map of single values, e.g. the path parameters
+	// provided by most routers.
+	//
+	// This is equivalent to calling `DeserializeKVList` with each value
+	// wrapped in a one-element list.
+	DeserializePathParams(map[string]string) (*To, error)
 }
 type KVListReflectDeserializer interface {
 	DeserializeKVListTo(kvlist.KVList, *reflect.Value) error
 }
 
+// A deserializer from environment variables.
+type EnvDeserializer[To any] interface {
+	// Deserialize from the environment of the current process.
+	DeserializeEnv() (*To, error)
+	// Deserialize from a list of variables, in the format of `os.Environ()`.
+	DeserializeEnviron([]string) (*To, error)
+}
+
 // Create a deserializer from Dict.
 func MakeMapDeserializer[T any](options Options) (MapDeserializer[T], error) {
-	tagName := options.MainTagName
-	if tagName == "" {
-		return nil, errors.New("missing option MainTagName")
+	innerOptions, err := makeInnerOptions(options)
+	if err != nil {
+		return nil, err
 	}
-	if options.Unmarshaler == nil {
-		return nil, errors.New("please specify an unmarshaler")
+	result, err := makeOuterStructDeserializer[T](options.RootPath, innerOptions)
+	if err != nil {
+		return nil, err
 	}
-	return makeOuterStructDeserializer[T](options.RootPath, innerOptions{
-		renamingTagName: tagName,
-		unmarshaler:     options.Unmarshaler(),
-	})
+	if options.Pool {
+		result.pool = &sync.Pool{
+			New: func() any {
+				return new(T)
+			},
+		}
+	}
+	return result, nil
 }
 func MakeMapDeserializerFromReflect(options Options, typ reflect.Type) (MapReflectDeserializer, error) {
-	tagName := options.MainTagName
-	if tagName == "" {
-		return nil, errors.New("missing option MainTagName")
-	}
-	if options.Unmarshaler == nil {
-		return nil, errors.New("please specify an unmarshaler")
+	innerOptions, err := makeInnerOptions(options)
+	if err != nil {
+		return nil, err
 	}
 	var placeholder = reflect.New(typ).Elem()
-	innerOptions := innerOptions{
-		renamingTagName: tagName,
-		unmarshaler:     options.Unmarshaler(),
-	}
 
 	noTags := tags.Empty()
 	reflectDeserializer, err := makeFieldDeserializerFromReflect(options.RootPath, typ, innerOptions, &noTags, placeholder, false, false)
@@ -223,23 +633,96 @@ func MakeMapDeserializerFromReflect(options Options, typ reflect.Type) (MapRefle
 	}
 	return mapReflectDeserializer{
 		reflectDeserializer: reflectDeserializer,
+		typ:                 typ,
+		options:             innerOptions,
+		path:                options.RootPath,
 	}, nil
 
 }
 
+// Create a deserializer for a single value of type `typ`, without going
+// through a struct, e.g. to deserialize the elements of a custom container.
+//
+// The resulting function deserializes a value (e.g. obtained with
+// `WrapValue` from the driver) into `out`, which MUST be settable and
+// have type `typ`. A `nil` value is handled as a missing value.
+//
+// As `MapDeserializer`, the resulting function is safe for concurrent use.
+func MakeValueDeserializer(options Options, typ reflect.Type) (func(out *reflect.Value, value shared.Value) error, error) {
+	innerOptions, err := makeInnerOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	if options.Envelope != "" {
+		return nil, errors.New("option Envelope is not supported for value deserializers")
+	}
+	var placeholder = reflect.New(typ).Elem()
+
+	noTags := tags.Empty()
+	reflectDeserializer, err := makeFieldDeserializerFromReflect(options.RootPath, typ, innerOptions, &noTags, placeholder, false, false)
+	if err != nil {
+		return nil, err
+	}
+	return func(out *reflect.Value, value shared.Value) error {
+		if out.Type() != typ || !out.CanSet() {
+			return fmt.Errorf("cannot deserialize a %s into a %s, expected a settable %s", typeName(typ), out.Type(), typeName(typ))
+		}
+		return reflectDeserializer(out, value, newCallData())
+	}, nil
+}
+
 type mapReflectDeserializer struct {
 	reflectDeserializer reflectDeserializer
+	typ                 reflect.Type
+	options             innerOptions
+	path                string
 }
 
 func (mrd mapReflectDeserializer) DeserializeDictTo(dict shared.Dict, reflectOut *reflect.Value) error {
+	dict, err := mrd.options.unwrapEnvelope(mrd.path, dict)
+	if err != nil {
+		return err
+	}
 	input := dict.AsValue()
-	err := mrd.reflectDeserializer(reflectOut, input)
+	err = mrd.reflectDeserializer(reflectOut, input, newCallData())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// Deserialize a list of values, appending them to `reflectOut`.
+//
+// `reflectOut` MUST be a settable slice whose elements have the type
+// for which this deserializer was built.
+func (mrd mapReflectDeserializer) DeserializeListTo(list []shared.Value, reflectOut *reflect.Value) error {
+	if reflectOut.Kind() != reflect.Slice || reflectOut.Type().Elem() != mrd.typ {
+		return fmt.Errorf("cannot deserialize a list of %s into a %s", typeName(mrd.typ), reflectOut.Type())
+	}
+	result := *reflectOut
+	for i, entry := range list {
+		if mrd.options.envelope != "" {
+			dict, ok := entry.AsDict()
+			if !ok {
+				return fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, newTypeMismatchError(fmt.Sprint("[", i, "]"), typeName(mrd.typ), entry.Interface(), mismatchObject))
+			}
+			dict, err := mrd.options.unwrapEnvelope(mrd.path, dict)
+			if err != nil {
+				return fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, err)
+			}
+			entry = dict.AsValue()
+		}
+		element := reflect.New(mrd.typ).Elem()
+		err := mrd.reflectDeserializer(&element, entry, newCallData())
+		if err != nil {
+			return fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, err)
+		}
+		result = reflect.Append(result, element)
+	}
+	reflectOut.Set(result)
+	return nil
+}
+
 // Create a deserializer from (key, value list).
 //
 // `T` MUST have the following shape:
@@ -253,46 +736,60 @@ func (mrd mapReflectDeserializer) DeserializeDictTo(dict shared.Dict, reflectOut
 // Where each `TypeX` is either
 // - int, intX, uintX, float, string, bool
 // - a type that supports `UnmarshalText`.
+//
+// Fields may also be nested structs with the same shape, read from dotted
+// keys, e.g. `address.city` for field `City` of field `Address`.
 func MakeKVListDeserializer[T any](options Options) (KVListDeserializer[T], error) {
-	tagName := options.MainTagName
-	if tagName == "" {
-		return nil, errors.New("missing option MainTagName")
-	}
-	if options.Unmarshaler == nil {
-		return nil, errors.New("please specify an unmarshaler")
+	innerOptions, err := makeInnerOptions(options)
+	if err != nil {
+		return nil, err
 	}
-	innerOptions := innerOptions{
-		renamingTagName: tagName,
-		unmarshaler:     options.Unmarshaler(),
+	if options.Envelope != "" {
+		return nil, errors.New("option Envelope is not supported for KVList deserializers")
 	}
 	wrapped, err := makeOuterStructDeserializer[T](options.RootPath, innerOptions)
 	if err != nil {
 		return nil, err
 	}
-	deserializer := func(value kvlist.KVList, out *T) error {
+	deserializer := func(value kvlist.KVList, out *T, call *callData) error {
 		normalized := make(map[string]any)
 		err := deListMap[T](normalized, value, innerOptions)
 		if err != nil {
 			return fmt.Errorf("error attempting to deserialize from a list of entries:\n\t * %w", err)
 		}
-		return wrapped.deserializer(kvlist.MakeRootDict(normalized), out)
+		return wrapped.deserializer(kvlist.MakeRootDict(normalized), out, call)
 	}
 	return kvListDeserializer[T]{
 		deserializer: deserializer,
 		options:      innerOptions,
 	}, nil
 }
-func MakeKVDeserializerFromReflect(options Options, typ reflect.Type) (KVListReflectDeserializer, error) {
-	tagName := options.MainTagName
-	if tagName == "" {
-		return nil, errors.New("missing option MainTagName")
+
+// Create a deserializer from environment variables.
+//
+// `T` MUST have the same shape as for `MakeKVListDeserializer`.
+func MakeEnvDeserializer[T any](options Options) (EnvDeserializer[T], error) {
+	if options.DisallowUnknownFields && options.EnvPrefix == "" {
+		// Without a prefix, every variable of the environment would be an unknown field.
+		return nil, errors.New("option DisallowUnknownFields requires option EnvPrefix for env deserializers")
 	}
-	if options.Unmarshaler == nil {
-		return nil, errors.New("please specify an unmarshaler")
+	wrapped, err := MakeKVListDeserializer[T](options)
+	if err != nil {
+		return nil, err
+	}
+	return envDeserializer[T]{
+		wrapped: wrapped,
+		prefix:  options.EnvPrefix,
+	}, nil
+}
+
+func MakeKVDeserializerFromReflect(options Options, typ reflect.Type) (KVListReflectDeserializer, error) {
+	innerOptions, err := makeInnerOptions(options)
+	if err != nil {
+		return nil, err
 	}
-	innerOptions := innerOptions{
-		renamingTagName: tagName,
-		unmarshaler:     options.Unmarshaler(),
+	if options.Envelope != "" {
+		return nil, errors.New("option Envelope is not supported for KVList deserializers")
 	}
 	var placeholder = reflect.New(typ).Elem()
 	noTags := tags.Empty()
@@ -321,7 +818,7 @@ func (kvrd kvReflectDeserializer) DeserializeKVListTo(value kvlist.KVList, refle
 		return err
 	}
 
-	err = kvrd.reflectDeserializer(reflectOut, kvlist.MakeRootDict(normalized).AsValue())
+	err = kvrd.reflectDeserializer(reflectOut, kvlist.MakeRootDict(normalized).AsValue(), newCallData())
 	if err != nil {
 		return err
 	}
@@ -352,84 +849,588 @@ func (e CustomDeserializerError) Unwrap() error {
 
 var _ error = CustomDeserializerError{} //nolint:exhaustruct
 
-// ----------------- Private
+// An error that arises because a value is missing from the input, e.g. a
+// field without `default`, `orMethod` or `optional`.
+//
+// Use `errors.As` to distinguish missing values from invalid values.
+type MissingFieldError struct {
+	// The human-readable path to the missing value, e.g. `FetchRequest.number`.
+	Path string
 
-type innerOptions struct {
-	// The name of tag used for renamings (e.g. "json").
-	renamingTagName string
+	// The human-readable type expected at this path, e.g. `uint8`.
+	Type string
 
-	// The instance of the unmarshaling driver.
-	unmarshaler shared.Driver
+	// How the message describes the missing value.
+	description missingDescription
 }
 
-// A deserializer from (key, value) maps.
-type mapDeserializer[T any] struct {
-	deserializer func(value shared.Dict, out *T) error
-	options      innerOptions
-}
+// How the message of a `MissingFieldError` describes the missing value.
+type missingDescription int
+
+const (
+	missingValue missingDescription = iota
+	missingObject
+	missingArray
+	missingRequired
+	missingAtLeastOne
+	missingAtLeast
+	missingEnvelope
+)
 
-func (me mapDeserializer[T]) DeserializeBytes(source []byte) (*T, error) {
-	unmarshaler := me.options.unmarshaler
-	dict := new(any)
-	if err := unmarshaler.Unmarshal(source, dict); err != nil {
-		return nil, fmt.Errorf("failed to deserialize source: \n\t * %w", err)
-	}
-	asDict, ok := unmarshaler.WrapValue(*dict).AsDict()
-	if !ok {
-		return nil, errors.New("failed to deserialize as a dictionary")
+// Return the user-facing message.
+func (e MissingFieldError) Error() string {
+	switch e.description {
+	case missingObject:
+		return fmt.Sprintf("missing object value at %s, expected %s", e.Path, e.Type)
+	case missingArray:
+		// Historical message, kept for compatibility.
+		return fmt.Sprintf("missing value at %s[], expected an array of %s", e.Path, e.Path)
+	case missingRequired:
+		return fmt.Sprintf("missing required value at %s", e.Path)
+	case missingAtLeastOne:
+		return fmt.Sprintf("missing value at %s, expected at least one of %s", e.Path, e.Type)
+	case missingAtLeast:
+		return fmt.Sprintf("missing value at %s, expected at least %s", e.Path, e.Type)
+	case missingEnvelope:
+		return fmt.Sprintf("missing envelope at %s, expected %s", e.Path, e.Type)
+	default:
+		return fmt.Sprintf("missing value at %s, expected %s", e.Path, e.Type)
 	}
-	return me.DeserializeDict(asDict)
 }
 
-func (me mapDeserializer[T]) DeserializeString(source string) (*T, error) {
-	return me.DeserializeBytes([]byte(source))
+var _ error = MissingFieldError{} //nolint:exhaustruct
+
+// An error that arises because a value is present in the input but cannot
+// be converted to the expected type, e.g. a string where a number is expected.
+//
+// Use `errors.As` to distinguish invalid values from missing values.
+type TypeMismatchError struct {
+	// The human-readable path to the invalid value, e.g. `FetchRequest.number`.
+	Path string
+
+	// The human-readable type expected at this path, e.g. `uint8`.
+	Expected string
+
+	// The kind of the value actually found, e.g. `string`, or `nil`.
+	GotKind string
+
+	// The value actually found.
+	GotValue any
+
+	// How the message describes the expected value.
+	description mismatchDescription
+
+	// If non-nil, the reason for which the value could not be converted.
+	cause error
 }
 
-func (me mapDeserializer[T]) DeserializeDict(value shared.Dict) (*T, error) {
-	out := new(T)
-	err := me.deserializer(value, out)
-	if err != nil {
-		return nil, err
+// How the message of a `TypeMismatchError` describes the expected value.
+type mismatchDescription int
+
+const (
+	mismatchValue mismatchDescription = iota
+	mismatchObject
+	mismatchArray
+	mismatchInteger
+	mismatchRange
+)
+
+// Construct a `TypeMismatchError` for a value `got` found at `path`.
+func newTypeMismatchError(path string, expected string, got any, description mismatchDescription) TypeMismatchError {
+	return TypeMismatchError{
+		Path:        path,
+		Expected:    expected,
+		GotKind:     kindOf(got),
+		GotValue:    got,
+		description: description,
+		cause:       nil,
 	}
-	return out, nil
 }
 
-func (me mapDeserializer[T]) DeserializeList(list []shared.Value) ([]T, error) {
-	result := []T{}
-	for i, entry := range list {
-		if dict, ok := entry.AsDict(); ok {
-			out := new(T)
-			err := me.deserializer(dict, out)
-			if err != nil {
-				return []T{}, fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, err)
-			}
-			result = append(result, *out)
+// Return the user-facing message.
+func (e TypeMismatchError) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("invalid value at %s, expected %s\n\t * %s", e.Path, e.Expected, e.cause.Error())
+	}
+	switch e.description {
+	case mismatchObject:
+		if e.Expected == "" {
+			return fmt.Sprintf("invalid value at %s, expected an object, got %s", e.Path, e.GotKind)
 		}
+		return fmt.Sprintf("invalid value at %s, expected an object of type %s, got %s", e.Path, e.Expected, e.GotKind)
+	case mismatchArray:
+		return fmt.Sprintf("invalid value at %s, expected an array of type %s, got %v", e.Path, e.Expected, e.GotValue)
+	case mismatchInteger:
+		return fmt.Sprintf("invalid value at %s, expected an integer, got %v", e.Path, e.GotValue)
+	case mismatchRange:
+		return fmt.Sprintf("value %v out of range for %s at %s", e.GotValue, e.Expected, e.Path)
+	default:
+		return fmt.Sprintf("invalid value at %s, expected %s, got %v", e.Path, e.Expected, e.GotValue)
 	}
-	return result, nil
 }
 
-// A deserializer from (key, []string) maps.
-type kvListDeserializer[T any] struct {
-	deserializer func(value kvlist.KVList, out *T) error
-	options      innerOptions
+// Return the reason for which the value could not be converted, if any.
+func (e TypeMismatchError) Unwrap() error {
+	return e.cause
 }
 
-func (me kvListDeserializer[T]) DeserializeKVList(value kvlist.KVList) (*T, error) {
-	out := new(T)
-	err := me.deserializer(value, out)
-	if err != nil {
-		return nil, err
+var _ error = TypeMismatchError{} //nolint:exhaustruct
+
+// Return a human-readable kind for a value, e.g. `string`, or `nil`.
+func kindOf(value any) string {
+	if value == nil {
+		return "nil"
 	}
-	return out, nil
+	if _, ok := value.(json.Number); ok {
+		return "number"
+	}
+	return reflect.ValueOf(value).Kind().String()
 }
 
-// Convert a `map[string] []string` (as provided e.g. by the query parser) into a `Dict`
-// (as consumed by this parsing mechanism).
-func deListMapReflect(typ reflect.Type, outMap map[string]any, inMap map[string][]string, options innerOptions) error {
-	if typ.Kind() != reflect.Struct {
-		return fmt.Errorf("cannot implement a MapListDeserializer without a struct, got %s", typ.Name())
-	}
+// ----------------- Private
+
+type innerOptions struct {
+	// The names of tags used for renamings (e.g. "json"), by order
+	// of precedence.
+	renamingTagNames []string
+
+	// The instance of the unmarshaling driver.
+	unmarshaler shared.Driver
+
+	// If `true`, reject unknown keys in structs.
+	disallowUnknownFields bool
+
+	// If non-empty, the key wrapping the payload.
+	envelope string
+
+	// If `true`, accept `true`/`false` for numeric fields.
+	boolsAsNumbers bool
+
+	// If `true`, reject numbers with a fractional part for integer fields.
+	strictIntegers bool
+
+	// If `true`, the unmarshaler decodes numbers without loss of precision.
+	useNumber bool
+
+	// If `true`, the unmarshaler rejects duplicate keys.
+	rejectDuplicateKeys bool
+
+	// If non-empty, the separator used to split KVList values into lists.
+	listSeparator string
+
+	// Custom parsers, by type.
+	parsers map[reflect.Type]shared.Parser
+
+	// Custom parsers, by name.
+	namedParsers map[string]shared.Parser
+
+	// JSON Schemas, by name.
+	schemas map[string]*jsonschema.Schema
+
+	// Codecs, by name.
+	codecs map[string]shared.Codec
+
+	// String transforms, by name.
+	transforms map[string]func(string) string
+
+	// The locale used to parse numbers provided as strings.
+	locale language.Tag
+
+	// If non-nil, a replacer converting numbers formatted for `locale`
+	// into numbers as expected by `strconv`.
+	numberLocalizer *strings.Replacer
+
+	// If `true`, slices also accept objects keyed by index.
+	acceptIndexedObjectsAsArrays bool
+
+	// If non-0, the deadline for each call to a custom method.
+	customCallTimeout time.Duration
+
+	// If `true`, accept several flattened fields reading the same key.
+	allowFlattenCollisions bool
+	// If `true`, report the errors of all the fields of a struct, then its validation error.
+	accumulateErrors bool
+
+	// Set to `true` once we have built a deserializer for raw values, in which
+	// case the driver must keep the bytes it decodes, see `bytesDriver`.
+	//
+	// Atomic, as dynamic fields are built while deserializing.
+	capturesRaw *atomic.Bool
+}
+
+// The driver used to decode bytes at the top-level.
+func (options innerOptions) bytesDriver() shared.Driver {
+	if options.capturesRaw.Load() {
+		// We have checked that the driver is a `shared.RawDriver` while building the deserializer.
+		return options.unmarshaler.(shared.RawDriver).WithKeepRaw() //nolint:forcetypeassert
+	}
+	return options.unmarshaler
+}
+
+// Check the public options and convert them into inner options.
+func makeInnerOptions(options Options) (innerOptions, error) {
+	tagName := options.MainTagName
+	if tagName == "" {
+		return innerOptions{}, errors.New("missing option MainTagName")
+	}
+	if options.Unmarshaler == nil {
+		return innerOptions{}, errors.New("please specify an unmarshaler")
+	}
+	unmarshaler := options.Unmarshaler()
+	if options.UseNumber {
+		numberDriver, ok := unmarshaler.(shared.NumberDriver)
+		if !ok {
+			return innerOptions{}, errors.New("option UseNumber is not supported by this unmarshaler")
+		}
+		unmarshaler = numberDriver.WithUseNumber()
+	}
+	if options.RejectDuplicateKeys {
+		duplicateKeysDriver, ok := unmarshaler.(shared.DuplicateKeysDriver)
+		if !ok {
+			return innerOptions{}, errors.New("option RejectDuplicateKeys is not supported by this unmarshaler")
+		}
+		unmarshaler = duplicateKeysDriver.WithRejectDuplicateKeys()
+	}
+	return innerOptions{
+		renamingTagNames:      append([]string{tagName}, options.FallbackTagNames...),
+		unmarshaler:           unmarshaler,
+		disallowUnknownFields: options.DisallowUnknownFields,
+		envelope:              options.Envelope,
+		boolsAsNumbers:        options.BoolsAsNumbers,
+		strictIntegers:        options.StrictIntegers,
+		useNumber:             options.UseNumber,
+		rejectDuplicateKeys:   options.RejectDuplicateKeys,
+		listSeparator:         options.ListSeparator,
+		parsers:               maps.Clone(options.Parsers),
+		namedParsers:          maps.Clone(options.NamedParsers),
+		schemas:               maps.Clone(options.Schemas),
+		codecs:                maps.Clone(options.Codecs),
+		transforms:            maps.Clone(options.Transforms),
+		locale:                options.Locale,
+		numberLocalizer:       makeNumberLocalizer(options.Locale),
+
+		acceptIndexedObjectsAsArrays: options.AcceptIndexedObjectsAsArrays,
+		customCallTimeout:            options.CustomCallTimeout,
+		allowFlattenCollisions:       options.AllowFlattenCollisions,
+		accumulateErrors:             options.AccumulateErrors,
+		capturesRaw:                  new(atomic.Bool),
+	}, nil
+}
+
+// Find a parser for values of type `typ` provided as strings.
+//
+// Custom parsers take precedence over built-in parsers.
+func (options innerOptions) lookupParser(typ reflect.Type) *shared.Parser {
+	if parser, ok := options.parsers[typ]; ok {
+		return &parser
+	}
+	return shared.LookupParser(typ)
+}
+
+// If we have an envelope, extract its contents.
+func (options innerOptions) unwrapEnvelope(path string, value shared.Dict) (shared.Dict, error) {
+	if options.envelope == "" {
+		return value, nil
+	}
+	wrapped, ok := value.Lookup(options.envelope)
+	if !ok {
+		return nil, MissingFieldError{Path: fmt.Sprint(path, ".", options.envelope), Type: "an object", description: missingEnvelope}
+	}
+	result, ok := wrapped.AsDict()
+	if !ok {
+		return nil, newTypeMismatchError(fmt.Sprint(path, ".", options.envelope), "", wrapped.Interface(), mismatchObject)
+	}
+	return result, nil
+}
+
+// Data specific to a single call to a deserializer.
+//
+// By opposition to `innerOptions`, which is shared by all calls to a
+// deserializer, a new `callData` is created for each call.
+type callData struct {
+	// A bag of values to pass to `validation.Values`, or `nil`.
+	values map[string]any
+
+	// The context to pass to `validation.ContextInitializer` and `validation.ContextValidator`.
+	ctx context.Context
+
+	// A pointer to the struct whose fields are being deserialized, if any.
+	//
+	// Passed to `orMethod` constructors that take the struct as argument.
+	self reflect.Value
+}
+
+func newCallData() *callData {
+	return &callData{
+		values: nil,
+		ctx:    context.Background(),
+		self:   reflect.Value{},
+	}
+}
+
+// Call `InitializeContext()` or `Initialize()` on `ptr`, if available.
+//
+// If `timeout` is non-0, give up after `timeout`, see `callWithTimeout`.
+// `InitializeContext()` receives a context that is cancelled at that point.
+//
+// Returns `true` if `ptr` supports initialization.
+func (call *callData) initialize(path string, timeout time.Duration, ptr any) (bool, error) {
+	if initializer, ok := ptr.(validation.ContextInitializer); ok {
+		ctx := call.ctx
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		_, err := callWithTimeout(timeout, "InitializeContext", path, func() (struct{}, error) {
+			return struct{}{}, initializer.InitializeContext(ctx) //nolint:wrapcheck
+		})
+		return true, err
+	}
+	if initializer, ok := ptr.(validation.Initializer); ok {
+		_, err := callWithTimeout(timeout, "Initialize", path, func() (struct{}, error) {
+			return struct{}{}, initializer.Initialize() //nolint:wrapcheck
+		})
+		return true, err
+	}
+	return false, nil
+}
+
+// Call `ValidateContext()`, `ValidateAt()` or `Validate()` on `ptr`, if available.
+//
+// `path` is the human-readable path to `ptr`, passed to `ValidateAt()`.
+func (call *callData) validate(path string, ptr any) error {
+	if validator, ok := ptr.(validation.ContextValidator); ok {
+		return validator.ValidateContext(call.ctx) //nolint:wrapcheck
+	}
+	if validator, ok := ptr.(validation.PathAwareValidator); ok {
+		return validator.ValidateAt(path) //nolint:wrapcheck
+	}
+	if validator, ok := ptr.(validation.Validator); ok {
+		return validator.Validate() //nolint:wrapcheck
+	}
+	return nil
+}
+
+// If we have a bag of values, pass a copy to `ptr`.
+func (call *callData) setValues(ptr any) {
+	if call.values == nil {
+		return
+	}
+	if receiver, ok := ptr.(validation.Values); ok {
+		receiver.SetValues(maps.Clone(call.values))
+	}
+}
+
+// A deserializer from (key, value) maps.
+type mapDeserializer[T any] struct {
+	deserializer func(value shared.Dict, out *T, call *callData) error
+	options      innerOptions
+
+	// If `Options.Pool` is set, the pool of top-level values. Otherwise, `nil`.
+	pool *sync.Pool
+}
+
+func (me mapDeserializer[T]) DeserializeBytes(source []byte) (*T, error) {
+	return me.DeserializeBytesContext(context.Background(), source)
+}
+
+func (me mapDeserializer[T]) DeserializeBytesContext(ctx context.Context, source []byte) (*T, error) {
+	unmarshaler := me.options.bytesDriver()
+	dict := new(any)
+	if err := unmarshaler.Unmarshal(source, dict); err != nil {
+		return nil, fmt.Errorf("failed to deserialize source: \n\t * %w", err)
+	}
+	asDict, ok := unmarshaler.WrapValue(*dict).AsDict()
+	if !ok {
+		return nil, errors.New("failed to deserialize as a dictionary")
+	}
+	return me.DeserializeDictContext(ctx, asDict)
+}
+
+func (me mapDeserializer[T]) DeserializeReader(source io.Reader) (*T, error) {
+	unmarshaler := me.options.bytesDriver()
+	dict := new(any)
+	if err := shared.UnmarshalReader(unmarshaler, source, dict); err != nil {
+		return nil, fmt.Errorf("failed to deserialize source: \n\t * %w", err)
+	}
+	asDict, ok := unmarshaler.WrapValue(*dict).AsDict()
+	if !ok {
+		return nil, errors.New("failed to deserialize as a dictionary")
+	}
+	return me.DeserializeDict(asDict)
+}
+
+func (me mapDeserializer[T]) DeserializeString(source string) (*T, error) {
+	return me.DeserializeBytes([]byte(source))
+}
+
+func (me mapDeserializer[T]) DeserializeDict(value shared.Dict) (*T, error) {
+	return me.DeserializeDictContext(context.Background(), value)
+}
+
+func (me mapDeserializer[T]) DeserializeDictInto(value shared.Dict, out *T) error {
+	if out == nil {
+		return errors.New("cannot deserialize into a nil pointer")
+	}
+	return me.deserializer(value, out, newCallData())
+}
+
+func (me mapDeserializer[T]) DeserializeDictContext(ctx context.Context, value shared.Dict) (*T, error) {
+	out := new(T)
+	call := newCallData()
+	call.ctx = ctx
+	err := me.deserializer(value, out, call)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (me mapDeserializer[T]) DeserializeDictWithValues(value shared.Dict, values map[string]any) (*T, error) {
+	out := new(T)
+	call := newCallData()
+	call.values = values
+	if call.values == nil {
+		// Distinguish between "no bag" and "an empty bag".
+		call.values = make(map[string]any)
+	}
+	err := me.deserializer(value, out, call)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (me mapDeserializer[T]) DeserializeLayered(layers []shared.Dict) (*T, error) {
+	return me.DeserializeDict(internal.MakeLayeredDict(layers))
+}
+
+func (me mapDeserializer[T]) DeserializeList(list []shared.Value) ([]T, error) {
+	result := []T{}
+	for i, entry := range list {
+		if dict, ok := entry.AsDict(); ok {
+			out := new(T)
+			err := me.deserializer(dict, out, newCallData())
+			if err != nil {
+				return []T{}, fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, err)
+			}
+			result = append(result, *out)
+		}
+	}
+	return result, nil
+}
+
+func (me mapDeserializer[T]) DeserializeListResults(list []shared.Value) []Result[T] {
+	result := make([]Result[T], len(list))
+	for i, entry := range list {
+		result[i].Index = i
+		dict, ok := entry.AsDict()
+		if !ok {
+			result[i].Err = fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, newTypeMismatchError(fmt.Sprint("[", i, "]"), typeName(reflect.TypeOf(new(T)).Elem()), entry.Interface(), mismatchObject))
+			continue
+		}
+		out := new(T)
+		err := me.deserializer(dict, out, newCallData())
+		if err != nil {
+			result[i].Err = fmt.Errorf("failed to deserialize entry %d: \n\t * %w", i, err)
+			continue
+		}
+		result[i].Value = out
+	}
+	return result
+}
+
+// A deserializer from (key, []string) maps.
+type kvListDeserializer[T any] struct {
+	deserializer func(value kvlist.KVList, out *T, call *callData) error
+	options      innerOptions
+}
+
+func (me kvListDeserializer[T]) DeserializeKVList(value kvlist.KVList) (*T, error) {
+	out := new(T)
+	err := me.deserializer(value, out, newCallData())
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (me kvListDeserializer[T]) DeserializeKVListInto(value kvlist.KVList, out *T) error {
+	if out == nil {
+		return errors.New("cannot deserialize into a nil pointer")
+	}
+	return me.deserializer(value, out, newCallData())
+}
+
+func (me kvListDeserializer[T]) DeserializePathParams(params map[string]string) (*T, error) {
+	value := make(kvlist.KVList, len(params))
+	for k, v := range params {
+		value[k] = []string{v}
+	}
+	return me.DeserializeKVList(value)
+}
+
+func (me kvListDeserializer[T]) DeserializeKVListSlice(value kvlist.KVList) ([]T, error) {
+	indices, entries, err := splitIndexedKVList(value)
+	if err != nil {
+		return []T{}, err
+	}
+	result := make([]T, len(entries))
+	for i, entry := range entries {
+		err := me.deserializer(entry, &result[i], newCallData())
+		if err != nil {
+			return []T{}, fmt.Errorf("failed to deserialize entry %d: \n\t * %w", indices[i], err)
+		}
+	}
+	return result, nil
+}
+
+// A deserializer from environment variables.
+type envDeserializer[T any] struct {
+	wrapped KVListDeserializer[T]
+	prefix  string
+}
+
+func (me envDeserializer[T]) DeserializeEnv() (*T, error) {
+	return me.DeserializeEnviron(os.Environ())
+}
+
+func (me envDeserializer[T]) DeserializeEnviron(environ []string) (*T, error) {
+	return me.wrapped.DeserializeKVList(env.Environ(environ, me.prefix)) //nolint:wrapcheck
+}
+
+// Convert a `map[string] []string` (as provided e.g. by the query parser) into a `Dict`
+// (as consumed by this parsing mechanism).
+//
+// Nested structs are read from dotted keys, e.g. field `City` of field `Address`
+// is read from key `address.city`.
+//
+// If `options.disallowUnknownFields`, keys that match no field are copied as is,
+// to be rejected by the struct deserializer.
+func deListMapReflect(typ reflect.Type, outMap map[string]any, inMap map[string][]string, options innerOptions) error {
+	consumed := make(map[string]struct{})
+	err := deListMapFields(typ, outMap, inMap, options, consumed)
+	if err != nil {
+		return err
+	}
+	if options.disallowUnknownFields {
+		for key, values := range inMap {
+			if _, ok := consumed[key]; !ok {
+				outMap[key] = values
+			}
+		}
+	}
+	return nil
+}
+
+// Convert the fields of `typ`, as `deListMapReflect`.
+//
+// `consumed` receives the keys of `inMap` read by these fields.
+func deListMapFields(typ reflect.Type, outMap map[string]any, inMap map[string][]string, options innerOptions, consumed map[string]struct{}) error {
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot implement a MapListDeserializer without a struct, got %s", typ.Name())
+	}
 
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
@@ -440,21 +1441,68 @@ func deListMapReflect(typ reflect.Type, outMap map[string]any, inMap map[string]
 		}
 
 		// We'll use the public field name both to fetch from `value` and to write to `out`.
-		publicFieldName := tags.PublicFieldName(options.renamingTagName)
+		publicFieldName := tags.PublicFieldName(options.renamingTagNames...)
 		if publicFieldName == nil {
 			publicFieldName = &field.Name
 		}
+		consumed[*publicFieldName] = struct{}{}
 
 		switch {
 		case field.Type.Kind() == reflect.Array:
 			fallthrough
 		case field.Type.Kind() == reflect.Slice:
-			outMap[*publicFieldName] = inMap[*publicFieldName]
+			values, ok := inMap[*publicFieldName]
+			isText := reflect.PointerTo(field.Type).Implements(textUnmarshalerInterface)
+			if !ok && isText {
+				// A single value, e.g. a UUID, leave it missing.
+				continue
+			}
+			if options.listSeparator != "" && len(values) == 1 && !isText {
+				if values[0] == "" {
+					values = []string{}
+				} else {
+					values = strings.Split(values[0], options.listSeparator)
+				}
+			}
+			outMap[*publicFieldName] = values
 		case field.Type.Kind() == reflect.Struct && (tags.IsFlattened() || field.Anonymous):
-			err = deListMapReflect(field.Type, outMap, inMap, options)
+			err = deListMapFields(field.Type, outMap, inMap, options, consumed)
+			if err != nil {
+				return err
+			}
+		case isNestedKVListStruct(field.Type):
+			nestedIn := make(map[string][]string)
+			prefix := *publicFieldName + "."
+			for key, values := range inMap {
+				if nestedKey, ok := strings.CutPrefix(key, prefix); ok {
+					nestedIn[nestedKey] = values
+					consumed[key] = struct{}{}
+				}
+			}
+			if _, ok := inMap[*publicFieldName]; ok && len(nestedIn) != 0 {
+				return fmt.Errorf("key %s is used both as a value and as a prefix in field %s.%s", *publicFieldName, typ.Name(), field.Name)
+			}
+			if len(nestedIn) == 0 {
+				// No value, or a single value, e.g. for tag `split`.
+				switch values := inMap[*publicFieldName]; len(values) {
+				case 0: // No value.
+				case 1:
+					outMap[*publicFieldName] = values[0]
+				default:
+					return fmt.Errorf("cannot fit %d elements into a single entry of field %s.%s", len(values), typ.Name(), field.Name)
+				}
+				continue
+			}
+			nestedType := field.Type
+			if nestedType.Kind() == reflect.Pointer {
+				nestedType = nestedType.Elem()
+			}
+			nestedOut := make(map[string]any)
+			err = deListMapReflect(nestedType, nestedOut, nestedIn, options)
 			if err != nil {
 				return err
 			}
+			outMap[*publicFieldName] = nestedOut
 		default:
 			length := len(inMap[*publicFieldName])
 			switch length {
@@ -468,6 +1516,67 @@ func deListMapReflect(typ reflect.Type, outMap map[string]any, inMap map[string]
 	}
 	return nil
 }
+
+// The syntax of indexed keys, e.g. `item[0].name`.
+var indexedKey = regexp.MustCompile(`^(.*)\[([0-9]+)\]\.(.+)$`)
+
+// Split a KVList with indexed keys, e.g. `item[0].name`, into one KVList per index,
+// e.g. with key `name`.
+//
+// Returns the indices, in increasing order, and the corresponding KVLists.
+func splitIndexedKVList(inMap kvlist.KVList) ([]int, []kvlist.KVList, error) {
+	var prefix *string
+	byIndex := make(map[int]kvlist.KVList)
+	for key, values := range inMap {
+		match := indexedKey.FindStringSubmatch(key)
+		if match == nil {
+			// Not an indexed key, ignore.
+			continue
+		}
+		if prefix == nil {
+			prefix = &match[1]
+		} else if *prefix != match[1] {
+			first, second := *prefix, match[1]
+			if second < first {
+				first, second = second, first
+			}
+			return nil, nil, fmt.Errorf("cannot mix indexed keys with prefixes %q and %q", first, second)
+		}
+		index, err := strconv.Atoi(match[2])
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid index in key %s\n\t * %w", key, err)
+		}
+		entry, ok := byIndex[index]
+		if !ok {
+			entry = make(kvlist.KVList)
+			byIndex[index] = entry
+		}
+		entry[match[3]] = append(entry[match[3]], values...)
+	}
+	indices := make([]int, 0, len(byIndex))
+	for index := range byIndex {
+		indices = append(indices, index)
+	}
+	slices.Sort(indices)
+	entries := make([]kvlist.KVList, len(indices))
+	for i, index := range indices {
+		entries[i] = byIndex[index]
+	}
+	return indices, entries, nil
+}
+
+// Determine whether a field is a nested struct (or a pointer to a nested struct) in a KVList,
+// i.e. a struct that doesn't know how to deserialize itself from a string.
+func isNestedKVListStruct(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	return !typ.Implements(textUnmarshalerInterface) && !reflect.PointerTo(typ).Implements(textUnmarshalerInterface)
+}
+
 func deListMap[T any](outMap map[string]any, inMap map[string][]string, options innerOptions) error {
 	var placeholder T
 	reflectedT := reflect.TypeOf(placeholder)
@@ -475,13 +1584,24 @@ func deListMap[T any](outMap map[string]any, inMap map[string][]string, options
 }
 
 // A type of deserializers using reflection to perform any conversions.
-type reflectDeserializer func(slot *reflect.Value, data shared.Value) error
+type reflectDeserializer func(slot *reflect.Value, data shared.Value, call *callData) error
 
 // The interface `validation.Initializer`, which we use throughout the code
 // to pre-initialize structs.
 var initializerInterface = reflect.TypeOf((*validation.Initializer)(nil)).Elem()
 var validatorInterface = reflect.TypeOf((*validation.Validator)(nil)).Elem()
+var contextInitializerInterface = reflect.TypeOf((*validation.ContextInitializer)(nil)).Elem()
+var contextValidatorInterface = reflect.TypeOf((*validation.ContextValidator)(nil)).Elem()
+var pathAwareValidatorInterface = reflect.TypeOf((*validation.PathAwareValidator)(nil)).Elem()
 var unmarshalDictInterface = reflect.TypeOf((*shared.UnmarshalDict)(nil)).Elem()
+var unmarshalValueInterface = reflect.TypeOf((*shared.UnmarshalValue)(nil)).Elem()
+var textUnmarshalerInterface = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+var defaulterInterface = reflect.TypeOf((*validation.Defaulter)(nil)).Elem()
+var valuesInterface = reflect.TypeOf((*validation.Values)(nil)).Elem()
+var dynamicFieldsInterface = reflect.TypeOf((*shared.DynamicFields)(nil)).Elem()
+
+// The type `time.Time`, the only type that supports tag `layout`.
+var timeType = reflect.TypeOf(time.Time{}) //nolint:exhaustruct
 
 // The interface `error`.
 var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
@@ -510,25 +1630,23 @@ func makeOuterStructDeserializerFromReflect(path string, options innerOptions, c
 
 	// The outer struct can't have any tags attached.
 	tags := tagsPkg.Empty()
-	reflectDeserializer, err := makeStructDeserializerFromReflect(path, typ, options, &tags, container, initializationMetadata.canInitializeSelf)
+	reflectDeserializer, err := makeStructDeserializerFromReflect(path, typ, options, &tags, container, initializationMetadata.canInitializeSelf, false)
 	if err != nil {
 		return nil, err
 	}
 
 	var result = mapDeserializer[any]{
-		deserializer: func(value shared.Dict, out *any) error {
+		deserializer: func(value shared.Dict, out *any, call *callData) error {
 			result := reflect.ValueOf(out)
 			if initializationMetadata.canInitializeSelf {
-				initializer, ok := any(out).(validation.Initializer)
-				var err error
+				ok, err := call.initialize(path, options.customCallTimeout, any(out))
 				if !ok && out != nil {
-					initializer, ok = (*out).(validation.Initializer)
+					ok, err = call.initialize(path, options.customCallTimeout, *out)
 				}
 				if !ok {
 					err = errors.New("we have already checked that the result can be converted to `Initializer` but conversion has failed")
 					panic(err)
 				}
-				err = initializer.Initialize()
 				if err != nil {
 					err = fmt.Errorf("at %s, encountered an error while initializing optional fields:\n\t * %w", path, err)
 					slog.Error("internal error during deserialization", "error", err)
@@ -540,15 +1658,24 @@ func makeOuterStructDeserializerFromReflect(path string, options innerOptions, c
 
 				}
 			}
+			value, err := options.unwrapEnvelope(path, value)
+			if err != nil {
+				return err
+			}
 			resultSlot := result.Elem()
+			if resultSlot.Kind() == reflect.Interface && !resultSlot.IsNil() && resultSlot.Elem().Type() == reflect.PointerTo(typ) {
+				// `out` contains a pointer to the struct, deserialize in place.
+				resultSlot = resultSlot.Elem().Elem()
+			}
 			input := value.AsValue()
-			err := reflectDeserializer(&resultSlot, input)
+			err = reflectDeserializer(&resultSlot, input, call)
 			if err != nil {
 				return err
 			}
 			return nil
 		},
 		options: options,
+		pool:    nil,
 	}
 	return &result, nil
 }
@@ -565,21 +1692,40 @@ func makeOuterStructDeserializer[T any](path string, options innerOptions) (*map
 
 	// Pre-check if we're going to perform initialization.
 	typ := reflect.TypeOf(*container)
-	deserializerAny, err := makeOuterStructDeserializerFromReflect(path, options, reflect.ValueOf(container), typ)
+	deserializerAny, err := makeCachedOuterStructDeserializerFromReflect(path, options, reflect.ValueOf(container), typ)
 	if err != nil {
 		return nil, err
 	}
+	// If the deserializer comes from the cache, it was built with other options.
+	options.capturesRaw = deserializerAny.options.capturesRaw
+	_, canFinalize := any(container).(validation.Finalizer)
+	finalizePath := typeName(typ)
+	if path != "" {
+		finalizePath = fmt.Sprint(path, ".", finalizePath)
+	}
 	return &mapDeserializer[T]{
-		deserializer: func(value shared.Dict, out *T) error {
+		deserializer: func(value shared.Dict, out *T, call *callData) error {
 			resultAny := any(out)
-			err := deserializerAny.deserializer(value, &resultAny)
+			err := deserializerAny.deserializer(value, &resultAny, call)
 			if err != nil {
 				return err
 			}
-			*out, _ = resultAny.(T)
-			return nil
-		},
-		options: options,
+			if result, ok := resultAny.(T); ok {
+				*out = result
+			} // Otherwise, `resultAny` is still `out` and we have deserialized in place.
+			if canFinalize {
+				finalizer, ok := any(out).(validation.Finalizer)
+				if !ok {
+					panic("we have already checked that the result can be converted to `Finalizer` but conversion has failed")
+				}
+				if err := finalizer.Finalize(); err != nil {
+					return validation.WrapError(finalizePath, err)
+				}
+			}
+			return nil
+		},
+		options: options,
+		pool:    nil,
 	}, nil
 }
 
@@ -589,18 +1735,61 @@ func makeOuterStructDeserializer[T any](path string, options innerOptions) (*map
 //   - `typ` the dynamic type for the struct being compiled;
 //   - `tags` the table of tags for this field.
 //   - `wasPreinitialized` if this value was preinitialized, typically through `Initializer`
-func makeStructDeserializerFromReflect(path string, typ reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreInitialized bool) (reflectDeserializer, error) {
+//   - `wasFlattened` if this struct is pulled from the same dict as its container.
+func makeStructDeserializerFromReflect(path string, typ reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreInitialized bool, wasFlattened bool) (reflectDeserializer, error) {
 	if typ.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("invalid call to StructDeserializer: %s is not a struct", path)
 	}
 	selfContainer := reflect.New(typ)
-	deserializers := make(map[string]func(outPtr *reflect.Value, inMap shared.Dict) error)
+	deserializers := make(map[string]func(outPtr *reflect.Value, inMap shared.Dict, call *callData) error)
+
+	// Deserializers for fields whose `orMethod` takes the struct as argument. These
+	// need to run once the other fields have been populated.
+	lateDeserializers := make(map[string]func(outPtr *reflect.Value, inMap shared.Dict, call *callData) error)
+
+	// The public names of fields, used to detect unknown fields.
+	//
+	// If this struct is flattened, the check is performed by the container.
+	checkUnknownFields := options.disallowUnknownFields && !wasFlattened
+	knownFields := make(map[string]struct{})
+
+	// The native path of the field reading each key, e.g. `Inner.Left`, and
+	// whether that field is part of a flattened struct, used to detect
+	// fields that would silently share the same key.
+	type keyOwner struct {
+		nativePath string
+		flattened  bool
+	}
+	keyOwners := make(map[string]keyOwner)
+	claimKey := func(publicName string, nativePath string, flattened bool) error {
+		if previous, ok := keyOwners[publicName]; ok && (flattened || previous.flattened) && !options.allowFlattenCollisions {
+			return fmt.Errorf("struct %s contains fields \"%s\" and \"%s\" that both read key %s, please rename one of them or set option AllowFlattenCollisions", path, previous.nativePath, nativePath, publicName)
+		}
+		keyOwners[publicName] = keyOwner{nativePath: nativePath, flattened: flattened}
+		knownFields[publicName] = struct{}{}
+		return nil
+	}
+
+	// The native names of non-flattened fields, by public name, and
+	// the checks specified with tags `enumWhen` and `checksumOf`.
+	nativeNames := make(map[string]string)
+	enumWhenChecks := []*enumWhenCheck{}
+	checksumChecks := []*checksumCheck{}
 
 	initializationData, err := initializationData(path, typ, options)
 	if err != nil {
 		return nil, err
 	}
 
+	// `true` if all the fields of this struct are flat, i.e. neither flattened
+	// nor containers, see `isFlatStruct` below.
+	allFieldsFlat := true
+
+	// If specified with tag `split`, the separator used to split a string into
+	// the public fields of this struct, in order of declaration.
+	split := tags.Split()
+	splitFields := []string{}
+
 	for i := 0; i < typ.NumField(); i++ {
 		field := typ.Field(i)
 		fieldType := field.Type
@@ -608,13 +1797,16 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 		if err != nil {
 			return nil, fmt.Errorf("failed to parse tags at %s.%s:\n\t * %w", path, field.Name, err)
 		}
+		// Defaults may be specific to a format, e.g. `queryDefault`.
+		tags = tags.WithScopedDefault(options.renamingTagNames[0])
+		fieldIndex := i
 		fieldNativeName := field.Name
 		fieldNativeExported := field.IsExported()
 
 		// Extract the public field name (that's the content of `json:"XXX"` if we're deserializing JSON).
 		// We'll use for deserialization and also for error messages, as we expect that the errors will
 		// be readable by external users.
-		publicFieldName := tags.PublicFieldName(options.renamingTagName)
+		publicFieldName := tags.PublicFieldName(options.renamingTagNames...)
 		if publicFieldName == nil {
 			publicFieldName = &fieldNativeName
 		}
@@ -626,6 +1818,28 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 			return nil, fmt.Errorf("struct %s contains a field \"%s\" that has both a `default` and a `orMethod` declaration. Please specify only one", path, fieldNativeName)
 		}
 
+		isRequired := tags.IsRequired()
+		if isRequired && (hasDefault || hasConstructionMethod) {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is `required` but also has a `default` or `orMethod` declaration. Please specify only one", path, fieldNativeName)
+		}
+		if isRequired && tags.DefaultEnv() != nil {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is `required` but also has a `defaultEnv` declaration. Please specify only one", path, fieldNativeName)
+		}
+		isOptional := tags.IsOptional()
+		if isOptional && (hasDefault || hasConstructionMethod || tags.DefaultEnv() != nil) {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is `optional` but also has a `default`, `defaultEnv` or `orMethod` declaration. Please specify only one", path, fieldNativeName)
+		}
+		if isOptional && isRequired {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both `optional` and `required`. Please specify only one", path, fieldNativeName)
+		}
+		isReadOnly := tags.IsReadOnly()
+		if isReadOnly && isRequired {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both `required` and `readOnly`. Please specify only one", path, fieldNativeName)
+		}
+		if isReadOnly && split != nil {
+			return nil, fmt.Errorf("at %s, tag `split` cannot be used with struct %s as it contains a `readOnly` field \"%s\"", path, typeName(typ), fieldNativeName)
+		}
+
 		willPreinitialize := initializationData.willPreinitialize || wasPreInitialized || tags.IsPreinitialized()
 
 		// By Go convention, a field with lower-case name or with a publicFieldName of "-" is private and
@@ -637,23 +1851,84 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 
 		fieldPath := fmt.Sprint(path, ".", *publicFieldName)
 
-		var fieldDeserializer func(*reflect.Value, shared.Dict) error
+		guard, err := parseWhen(fieldPath, &tags)
+		if err != nil {
+			return nil, err
+		}
+		if guard != nil && !isPublic {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both private and `when`, this is not supported", path, fieldNativeName)
+		}
+		if guard != nil && isRequired {
+			return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both `required` and `when`. Please specify only one", path, fieldNativeName)
+		}
+		// If the field is disabled, we still apply its `default`, `defaultEnv` or `orMethod`, if any.
+		hasFallback := hasDefault || hasConstructionMethod || tags.DefaultEnv() != nil
+
+		var fieldDeserializer func(*reflect.Value, shared.Dict, *callData) error
 		if tags.IsFlattened() || field.Anonymous {
+			if guard != nil {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both flattened and `when`, this is not supported", path, fieldNativeName)
+			}
+			if isRequired {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both flattened and `required`, this is not supported", path, fieldNativeName)
+			}
+			if isReadOnly {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both flattened and `readOnly`, this is not supported", path, fieldNativeName)
+			}
+			if isOptional {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both flattened and `optional`, this is not supported", path, fieldNativeName)
+			}
+			if tags.EnumWhen() != nil {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both flattened and `enumWhen`, this is not supported", path, fieldNativeName)
+			}
+			if tags.ChecksumOf() != nil {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is both flattened and `checksumOf`, this is not supported", path, fieldNativeName)
+			}
+			if split != nil {
+				return nil, fmt.Errorf("at %s, tag `split` cannot be used with struct %s as it contains a flattened field \"%s\"", path, typeName(typ), fieldNativeName)
+			}
+			// An embedded pointer to a struct, e.g. `*Inner`, is allocated, then
+			// its contents are flattened.
+			isFlattenedPointer := fieldType.Kind() == reflect.Pointer && fieldType.Elem().Kind() == reflect.Struct
+			contentType := fieldType
+			if isFlattenedPointer {
+				contentType = fieldType.Elem()
+			}
+			if contentType.Kind() == reflect.Struct {
+				flattenedFields := make(map[string]string)
+				err = collectPublicFieldNames(contentType, options, fieldNativeName, flattenedFields)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse tags at %s.%s:\n\t * %w", path, field.Name, err)
+				}
+				for publicName, nativePath := range flattenedFields {
+					err = claimKey(publicName, nativePath, true)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
 			// The field is flattened either explicitly (tag `flatten`) or implicitly
 			// (because it's an anonymous field). In either case, the *contents* of that
 			// struct are pulled from *the same outer map* `inMap`.
+			allFieldsFlat = false
 
-			fieldContentDeserializer, err := makeFieldDeserializerFromReflect(fieldPath, fieldType, options, &tags, selfContainer, willPreinitialize, true)
+			fieldContentDeserializer, err := makeFieldDeserializerFromReflect(fieldPath, contentType, options, &tags, selfContainer, willPreinitialize, true)
 			if err != nil {
 				return nil, err
 			}
 
-			fieldDeserializer = func(outPtr *reflect.Value, inMap shared.Dict) error {
+			fieldDeserializer = func(outPtr *reflect.Value, inMap shared.Dict, call *callData) error {
 				// Note: maps are references, so there is no loss to passing a `map` instead of a `*map`.
 				// Use the `fieldName` to access the field in the record.
-				outReflect := outPtr.FieldByName(fieldNativeName)
+				outReflect := outPtr.Field(fieldIndex)
+				if isFlattenedPointer {
+					if outReflect.IsNil() {
+						outReflect.Set(reflect.New(contentType))
+					}
+					outReflect = outReflect.Elem()
+				}
 
-				err := fieldContentDeserializer(&outReflect, inMap.AsValue())
+				err := fieldContentDeserializer(&outReflect, inMap.AsValue(), call)
 				if err != nil {
 					return err
 				}
@@ -664,16 +1939,44 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 			}
 
 		} else {
+			if isPublic {
+				err = claimKey(*publicFieldName, fieldNativeName, false)
+				if err != nil {
+					return nil, err
+				}
+				splitFields = append(splitFields, *publicFieldName)
+			} else if isRequired {
+				return nil, fmt.Errorf("struct %s contains a field \"%s\" that is `required` but not public", path, fieldNativeName)
+			}
+			nativeNames[*publicFieldName] = fieldNativeName
+			if !isFlatKind(fieldType.Kind()) {
+				allFieldsFlat = false
+			}
+			enumWhenCheck, err := parseEnumWhen(fieldPath, fieldNativeName, &tags)
+			if err != nil {
+				return nil, err
+			}
+			if enumWhenCheck != nil {
+				enumWhenChecks = append(enumWhenChecks, enumWhenCheck)
+			}
+			checksumCheck, err := parseChecksum(fieldPath, fieldNativeName, fieldType, &tags)
+			if err != nil {
+				return nil, err
+			}
+			if checksumCheck != nil {
+				checksumChecks = append(checksumChecks, checksumCheck)
+			}
+
 			// The field is nested, so we'll try to move into the corresponding entry in the map.
 			fieldContentDeserializer, err := makeFieldDeserializerFromReflect(fieldPath, fieldType, options, &tags, selfContainer, willPreinitialize, false)
 			if err != nil {
 				return nil, err
 			}
 
-			fieldDeserializer = func(outPtr *reflect.Value, inMap shared.Dict) error {
+			fieldDeserializer = func(outPtr *reflect.Value, inMap shared.Dict, call *callData) error {
 				// Note: maps are references, so there is no loss to passing a `map` instead of a `*map`.
 				// Use the `fieldName` to access the field in the record.
-				outReflect := outPtr.FieldByName(fieldNativeName)
+				outReflect := outPtr.Field(fieldIndex)
 
 				// Use the `publicFieldName` to access the field in the map.
 				var fieldValue shared.Value
@@ -681,11 +1984,34 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 					// If the field is public, we can accept external data, if provided.
 					var ok bool
 					fieldValue, ok = inMap.Lookup(*publicFieldName)
+					if ok && isReadOnly {
+						return fmt.Errorf("field %s is read-only and cannot be set by clients", fieldPath)
+					}
+					if guard != nil && !guard.isEnabled(call) {
+						if ok && guard.reject {
+							return fmt.Errorf("field %s is disabled, it requires %s", fieldPath, guard.flag)
+						}
+						if !hasFallback {
+							// Leave the field untouched.
+							return nil
+						}
+						// Ignore the value, fallback to the default.
+						ok = false
+					}
 					if !ok {
+						if isRequired {
+							// Even if the field was pre-initialized, we need a value.
+							return MissingFieldError{Path: fieldPath, Type: typeName(fieldType), description: missingRequired}
+						}
+						if isOptional && !willPreinitialize {
+							// Reset the field, as we may be deserializing in place.
+							outReflect.SetZero()
+							return nil
+						}
 						fieldValue = nil
 					}
 				} // otherwise, use the zero value for that field.
-				err := fieldContentDeserializer(&outReflect, fieldValue)
+				err := fieldContentDeserializer(&outReflect, fieldValue, call)
 				if err != nil {
 					return err
 				}
@@ -696,67 +2022,295 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 			}
 		}
 
-		deserializers[field.Name] = fieldDeserializer
+		if orMethodTakesSelf(&tags, selfContainer) {
+			lateDeserializers[field.Name] = fieldDeserializer
+		} else {
+			deserializers[field.Name] = fieldDeserializer
+		}
 	}
 
-	// True if this struct has a default value of {}.
-	isZeroDefault := false
-	if defaultSource := tags.Default(); defaultSource != nil {
-		if *defaultSource == "{}" {
-			isZeroDefault = true
-		} else {
-			return nil, fmt.Errorf("at %s, invalid `default` value. The only supported `default` value for structs is \"{}\", got: %s", path, *defaultSource)
+	for _, check := range enumWhenChecks {
+		err = check.resolve(nativeNames)
+		if err != nil {
+			return nil, err
 		}
 	}
-	orMethod, err := makeOrMethodConstructor(tags, typ, container)
+	for _, check := range checksumChecks {
+		err = check.resolve(nativeNames)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// True if this struct has a default value of {}. Otherwise, the default value, if any.
+	isZeroDefault, defaultValue, err := parseDefaultObject(path, options, tags)
+	if err != nil {
+		return nil, err
+	}
+	orMethod, err := makeOrMethodConstructor(path, tags, typ, container, options.customCallTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", path, err)
 	}
 
-	result := func(outPtr *reflect.Value, inValue shared.Value) (err error) {
+	canValidate, err := implementsValidator(typ)
+	if err != nil {
+		return nil, err
+	}
+	canHaveDynamicFields, err := canInterface(typ, dynamicFieldsInterface)
+	if err != nil {
+		return nil, err
+	}
+	var dynamicFields *dynamicFieldsDeserializer
+	if canHaveDynamicFields {
+		if wasFlattened {
+			return nil, fmt.Errorf("at %s, struct %s implements DynamicFields, it cannot be flattened", path, typeName(typ))
+		}
+		dynamicFields = makeDynamicFieldsDeserializer(path, options, knownFields)
+	}
+
+	// If `true`, this struct is composed only of flat fields and doesn't need
+	// any hook (initialization, validation, etc.), so we may deserialize its
+	// fields directly into the destination, without allocating a copy.
+	//
+	// Note that, by opposition to the slow path, if deserialization fails,
+	// the destination may have been partially overwritten.
+	isFlatStruct := allFieldsFlat &&
+		!initializationData.canInitializeSelf &&
+		!initializationData.canDriverUnmarshal &&
+		!initializationData.canUnmarshalFromDict &&
+		!initializationData.canSetValues &&
+		!initializationData.canComputeDefaults &&
+		!canValidate &&
+		!canHaveDynamicFields &&
+		len(enumWhenChecks) == 0 &&
+		len(checksumChecks) == 0
+
+	// If specified, the keys, at least one of which must be present.
+	atLeastOne := tags.AtLeastOne()
+	for _, key := range atLeastOne {
+		if _, ok := knownFields[key]; !ok {
+			return nil, fmt.Errorf("at %s, invalid `atLeastOne` value, there is no field %s in this struct", path, key)
+		}
+	}
+
+	// Check that at least one of the keys specified with `atLeastOne` is present.
+	checkAtLeastOne := func(inMap shared.Dict) error {
+		if atLeastOne == nil {
+			return nil
+		}
+		for _, key := range atLeastOne {
+			if _, ok := inMap.Lookup(key); ok {
+				return nil
+			}
+		}
+		return MissingFieldError{Path: path, Type: strings.Join(atLeastOne, ", "), description: missingAtLeastOne}
+	}
+
+	// If specified, the minimal number of fields that must be present.
+	atLeast := 0
+	if atLeastSource := tags.AtLeast(); atLeastSource != nil {
+		atLeast, err = strconv.Atoi(*atLeastSource)
+		if err != nil || atLeast <= 0 {
+			return nil, fmt.Errorf("at %s, invalid `atLeast` value %s, expected a positive integer", path, *atLeastSource)
+		}
+		if atLeast > len(knownFields) {
+			return nil, fmt.Errorf("at %s, invalid `atLeast` value %d, struct %s only has %d fields", path, atLeast, typeName(typ), len(knownFields))
+		}
+	}
+
+	// Check that at least `atLeast` of the fields of this struct are present.
+	checkAtLeast := func(inMap shared.Dict) error {
+		if atLeast == 0 {
+			return nil
+		}
+		present := 0
+		for _, key := range inMap.Keys() {
+			if _, ok := knownFields[key]; ok {
+				present++
+			}
+		}
+		if present < atLeast {
+			return MissingFieldError{Path: path, Type: fmt.Sprint(atLeast, " fields"), description: missingAtLeast}
+		}
+		return nil
+	}
+
+	// Reject unknown fields, if requested.
+	//
+	// `dynamicKeys` are the keys of dynamic fields, if any.
+	checkUnknown := func(inMap shared.Dict, dynamicKeys map[string]struct{}) error {
+		if !checkUnknownFields {
+			return nil
+		}
+		unknownFields := []string{}
+		for _, key := range inMap.Keys() {
+			if _, ok := knownFields[key]; ok {
+				continue
+			}
+			if _, ok := dynamicKeys[key]; !ok {
+				unknownFields = append(unknownFields, key)
+			}
+		}
+		if len(unknownFields) != 0 {
+			slices.Sort(unknownFields)
+			return fmt.Errorf("unexpected field %s at %s", unknownFields[0], path)
+		}
+		return nil
+	}
+
+	// Split a string into the public fields of this struct, as specified by tag `split`.
+	splitObject := func(source string) (shared.Value, error) {
+		parts := strings.Split(source, *split)
+		if len(parts) != len(splitFields) {
+			return nil, fmt.Errorf("invalid value at %s, expected %d parts separated by %q, got %d", path, len(splitFields), *split, len(parts))
+		}
+		fields := make(map[string]any, len(parts))
+		for i, part := range parts {
+			fields[splitFields[i]] = part
+		}
+		return options.unmarshaler.WrapValue(fields), nil
+	}
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) (err error) {
+		// `true` if the value was provided by the caller, rather than by a default value
+		// or a constructor.
+		isFromInput := inValue != nil
+
+		if split != nil && inValue != nil {
+			if source, ok := inValue.Interface().(string); ok {
+				inValue, err = splitObject(source)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if isFlatStruct && inValue != nil && outPtr.CanSet() && outPtr.Type() == typ {
+			if inMap, ok := inValue.AsDict(); ok {
+				// Fast path.
+				err = checkUnknown(inMap, nil)
+				if err != nil {
+					return err
+				}
+				err = checkAtLeastOne(inMap)
+				if err != nil {
+					return err
+				}
+				err = checkAtLeast(inMap)
+				if err != nil {
+					return err
+				}
+				outPtr.SetZero()
+				return deserializeFields(outPtr, inMap, call, deserializers, lateDeserializers, options.accumulateErrors)
+			}
+			// Otherwise, let the slow path report the error.
+		}
+
 		resultPtr := reflect.New(typ)
 		result := resultPtr.Elem()
 
+		// Note: The deserializer may be called concurrently, so we MUST NOT
+		// alter `wasPreInitialized`.
+		isPreInitialized := wasPreInitialized
+
+		// If requested, inject values before anything else.
+		if initializationData.canSetValues {
+			call.setValues(resultPtr.Interface())
+		}
+
 		// If possible, perform pre-initialization with default values.
 		if initializationData.canInitializeSelf {
-			if initializer, ok := resultPtr.Interface().(validation.Initializer); ok {
-				err = initializer.Initialize()
-				wasPreInitialized = true
-				if err != nil {
-					err = fmt.Errorf("at %s, encountered an error while initializing optional fields:\n\t * %w", path, err)
-					slog.Error("Internal error during deserialization", "error", err)
-					return CustomDeserializerError{
-						Wrapped:   err,
-						Operation: "initializer",
-						Structure: "struct",
-					}
+			var ok bool
+			ok, err = call.initialize(path, options.customCallTimeout, resultPtr.Interface())
+			if ok {
+				isPreInitialized = true
+			}
+			if err != nil {
+				err = fmt.Errorf("at %s, encountered an error while initializing optional fields:\n\t * %w", path, err)
+				slog.Error("Internal error during deserialization", "error", err)
+				return CustomDeserializerError{
+					Wrapped:   err,
+					Operation: "initializer",
+					Structure: "struct",
 				}
 			}
 		}
 
-		// Don't forget to perform validation (unless we're returning an error).
+		// `true` once `result` has been populated and stored in `outPtr`.
+		populated := false
+
+		// `true` if deserializing some of the fields of `result` failed.
+		fieldsFailed := false
+
+		// Don't forget to compute defaults and perform validation (unless we're returning an error).
 		defer func() {
 			if err != nil {
-				// We're already returning an error, no need to insist.
+				if fieldsFailed && options.accumulateErrors {
+					// The validator may still have something useful to say about
+					// the partially-built value.
+					// If it merely returns the same error as one of the fields, don't report it twice.
+					validationErr := call.validate(path, resultPtr.Interface())
+					if validationErr != nil && !errors.Is(err, validationErr) {
+						err = violations{err, validation.WrapError(path, validationErr)}
+					}
+				}
+				// Otherwise, we're already returning an error, no need to insist.
 				return
 			}
-			mightValidate := resultPtr.Interface()
-			if validator, ok := mightValidate.(validation.Validator); ok {
-				err = validator.Validate()
-				if err != nil {
-					// Validation error, abort struct construction, wrap the error so that we can catch it.
-					err = validation.WrapError(path, err)
-					result = reflect.Zero(typ)
+			if populated && initializationData.canComputeDefaults {
+				if defaulter, ok := resultPtr.Interface().(validation.Defaulter); ok {
+					err = defaulter.ComputeDefaults()
+					if err != nil {
+						err = fmt.Errorf("at %s, encountered an error while computing defaults:\n\t * %w", path, err)
+						slog.Error("Internal error during deserialization", "error", err)
+						err = CustomDeserializerError{
+							Wrapped:   err,
+							Operation: "defaulter",
+							Structure: "struct",
+						}
+						return
+					}
+				}
+			}
+			if populated {
+				for _, check := range enumWhenChecks {
+					err = check.check(result)
+					if err != nil {
+						err = validation.WrapError(check.fieldPath, err)
+						result = reflect.Zero(typ)
+						return
+					}
+				}
+				for _, check := range checksumChecks {
+					err = check.check(result)
+					if err != nil {
+						err = validation.WrapError(check.fieldPath, err)
+						result = reflect.Zero(typ)
+						return
+					}
 				}
 			}
+			err = call.validate(path, resultPtr.Interface())
+			if err != nil {
+				// Validation error, abort struct construction, wrap the error so that we can catch it.
+				err = validation.WrapError(path, err)
+				result = reflect.Zero(typ)
+				return
+			}
+			if populated {
+				// `ComputeDefaults()` and `Validate()` may have altered the result.
+				outPtr.Set(result)
+			}
 		}()
 		switch {
 		case inValue != nil:
 			// We have all the data we need, proceed.
-		case isZeroDefault || wasPreInitialized:
+		case isZeroDefault || isPreInitialized:
 			inValue = internal.EmptyValue{}
+		case defaultValue != nil:
+			inValue = defaultValue
 		case orMethod != nil:
-			constructed, err := (*orMethod)()
+			constructed, err := (*orMethod)(call.self)
 			if err != nil {
 				err = fmt.Errorf("error in optional value at %s\n\t * %w", path, err)
 				slog.Error("Internal error during deserialization", "error", err)
@@ -767,11 +2321,16 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 				}
 			}
 			reflected := reflect.ValueOf(constructed)
+			if canValidate {
+				reflected, err = validateCollection(path, typ, reflected, call)
+				if err != nil {
+					return err
+				}
+			}
 			outPtr.Set(reflected)
 			return nil
 		default:
-			err = fmt.Errorf("missing object value at %s, expected %s", path, typeName(typ))
-			return err
+			return MissingFieldError{Path: path, Type: typeName(typ), description: missingObject}
 		}
 
 		switch {
@@ -789,8 +2348,8 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 			}
 			inDict, ok := inValue.AsDict()
 			if !ok {
-				err = fmt.Errorf("invalid value at %s, expected an object of type %s, got %s", path, typeName(typ), result.Type().Name())
-				return err
+				// Historically, the message displays the name of the expected type.
+				return newTypeMismatchError(path, typeName(typ), inValue.Interface(), mismatchObject)
 			}
 			err = unmarshalDict.UnmarshalDict(inDict)
 			if err != nil {
@@ -800,90 +2359,280 @@ func makeStructDeserializerFromReflect(path string, typ reflect.Type, options in
 		default:
 			inMap, ok := inValue.AsDict()
 			if !ok {
-				err = fmt.Errorf("invalid value at %s, expected an object of type %s, got %s", path, typeName(typ), result.Type().Name())
-				return err
+				// Historically, the message displays the name of the expected type.
+				return newTypeMismatchError(path, typeName(typ), inValue.Interface(), mismatchObject)
+			}
+
+			if dynamicFields == nil {
+				err = checkUnknown(inMap, nil)
+				if err != nil {
+					return err
+				}
+			}
+			if isFromInput {
+				err = checkAtLeastOne(inMap)
+				if err != nil {
+					return err
+				}
+				err = checkAtLeast(inMap)
+				if err != nil {
+					return err
+				}
 			}
 
 			// We may now deserialize fields.
-			for _, fieldDeserializer := range deserializers {
-				err = fieldDeserializer(&result, inMap)
+			err = deserializeFields(&result, inMap, call, deserializers, lateDeserializers, options.accumulateErrors)
+			if err != nil {
+				fieldsFailed = true
+				return err
+			}
+
+			// Once the regular fields are known, so is the schema of dynamic fields.
+			if dynamicFields != nil {
+				var dynamicKeys map[string]struct{}
+				if isFromInput {
+					dynamicKeys, err = dynamicFields.deserialize(resultPtr, inMap, call)
+					if err != nil {
+						return err
+					}
+				}
+				err = checkUnknown(inMap, dynamicKeys)
 				if err != nil {
 					return err
 				}
 			}
 		}
 		outPtr.Set(result)
+		populated = true
 		return err
 	}
+	if err = checkDefaultObject(path, typ, result, defaultValue); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-// Construct a dynamically-typed deserializer for maps.
+// Deserialize the fields of a struct, then the fields whose `orMethod` takes
+// the struct as argument, so that these methods may see the other fields.
 //
-//   - `path` the human-readable path into the data structure, used for error-reporting;
-//   - `typ` the dynamic type for the struct being compiled;
-//   - `tags` the table of tags for this field.
-//   - `wasPreinitialized` if this value was preinitialized, typically through `Initializer`
-func makeMapDeserializerFromReflect(path string, typ reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreInitialized bool) (reflectDeserializer, error) {
-	err := options.unmarshaler.Enter(path, typ)
-	if err != nil {
-		return nil, err //nolint:wrapcheck
-	}
+// If `accumulate` is `true`, deserialize all fields even if some of them fail,
+// then return all the errors, sorted, otherwise stop at the first error.
+//
+// `outPtr` MUST be addressable.
+func deserializeFields(outPtr *reflect.Value, inMap shared.Dict, call *callData, deserializers map[string]func(*reflect.Value, shared.Dict, *callData) error, lateDeserializers map[string]func(*reflect.Value, shared.Dict, *callData) error, accumulate bool) error {
+	previousSelf := call.self
+	call.self = outPtr.Addr()
 	defer func() {
-		options.unmarshaler.Exit(typ)
+		call.self = previousSelf
 	}()
+	errs := []error{}
+	for _, group := range []map[string]func(*reflect.Value, shared.Dict, *callData) error{deserializers, lateDeserializers} {
+		for _, fieldDeserializer := range group {
+			err := fieldDeserializer(outPtr, inMap, call)
+			if err == nil {
+				continue
+			}
+			if !accumulate {
+				return err
+			}
+			errs = append(errs, err)
+		}
+	}
+	// Fields are deserialized in no specific order, sort errors to keep messages stable.
+	slices.SortFunc(errs, func(a error, b error) int {
+		return strings.Compare(a.Error(), b.Error())
+	})
+	return joinViolations(errs)
+}
 
-	if typ.Kind() != reflect.Map {
-		panic(fmt.Sprintf("invalid call: %s is not a map", path))
+// Parse the `default` value for a struct or a map.
+//
+// Returns `true` if the default value is `{}`. Otherwise, returns the default
+// value parsed by the driver, if any, e.g. `{"host": "localhost", "port": 8080}`.
+func parseDefaultObject(path string, options innerOptions, tags *tagsPkg.Tags) (bool, shared.Value, error) {
+	defaultSource := tags.Default()
+	if defaultSource == nil {
+		return false, nil, nil
 	}
-	if typ.Key().Kind() != reflect.String {
-		return nil, fmt.Errorf("invalid map type at %s, only map[string]T can be converted into a deserializer", path)
+	if *defaultSource == "{}" {
+		return true, nil, nil
 	}
-
-	// From this point, we know that it's a `map[string]T` for some `T`.
-	selfContainer := reflect.New(typ)
-
-	initializationMetadata, err := initializationData(path, typ, options)
+	decoded := new(any)
+	err := options.unmarshaler.Unmarshal(*defaultSource, decoded)
 	if err != nil {
-		return nil, err
+		if json.Valid([]byte(*defaultSource)) {
+			// The literal is well-formed, but the driver cannot parse objects, e.g. the kvlist driver.
+			return false, nil, fmt.Errorf("at %s, invalid `default` value, driver %T does not support object literals, only `{}`, got: %s\n\t * %w", path, options.unmarshaler, *defaultSource, err)
+		}
+		return false, nil, fmt.Errorf("at %s, invalid `default` value, expected an object, got: %s\n\t * %w", path, *defaultSource, err)
 	}
-	if initializationMetadata.canInitializeSelf {
-		panic(fmt.Errorf("at %s, we see a map type that looks like it can be initialized, that's currently impossible in go", path))
+	value := options.unmarshaler.WrapValue(*decoded)
+	if _, ok := value.AsDict(); !ok {
+		return false, nil, fmt.Errorf("at %s, invalid `default` value, expected an object, got: %s", path, *defaultSource)
 	}
+	return false, value, nil
+}
 
-	subPath := path + "[]"
-	subTags := tagsPkg.Empty()
-	subTyp := typ.Elem()
-	contentDeserializer, err := makeFieldDeserializerFromReflect(subPath, subTyp, options, &subTags, selfContainer, initializationMetadata.willPreinitialize, false)
+// Make sure that the `default` value of a struct or a map, if any, can be
+// deserialized, so that a broken default fails when building the deserializer
+// rather than whenever it is used.
+func checkDefaultObject(path string, typ reflect.Type, deserializer reflectDeserializer, defaultValue shared.Value) error {
+	if defaultValue == nil {
+		return nil
+	}
+	scratch := reflect.New(typ).Elem()
+	err := deserializer(&scratch, defaultValue, newCallData())
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("at %s, invalid `default` value, cannot deserialize object\n\t * %w", path, err)
 	}
+	return nil
+}
 
-	// True if this map has a default value of {}.
-	isZeroDefault := false
-	if defaultSource := tags.Default(); defaultSource != nil {
-		if *defaultSource == "{}" {
-			isZeroDefault = true
-		} else {
-			return nil, fmt.Errorf("at %s, invalid `default` value. The only supported `default` value for maps is \"{}\", got: %s", path, *defaultSource)
-		}
+// Parse the `default` value for a slice or an array.
+//
+// Returns `true` if the default value is `[]`. Otherwise, returns the elements
+// of the default value parsed by the driver, if any, e.g. `[1, 2, 3]`.
+func parseDefaultList(path string, options innerOptions, tags *tagsPkg.Tags) (bool, []shared.Value, error) {
+	defaultSource := tags.Default()
+	if defaultSource == nil {
+		return false, nil, nil
+	}
+	if *defaultSource == "[]" {
+		return true, nil, nil
 	}
-	orMethod, err := makeOrMethodConstructor(tags, typ, container)
+	decoded := new(any)
+	err := options.unmarshaler.Unmarshal(*defaultSource, decoded)
 	if err != nil {
-		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", path, err)
+		return false, nil, fmt.Errorf("at %s, invalid `default` value, expected an array, got: %s\n\t * %w", path, *defaultSource, err)
 	}
+	elements, ok := options.unmarshaler.WrapValue(*decoded).AsSlice()
+	if !ok {
+		return false, nil, fmt.Errorf("at %s, invalid `default` value, expected an array, got: %s", path, *defaultSource)
+	}
+	return false, elements, nil
+}
+
+// Collect the public names of fields of `typ` that may accept external data,
+// including the fields of flattened or anonymous structs.
+//
+// `out` maps each public name to the native path of the field, prefixed
+// with `nativePath`, e.g. `Inner.Left`.
+func collectPublicFieldNames(typ reflect.Type, options innerOptions, nativePath string, out map[string]string) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tags, err := tagsPkg.Parse(field.Tag)
+		if err != nil {
+			return err //nolint:wrapcheck
+		}
+		fieldNativePath := fmt.Sprint(nativePath, ".", field.Name)
+		if tags.IsFlattened() || field.Anonymous {
+			contentType := field.Type
+			if contentType.Kind() == reflect.Pointer {
+				contentType = contentType.Elem()
+			}
+			if contentType.Kind() == reflect.Struct {
+				err = collectPublicFieldNames(contentType, options, fieldNativePath, out)
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		publicFieldName := tags.PublicFieldName(options.renamingTagNames...)
+		if publicFieldName == nil {
+			publicFieldName = &field.Name
+		}
+		if *publicFieldName != "-" && field.IsExported() {
+			out[*publicFieldName] = fieldNativePath
+		}
+	}
+	return nil
+}
+
+// Construct a dynamically-typed deserializer for maps.
+//
+//   - `path` the human-readable path into the data structure, used for error-reporting;
+//   - `typ` the dynamic type for the struct being compiled;
+//   - `tags` the table of tags for this field.
+//   - `wasPreinitialized` if this value was preinitialized, typically through `Initializer`
+func makeMapDeserializerFromReflect(path string, typ reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreInitialized bool) (reflectDeserializer, error) {
+	err := options.unmarshaler.Enter(path, typ)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	defer func() {
+		options.unmarshaler.Exit(typ)
+	}()
+
+	if typ.Kind() != reflect.Map {
+		panic(fmt.Sprintf("invalid call: %s is not a map", path))
+	}
+	keyParser, err := makeMapKeyParser(path, typ.Key(), options)
+	if err != nil {
+		return nil, err
+	}
+
+	// From this point, we know that it's a `map[K]T` for some `T` and some parseable `K`.
+	selfContainer := reflect.New(typ)
+
+	initializationMetadata, err := initializationData(path, typ, options)
+	if err != nil {
+		return nil, err
+	}
+	if initializationMetadata.canInitializeSelf {
+		panic(fmt.Errorf("at %s, we see a map type that looks like it can be initialized, that's currently impossible in go", path))
+	}
+
+	subPath := path + "[]"
+	subTags := tagsPkg.Empty()
+	subTyp := typ.Elem()
+	contentDeserializer, err := makeFieldDeserializerFromReflect(subPath, subTyp, options, &subTags, selfContainer, initializationMetadata.willPreinitialize, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rejectEmptyKeys := tags.RejectEmptyKeys()
+
+	// If specified with tag `allowedKeys`, the only keys accepted in this map.
+	var allowedKeys map[string]struct{}
+	if allowedKeysList := tags.AllowedKeys(); allowedKeysList != nil {
+		allowedKeys = make(map[string]struct{}, len(allowedKeysList))
+		for _, k := range allowedKeysList {
+			if _, err = keyParser(k); err != nil {
+				return nil, fmt.Errorf("at %s, invalid `allowedKeys` value\n\t * %w", path, err)
+			}
+			allowedKeys[k] = struct{}{}
+		}
+	}
+
+	// True if this map has a default value of {}. Otherwise, the default value, if any.
+	isZeroDefault, defaultValue, err := parseDefaultObject(path, options, tags)
+	if err != nil {
+		return nil, err
+	}
+	orMethod, err := makeOrMethodConstructor(path, tags, typ, container, options.customCallTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", path, err)
+	}
+
+	// Named map types, e.g. `type Labels map[string]string`, may implement Validator.
+	canValidate, err := implementsValidator(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) (err error) {
+		result := reflect.MakeMap(typ)
 
-	result := func(outPtr *reflect.Value, inValue shared.Value) (err error) {
-		result := reflect.MakeMap(typ)
-
-		// No deferred validation, as we can't implement Validator on a map.
 		switch {
 		case inValue != nil:
 			// We have all the data we need, proceed.
 		case isZeroDefault || wasPreInitialized:
 			inValue = internal.EmptyDict{}.AsValue()
+		case defaultValue != nil:
+			inValue = defaultValue
 		case orMethod != nil:
-			constructed, err := (*orMethod)()
+			constructed, err := (*orMethod)(call.self)
 			if err != nil {
 				err = fmt.Errorf("error in optional value at %s\n\t * %w", path, err)
 				slog.Error("Internal error during deserialization", "error", err)
@@ -897,19 +2646,23 @@ func makeMapDeserializerFromReflect(path string, typ reflect.Type, options inner
 			outPtr.Set(reflected)
 			return nil
 		default:
-			err = fmt.Errorf("missing object value at %s, expected %s", path, typeName(typ))
-			return err
+			return MissingFieldError{Path: path, Type: typeName(typ), description: missingObject}
 		}
 
 		inMap, ok := inValue.AsDict()
 		if !ok {
-			err = fmt.Errorf("invalid value at %s, expected an object of type %s, got %v", path, typeName(typ), inValue.Interface())
-			return err
+			return newTypeMismatchError(path, typeName(typ), inValue.Interface(), mismatchObject)
 		}
 
 		// We may now deserialize keys and values.
 		keys := inMap.Keys()
 		for _, k := range keys {
+			if rejectEmptyKeys && k == "" {
+				return fmt.Errorf("invalid empty key at %s", path)
+			}
+			if _, ok := allowedKeys[k]; allowedKeys != nil && !ok {
+				return fmt.Errorf("invalid key at %s[%s], expected one of %s", path, k, strings.Join(tags.AllowedKeys(), ", "))
+			}
 			subInValue, ok := inMap.Lookup(k)
 			if !ok {
 				slog.Error("Internal error while ranging over map: missing value", "path", path, "key", k)
@@ -917,17 +2670,110 @@ func makeMapDeserializerFromReflect(path string, typ reflect.Type, options inner
 				continue
 			}
 
+			reflectedKey, err := keyParser(k)
+			if err != nil {
+				return err
+			}
+
 			reflectedContent := reflect.New(subTyp).Elem()
-			err = contentDeserializer(&reflectedContent, subInValue)
+			err = contentDeserializer(&reflectedContent, subInValue, call)
 			if err != nil {
 				return err
 			}
-			result.SetMapIndex(reflect.ValueOf(k), reflectedContent)
+			result.SetMapIndex(reflectedKey, reflectedContent)
 		}
 
+		if canValidate {
+			result, err = validateCollection(path, typ, result, call)
+			if err != nil {
+				return err
+			}
+		}
 		outPtr.Set(result)
 		return nil
 	}
+	if err = checkDefaultObject(path, typ, result, defaultValue); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Run `ValidateContext()` or `Validate()` on a collection once it has been built,
+// e.g. on a `type Tags []string`.
+//
+// Returns the value to store, as validation may have altered it.
+func validateCollection(path string, typ reflect.Type, value reflect.Value, call *callData) (reflect.Value, error) {
+	resultPtr := reflect.New(typ)
+	resultPtr.Elem().Set(value)
+	err := call.validate(path, resultPtr.Interface())
+	if err != nil {
+		return reflect.Value{}, validation.WrapError(path, err)
+	}
+	return resultPtr.Elem(), nil
+}
+
+// Construct a parser for the keys of a map.
+//
+// Keys are always received as strings. We accept key types for which we have a
+// custom parser, key types that implement `encoding.TextUnmarshaler` and key types
+// for which we have a primitive parser.
+//
+//   - `path` the human-readable path into the data structure, used for error-reporting;
+//   - `keyTyp` the dynamic type for the keys of the map.
+func makeMapKeyParser(path string, keyTyp reflect.Type, options innerOptions) (func(string) (reflect.Value, error), error) {
+	_, hasCustomParser := options.parsers[keyTyp]
+	if !hasCustomParser && reflect.PointerTo(keyTyp).Implements(textUnmarshalerInterface) {
+		return func(source string) (reflect.Value, error) {
+			ptrResult := reflect.New(keyTyp)
+			unmarshaler, ok := ptrResult.Interface().(encoding.TextUnmarshaler)
+			if !ok {
+				panic(fmt.Sprintf("at %s, type %s should implement TextUnmarshaler", path, typeName(keyTyp)))
+			}
+			err := unmarshaler.UnmarshalText([]byte(source))
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("invalid key %q at %s, expected %s\n\t * %w", source, path, typeName(keyTyp), err)
+			}
+			return ptrResult.Elem(), nil
+		}, nil
+	}
+	parser := options.lookupParser(keyTyp)
+	if parser == nil {
+		return nil, fmt.Errorf("invalid map type at %s, keys must be parseable from a string or implement TextUnmarshaler, got %s", path, typeName(keyTyp))
+	}
+	return func(source string) (reflect.Value, error) {
+		parsed, err := (*parser)(source)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("invalid key %q at %s, expected %s\n\t * %w", source, path, typeName(keyTyp), err)
+		}
+		return reflect.ValueOf(parsed).Convert(keyTyp), nil
+	}, nil
+}
+
+// Convert an object keyed by index, e.g. `{"1": "b", "0": "a"}`, into
+// the list of its values sorted by index, e.g. `["a", "b"]`.
+func indexedObjectAsSlice(fieldPath string, dict shared.Dict) ([]shared.Value, error) {
+	keys := dict.Keys()
+	indices := make(map[uint64]string, len(keys))
+	for _, key := range keys {
+		index, err := strconv.ParseUint(key, 10, 0)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value at %s, expected an array or an object keyed by index, got key %q", fieldPath, key)
+		}
+		if previous, ok := indices[index]; ok {
+			return nil, fmt.Errorf("invalid value at %s, keys %q and %q both represent index %d", fieldPath, previous, key, index)
+		}
+		indices[index] = key
+	}
+	sorted := make([]uint64, 0, len(indices))
+	for index := range indices {
+		sorted = append(sorted, index)
+	}
+	slices.Sort(sorted)
+	result := make([]shared.Value, len(sorted))
+	for i, index := range sorted {
+		value, _ := dict.Lookup(indices[index])
+		result[i] = value
+	}
 	return result, nil
 }
 
@@ -939,21 +2785,19 @@ func makeMapDeserializerFromReflect(path string, typ reflect.Type, options inner
 //   - `tags` the table of tags for this field.
 func makeSliceDeserializer(fieldPath string, fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreinitialized bool) (reflectDeserializer, error) {
 	arrayPath := fmt.Sprint(fieldPath, "[]")
-	isEmptyDefault := false
-	if defaultSource := tags.Default(); defaultSource != nil {
-		if *defaultSource == "[]" {
-			isEmptyDefault = true
-		} else {
-			return nil, fmt.Errorf("at %s, invalid `default` value. The only supported `default` value for arrays or slices is \"[]\", got: %s", fieldPath, *defaultSource)
-		}
+
+	// True if this slice has a default value of []. Otherwise, the default value, if any.
+	isEmptyDefault, defaultValue, err := parseDefaultList(fieldPath, options, tags)
+	if err != nil {
+		return nil, err
 	}
-	orMethod, err := makeOrMethodConstructor(tags, fieldType, container)
+	orMethod, err := makeOrMethodConstructor(fieldPath, tags, fieldType, container, options.customCallTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", fieldPath, err)
 	}
 
-	// Early check that we're not misusing Validator.
-	_, err = canInterface(fieldType, validatorInterface)
+	// Named slice types, e.g. `type Tags []string`, may implement Validator.
+	canValidate, err := implementsValidator(fieldType)
 	if err != nil {
 		return nil, err
 	}
@@ -967,10 +2811,53 @@ func makeSliceDeserializer(fieldPath string, fieldType reflect.Type, options inn
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate a deserializer for %s\n\t * %w", fieldPath, err)
 	}
-	result := func(outPtr *reflect.Value, inValue shared.Value) (err error) {
+
+	// If `true`, reject duplicate elements. If `false`, drop them.
+	var rejectDuplicates bool
+	unique := tags.Unique()
+	if unique != nil {
+		switch *unique {
+		case "true", "error":
+			rejectDuplicates = true
+		case "drop":
+			if fieldType.Kind() == reflect.Array {
+				return nil, fmt.Errorf("at %s, `unique:\"drop\"` cannot be used on arrays, as their length is fixed", fieldPath)
+			}
+			rejectDuplicates = false
+		default:
+			return nil, fmt.Errorf("at %s, invalid `unique` value %s, expected \"error\" or \"drop\"", fieldPath, *unique)
+		}
+		if !fieldType.Elem().Comparable() {
+			return nil, fmt.Errorf("at %s, tag `unique` cannot be used with type %s as elements cannot be compared", fieldPath, fieldType)
+		}
+	}
+
+	// If specified, the field of the elements that must be unique.
+	var uniqueBy *uniqueByField
+	if uniqueByName := tags.UniqueBy(); uniqueByName != nil {
+		uniqueBy, err = makeUniqueByField(fieldPath, fieldType, *uniqueByName, options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Make sure that the default value can be deserialized.
+	if defaultValue != nil {
+		if fieldType.Kind() == reflect.Array && fieldType.Len() != len(defaultValue) {
+			return nil, fmt.Errorf("at %s, invalid `default` value, expecting %d elements, got %d", fieldPath, fieldType.Len(), len(defaultValue))
+		}
+		for i, element := range defaultValue {
+			scratch := reflect.New(fieldType.Elem()).Elem()
+			err = elementDeserializer(&scratch, element, newCallData())
+			if err != nil {
+				return nil, fmt.Errorf("at %s, invalid `default` value, cannot deserialize element %d\n\t * %w", fieldPath, i, err)
+			}
+		}
+	}
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) (err error) {
 		var reflectedResult reflect.Value
 
-		// Note: no defer() to call validation, as Validate cannot be implemented on slices.
 		// Move into slice.
 		var input []shared.Value
 		switch {
@@ -978,26 +2865,41 @@ func makeSliceDeserializer(fieldPath string, fieldType reflect.Type, options inn
 			// Simply deserialize.
 			var ok bool
 			if input, ok = inValue.AsSlice(); !ok {
-				return fmt.Errorf("error while deserializing %s: expected an array", fieldType)
+				dict, isDict := inValue.AsDict()
+				if !options.acceptIndexedObjectsAsArrays || !isDict {
+					return newTypeMismatchError(fieldPath, fieldType.String(), inValue.Interface(), mismatchArray)
+				}
+				if input, err = indexedObjectAsSlice(fieldPath, dict); err != nil {
+					return err
+				}
 			}
 		case isEmptyDefault:
 			// Nothing to deserialize, but we are allowed to default to an empty array.
 			input = make([]shared.Value, 0)
+		case defaultValue != nil:
+			// Nothing to deserialize, but we have a default value.
+			input = defaultValue
 		case orMethod != nil:
 			// Nothing to deserialize, but we know how to build a default value.
-			orMethodResult, err := (*orMethod)()
+			orMethodResult, err := (*orMethod)(call.self)
 			if err != nil {
 				return fmt.Errorf("error in optional value at %s\n\t * %w", fieldPath, err)
 			}
 			reflectedOrMethodSlice := reflect.ValueOf(orMethodResult)
 			result := reflect.MakeSlice(fieldType, 0, reflectedOrMethodSlice.Len())
 			result = reflect.AppendSlice(result, reflectedOrMethodSlice)
+			if canValidate {
+				result, err = validateCollection(fieldPath, fieldType, result, call)
+				if err != nil {
+					return err
+				}
+			}
 			outPtr.Set(result)
 			return nil
 		case wasPreinitialized:
 			// No value? That's ok, we got a value from preinitialization.
 		default:
-			return fmt.Errorf("missing value at %s, expected an array of %s", arrayPath, fieldPath)
+			return MissingFieldError{Path: fieldPath, Type: fieldType.String(), description: missingArray}
 		}
 
 		switch fieldType.Kind() {
@@ -1007,11 +2909,10 @@ func makeSliceDeserializer(fieldPath string, fieldType reflect.Type, options inn
 			// Recurse into entries.
 			for i, inAtIndex := range input {
 				outAtIndex := reflectedResult.Index(i)
-				err := elementDeserializer(&outAtIndex, inAtIndex)
+				err := elementDeserializer(&outAtIndex, inAtIndex, call)
 				if err != nil {
 					return fmt.Errorf("error while deserializing %s[%d]:\n\t * %w", fieldPath, i, err)
 				}
-				reflect.Append(reflectedResult, outAtIndex)
 			}
 		case reflect.Array:
 			if fieldType.Len() != len(input) {
@@ -1021,7 +2922,7 @@ func makeSliceDeserializer(fieldPath string, fieldType reflect.Type, options inn
 			// Recurse into entries.
 			for i, inAtIndex := range input {
 				outAtIndex := reflectedResult.Index(i)
-				err := elementDeserializer(&outAtIndex, inAtIndex)
+				err := elementDeserializer(&outAtIndex, inAtIndex, call)
 				if err != nil {
 					return fmt.Errorf("error while deserializing %s[%d]:\n\t * %w", fieldPath, i, err)
 				}
@@ -1029,12 +2930,130 @@ func makeSliceDeserializer(fieldPath string, fieldType reflect.Type, options inn
 		default:
 			panic("at this stage, we should have either an array or a slice")
 		}
+		if unique != nil {
+			reflectedResult, err = deduplicate(reflectedResult, rejectDuplicates)
+			if err != nil {
+				return validation.WrapError(fieldPath, err)
+			}
+		}
+		if uniqueBy != nil {
+			err = uniqueBy.check(fieldPath, reflectedResult)
+			if err != nil {
+				return err
+			}
+		}
+		if canValidate {
+			reflectedResult, err = validateCollection(fieldPath, fieldType, reflectedResult, call)
+			if err != nil {
+				return err
+			}
+		}
 		outPtr.Set(reflectedResult)
 		return nil
 	}
 	return result, nil
 }
 
+// A field of the elements of a slice that must be unique, as specified
+// with tag `uniqueBy`.
+type uniqueByField struct {
+	// The index of the field in the struct.
+	index int
+
+	// The public name of the field, used for error-reporting.
+	publicName string
+}
+
+// Prepare the check for tag `uniqueBy` on a slice or array of structs
+// (or pointers to structs).
+func makeUniqueByField(fieldPath string, fieldType reflect.Type, name string, options innerOptions) (*uniqueByField, error) {
+	elemType := fieldType.Elem()
+	if elemType.Kind() == reflect.Pointer {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("at %s, tag `uniqueBy` may only be used on slices of structs, got %s", fieldPath, fieldType)
+	}
+	field, ok := elemType.FieldByName(name)
+	if !ok || len(field.Index) != 1 {
+		return nil, fmt.Errorf("at %s, invalid `uniqueBy` value %s, struct %s has no such field", fieldPath, name, typeName(elemType))
+	}
+	if !field.IsExported() {
+		return nil, fmt.Errorf("at %s, invalid `uniqueBy` value %s, field %s of struct %s is private", fieldPath, name, name, typeName(elemType))
+	}
+	if !field.Type.Comparable() {
+		return nil, fmt.Errorf("at %s, invalid `uniqueBy` value %s, values of type %s cannot be compared", fieldPath, name, field.Type)
+	}
+	publicName := name
+	fieldTags, err := tagsPkg.Parse(field.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("at %s, invalid tags for field %s:\n\t * %w", fieldPath, name, err)
+	}
+	if renamed := fieldTags.PublicFieldName(options.renamingTagNames...); renamed != nil && *renamed != "-" {
+		publicName = *renamed
+	}
+	return &uniqueByField{
+		index:      field.Index[0],
+		publicName: publicName,
+	}, nil
+}
+
+// Fail on the first element whose field has already been seen in `list`.
+func (u uniqueByField) check(fieldPath string, list reflect.Value) error {
+	seen := make(map[any]int, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		element := list.Index(i)
+		if element.Kind() == reflect.Pointer {
+			if element.IsNil() {
+				continue
+			}
+			element = element.Elem()
+		}
+		field := element.Field(u.index)
+		if !field.Comparable() {
+			// This can happen with interfaces holding e.g. slices.
+			return validation.WrapError(fmt.Sprintf("%s[%d].%s", fieldPath, i, u.publicName), fmt.Errorf("cannot compare value %v", field))
+		}
+		key := field.Interface()
+		if previous, ok := seen[key]; ok {
+			return validation.WrapError(fmt.Sprintf("%s[%d].%s", fieldPath, i, u.publicName), fmt.Errorf("duplicate value %v, already used at index %d", key, previous))
+		}
+		seen[key] = i
+	}
+	return nil
+}
+
+// Detect duplicate elements in a slice or array of comparable elements.
+//
+// If `rejectDuplicates`, fail on the first duplicate. Otherwise, return a slice
+// without duplicates, keeping the first occurrence of each element.
+func deduplicate(list reflect.Value, rejectDuplicates bool) (reflect.Value, error) {
+	seen := make(map[any]struct{}, list.Len())
+	result := list
+	if !rejectDuplicates {
+		result = reflect.MakeSlice(list.Type(), 0, list.Len())
+	}
+	for i := 0; i < list.Len(); i++ {
+		element := list.Index(i)
+		if !element.Comparable() {
+			// This can happen with interfaces holding e.g. slices.
+			return reflect.Value{}, fmt.Errorf("cannot compare value %v", element.Interface())
+		}
+		key := element.Interface()
+		if _, ok := seen[key]; ok {
+			if rejectDuplicates {
+				return reflect.Value{}, fmt.Errorf("duplicate value %v", key)
+			}
+			continue
+		}
+		seen[key] = struct{}{}
+		if !rejectDuplicates {
+			result = reflect.Append(result, element)
+		}
+	}
+	return result, nil
+}
+
 // Construct a dynamically-typed deserializer for pointers.
 //
 //   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
@@ -1051,7 +3070,8 @@ func makePointerDeserializer(fieldPath string, fieldType reflect.Type, options i
 
 	ptrPath := fmt.Sprint(fieldPath, "*")
 	elemType := fieldType.Elem()
-	subTags := tagsPkg.Empty()
+	// Tags `layout`, `base`, `parsers`, `oneof` and `enumCase` apply to the value we're pointing at.
+	subTags := tags.Only(valueTags...)
 	subContainer := reflect.New(fieldType).Elem()
 	childPreinitialized := wasPreinitialized || tags.IsPreinitialized()
 	elementDeserializer, err := makeFieldDeserializerFromReflect(ptrPath, fieldType.Elem(), options, &subTags, subContainer, childPreinitialized, false)
@@ -1061,31 +3081,85 @@ func makePointerDeserializer(fieldPath string, fieldType reflect.Type, options i
 
 	// True if we support `nil` as default value.
 	isNilDefault := false
+
+	// For pointers to structs or maps, the object to deserialize if no value
+	// is provided, e.g. `{}`.
+	var objectDefault shared.Value
 	if defaultSource := tags.Default(); defaultSource != nil {
-		if *defaultSource == "nil" {
+		switch {
+		case *defaultSource == "nil":
 			isNilDefault = true
-		} else {
-			return nil, fmt.Errorf("at %s, invalid `default` value. The only supported `default` value for pointers is \"nil\", got: %s", fieldPath, *defaultSource)
+		case elemType.Kind() == reflect.Struct || elemType.Kind() == reflect.Map:
+			isZeroDefault, defaultValue, err := parseDefaultObject(fieldPath, options, tags)
+			if err != nil {
+				return nil, err
+			}
+			if isZeroDefault {
+				objectDefault = internal.EmptyValue{}
+			} else {
+				objectDefault = defaultValue
+				if err = checkDefaultObject(fieldPath, elemType, elementDeserializer, defaultValue); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("at %s, invalid `default` value. The only supported `default` value for pointers is \"nil\" (or an object, for pointers to structs or maps), got: %s", fieldPath, *defaultSource)
+		}
+	}
+
+	// If a `defaultEnv` tag is provided, the name of the environment variable
+	// to read if no value is provided, parsed as the type we're pointing at.
+	defaultEnv := tags.DefaultEnv()
+	var envParser *shared.Parser
+	if defaultEnv != nil {
+		envParser = options.lookupParser(elemType)
+		if envParser == nil {
+			return nil, fmt.Errorf("cannot specify `defaultEnv` at %s for type %s as we don't have a parser for such values", fieldPath, fieldType)
 		}
 	}
-	orMethod, err := makeOrMethodConstructor(tags, fieldType, container)
+
+	orMethod, err := makeOrMethodConstructor(fieldPath, tags, fieldType, container, options.customCallTimeout)
 	if err != nil {
 		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", fieldPath, err)
 	}
 
-	result := func(outPtr *reflect.Value, inValue shared.Value) (err error) {
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) (err error) {
+		// If no value is provided, the content of the `defaultEnv` variable, if set.
+		var envInput *string
+		if inValue == nil && !wasPreinitialized && defaultEnv != nil {
+			if envValue, ok := os.LookupEnv(*defaultEnv); ok {
+				envInput = &envValue
+			}
+		}
+
 		switch {
 		case inValue != nil:
 			// We have all the data we need, proced.
 		case wasPreinitialized:
 			// No value? That's ok, we got a value from preinitialization.
 			return nil
+		case envInput != nil:
+			parsed, err := (*envParser)(*envInput)
+			if err != nil {
+				return fmt.Errorf("invalid value at %s, environment variable %s should be a %s\n\t * %w", fieldPath, *defaultEnv, typeName(elemType), err)
+			}
+			reflectedParsed := reflect.ValueOf(parsed)
+			if !reflectedParsed.CanConvert(elemType) {
+				return fmt.Errorf("invalid value at %s, environment variable %s should be a %s", fieldPath, *defaultEnv, typeName(elemType))
+			}
+			reflectedPtrResult := reflect.New(elemType)
+			reflectedPtrResult.Elem().Set(reflectedParsed.Convert(elemType))
+			outPtr.Set(reflectedPtrResult)
+			return nil
 		case isNilDefault:
 			// No value? That's ok for a pointer.
-			*outPtr = reflect.ValueOf(nil)
+			outPtr.SetZero()
 			return nil
+		case objectDefault != nil:
+			// No value? Allocate the object and fill it with its own defaults.
+			inValue = objectDefault
 		case orMethod != nil:
-			result, err := (*orMethod)()
+			result, err := (*orMethod)(call.self)
 			if err != nil {
 				err = fmt.Errorf("error in optional value at %s\n\t * %w", fieldPath, err)
 				slog.Error("Internal error during deserialization", "error", err)
@@ -1102,7 +3176,7 @@ func makePointerDeserializer(fieldPath string, fieldType reflect.Type, options i
 		// Move into ptr
 		reflectedPtrResult := reflect.New(elemType)
 		reflectedResult := reflectedPtrResult.Elem()
-		err = elementDeserializer(&reflectedResult, inValue)
+		err = elementDeserializer(&reflectedResult, inValue, call)
 		if err != nil {
 			return err //nolint:wrapcheck
 		}
@@ -1136,7 +3210,32 @@ func makeFlatFieldDeserializer(fieldPath string, fieldType reflect.Type, options
 	}
 
 	// A parser in case we receive our data as a string.
-	parser := shared.LookupParser(fieldType)
+	parser := options.lookupParser(fieldType)
+	if layout := tags.Layout(); layout != nil && fieldType == timeType {
+		layoutParser, err := makeLayoutParser(fieldPath, *layout)
+		if err != nil {
+			return nil, err
+		}
+		parser = &layoutParser
+	}
+	if base := tags.Base(); base != nil && isIntegerKind(fieldType.Kind()) {
+		baseParser, err := makeBaseParser(fieldPath, fieldType, *base)
+		if err != nil {
+			return nil, err
+		}
+		parser = &baseParser
+	}
+	// If `true`, `parser` is a chain specified with tag `parsers`, whose
+	// errors are worth reporting.
+	hasParserChain := false
+	if names := tags.Parsers(); names != nil {
+		hasParserChain = true
+		chain, err := makeParserChain(fieldPath, options, names)
+		if err != nil {
+			return nil, err
+		}
+		parser = &chain
+	}
 
 	// An unmarshaler in case we receive our data as... something else.
 	var unmarshaler *func(any) (any, error)
@@ -1153,8 +3252,8 @@ func makeFlatFieldDeserializer(fieldPath string, fieldType reflect.Type, options
 		}
 		unmarshaler = &u
 	}
-	// Early check that we're not misusing Validator.
-	_, err = canInterface(fieldType, validatorInterface)
+	// Named types, e.g. `type Percent int`, may implement Validator.
+	canValidate, err := implementsValidator(fieldType)
 	if err != nil {
 		return nil, err
 	}
@@ -1163,120 +3262,932 @@ func makeFlatFieldDeserializer(fieldPath string, fieldType reflect.Type, options
 	var defaultValue any
 	if defaultSource := tags.Default(); defaultSource != nil {
 		// Attempt to generate a default value.
-		if parser == nil {
+		defaultParser := parser
+		if defaultParser == nil {
+			defaultParser = makeTextUnmarshalerParser(fieldType)
+		}
+		if defaultParser == nil {
 			return nil, fmt.Errorf("cannot specify a default value at %s for type %s as we don't have a parser for such values", fieldPath, fieldType)
 		}
-		var err error
-		defaultValue, err = (*parser)(*defaultSource)
-		if err != nil {
-			return nil, fmt.Errorf("cannot parse default value at %s\n\t * %w", fieldPath, err)
+		var err error
+		defaultValue, err = (*defaultParser)(*defaultSource)
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse default value at %s\n\t * %w", fieldPath, err)
+		}
+	}
+
+	// If a `defaultEnv` tag is provided, the name of the environment variable
+	// to read if no value is provided.
+	defaultEnv := tags.DefaultEnv()
+	if defaultEnv != nil && parser == nil {
+		return nil, fmt.Errorf("cannot specify `defaultEnv` at %s for type %s as we don't have a parser for such values", fieldPath, fieldType)
+	}
+
+	// If a `orMethod` tag is provided, a closure to call this method.
+	orMethod, err := makeOrMethodConstructor(fieldPath, tags, fieldType, container, options.customCallTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", fieldPath, err)
+	}
+
+	// Transformations applied, in order, to string inputs.
+	stringTransforms, err := makeStringTransforms(fieldPath, options, tags)
+	if err != nil {
+		return nil, err
+	}
+	enumCaseTransform, err := makeEnumCaseTransform(fieldPath, tags)
+	if err != nil {
+		return nil, err
+	}
+	if enumCaseTransform != nil {
+		// Applied last, so that e.g. `transform:"trim"` has a chance to run first.
+		stringTransforms = append(stringTransforms, enumCaseTransform)
+	}
+
+	// If `true`, an empty string is handled as a missing value, so that we fall
+	// back to `default`/`orMethod`.
+	coerceEmpty := tags.CoerceEmpty() != nil
+
+	// If `true`, reject numbers with a fractional part, rather than truncating them.
+	rejectFractions := options.strictIntegers && isNumericKind(fieldType.Kind()) &&
+		fieldType.Kind() != reflect.Float32 && fieldType.Kind() != reflect.Float64
+
+	// Checks applied to the value once converted, as specified by tags `min`, `max`,
+	// `minlen`, `maxlen`, `pattern`, `check`, `oneof`.
+	//
+	// As bounds may be resolved from the bag of values, the range check is kept separate.
+	rangeCheck, err := makeRangeCheck(fieldPath, fieldType, tags, parser)
+	if err != nil {
+		return nil, err
+	}
+	valueChecks := []func(reflect.Value) error{}
+	stringCheck, err := makeStringCheck(fieldPath, tags)
+	if err != nil {
+		return nil, err
+	}
+	if stringCheck != nil {
+		valueChecks = append(valueChecks, stringCheck)
+	}
+	formatCheck, err := makeFormatCheck(fieldPath, tags)
+	if err != nil {
+		return nil, err
+	}
+	if formatCheck != nil {
+		valueChecks = append(valueChecks, formatCheck)
+	}
+	oneOfCheck, err := makeOneOfCheck(fieldPath, fieldType, tags, parser)
+	if err != nil {
+		return nil, err
+	}
+	if oneOfCheck != nil {
+		valueChecks = append(valueChecks, oneOfCheck)
+	}
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) (err error) {
+		var reflectedInput reflect.Value
+
+		if coerceEmpty && inValue != nil {
+			if inputString, ok := inValue.Interface().(string); ok && inputString == "" {
+				inValue = nil
+			}
+		}
+
+		// If no value is provided, the content of the `defaultEnv` variable, if set.
+		var envInput *string
+		if inValue == nil && !wasPreinitialized && defaultEnv != nil {
+			if envValue, ok := os.LookupEnv(*defaultEnv); ok {
+				envInput = &envValue
+			}
+		}
+
+		var input any
+		switch {
+		case inValue != nil:
+			// We have all the data we need, proceed.
+			input = inValue.Interface()
+			if inputString, ok := input.(string); ok {
+				for _, transform := range stringTransforms {
+					inputString = transform(inputString)
+				}
+				input = inputString
+			}
+		case wasPreinitialized:
+			if outPtr.CanInterface() {
+				input = outPtr.Interface()
+			} else {
+				// This is a private field that was already initialized, nothing to do here.
+				return nil
+			}
+		case envInput != nil:
+			parsed, err := (*parser)(*envInput)
+			if err != nil {
+				return fmt.Errorf("invalid value at %s, environment variable %s should be a %s\n\t * %w", fieldPath, *defaultEnv, typeName, err)
+			}
+			input = parsed
+		case defaultValue != nil:
+			input = defaultValue
+		case orMethod != nil:
+			constructed, err := (*orMethod)(call.self)
+			if err != nil {
+				err = fmt.Errorf("error in optional value at %s\n\t * %w", fieldPath, err)
+				slog.Error("Internal error during deserialization", "error", err)
+				return CustomDeserializerError{
+					Wrapped:   err,
+					Operation: "orMethod",
+					Structure: "field",
+				}
+			}
+			input = constructed
+		default:
+			return MissingFieldError{Path: fieldPath, Type: typeName, description: missingValue}
+		}
+
+		// Type check: can our value convert to the expected type?
+
+		reflectedInput = reflect.ValueOf(input)
+
+		if input == nil {
+			// Case 1: we're dealing with `nil`. In such a case, `CanConvert`, `Convert` and `Set`
+			// panic, so we need to do things manually.
+			switch fieldType.Kind() {
+			case reflect.Pointer:
+				fallthrough
+			case reflect.Interface:
+				// Nothing to do.
+				outPtr.SetZero()
+			default:
+				return newTypeMismatchError(fieldPath, typeName, nil, mismatchValue)
+			}
+		} else {
+			// Case 2: we're not dealing with `nil`. In such a case, let's first unwrap any `shared.Value`.
+			// Then we'll gently ask Go to pretty please `Convert` the input.
+			unwrapped, ok := input.(shared.Value)
+			if ok {
+				input = unwrapped.Interface()
+				reflectedInput = reflect.ValueOf(input)
+			}
+			ok = reflectedInput.CanConvert(fieldType)
+			if _, isNumber := input.(json.Number); isNumber && fieldType.Kind() == reflect.String && fieldType != jsonNumberType {
+				// A `json.Number` is a string in disguise, but it must not be accepted
+				// where a string is expected.
+				ok = false
+			}
+			if !ok {
+				// The input cannot be converted?
+				//
+				// Perhaps we can fix it.
+				recovered := false
+				var parsed any
+				// If all the parsers specified with tag `parsers` failed, their errors.
+				var parserChainErr error
+				if inputBool, ok := input.(bool); ok && options.boolsAsNumbers && isNumericKind(fieldType.Kind()) {
+					// The input is a boolean, but we're looking for a number.
+					// We have been instructed to convert it to 0/1.
+					parsed = 0
+					if inputBool {
+						parsed = 1
+					}
+					recovered = true
+				}
+				if inputNumber, ok := input.(json.Number); ok && isNumericKind(fieldType.Kind()) {
+					// The input is a number decoded without loss of precision.
+					parsed, err = parseJSONNumber(inputNumber, fieldType.Kind())
+					if err == nil {
+						recovered = true
+					} else if errors.Is(err, strconv.ErrRange) {
+						return newTypeMismatchError(fieldPath, typeName, inputNumber, mismatchRange)
+					}
+				}
+				if !recovered && parser != nil {
+					if inputString, ok := input.(string); ok {
+						// The input is represented as a string, but we're not looking for a
+						// string. This can happen e.g. for queries, for which
+						// everything is a string, or for json bodies, in case of client error.
+						//
+						// Regardless, let's try and convert.
+						if options.numberLocalizer != nil && isNumericKind(fieldType.Kind()) {
+							inputString = options.numberLocalizer.Replace(inputString)
+						}
+						parsed, err = (*parser)(inputString)
+						if err == nil {
+							recovered = true
+						} else if errors.Is(err, strconv.ErrRange) {
+							return newTypeMismatchError(fieldPath, typeName, inputString, mismatchRange)
+						} else if hasParserChain {
+							parserChainErr = err
+						}
+					}
+				}
+				if !recovered && unmarshaler != nil {
+					parsed, err = (*unmarshaler)(input)
+					if err == nil {
+						recovered = true
+					}
+				}
+				switch {
+				case recovered:
+					input = parsed
+				case parserChainErr != nil:
+					mismatch := newTypeMismatchError(fieldPath, typeName, input, mismatchValue)
+					mismatch.cause = parserChainErr
+					return mismatch
+				default:
+					return newTypeMismatchError(fieldPath, typeName, input, mismatchValue)
+				}
+				reflectedInput = reflect.ValueOf(input)
+			}
+			if !fitsNumericType(reflectedInput, fieldType) {
+				// Don't let `Convert` silently wrap or truncate the value.
+				return newTypeMismatchError(fieldPath, typeName, input, mismatchRange)
+			}
+			if rejectFractions && reflectedInput.CanFloat() {
+				if f := reflectedInput.Float(); f != math.Trunc(f) {
+					return newTypeMismatchError(fieldPath, typeName, input, mismatchInteger)
+				}
+			}
+			reflectedInput = reflectedInput.Convert(fieldType)
+
+			// Run all checks, so that we may report all the violations at once.
+			violations := []error{}
+			if rangeCheck != nil {
+				violation, err := rangeCheck(reflectedInput, call)
+				if err != nil {
+					return err
+				}
+				if violation != nil {
+					violations = append(violations, violation)
+				}
+			}
+			for _, check := range valueChecks {
+				violation := check(reflectedInput)
+				if violation != nil {
+					violations = append(violations, violation)
+				}
+			}
+			if len(violations) != 0 {
+				return validation.WrapError(fieldPath, joinViolations(violations))
+			}
+			if canValidate {
+				// Validation is implemented on pointers, so we need a pointer.
+				resultPtr := reflect.New(fieldType)
+				resultPtr.Elem().Set(reflectedInput)
+				err = call.validate(fieldPath, resultPtr.Interface())
+				if err != nil {
+					return validation.WrapError(fieldPath, err)
+				}
+				// `Validate()` may have altered the value.
+				reflectedInput = resultPtr.Elem()
+			}
+			outPtr.Set(reflectedInput)
+		}
+
+		return nil
+	}
+	return result, nil
+}
+
+// The type of `json.Number`.
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+// Convert a `json.Number` into an int64, uint64 or float64, depending on `kind`.
+//
+// Integers are parsed without going through `float64`, to avoid losing precision.
+// Numbers with a fractional part or an exponent fall back to `float64`, just as
+// if `UseNumber` had not been specified.
+func parseJSONNumber(number json.Number, kind reflect.Kind) (any, error) {
+	var err error
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var result int64
+		result, err = number.Int64()
+		if err == nil {
+			return result, nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var result uint64
+		result, err = strconv.ParseUint(string(number), 10, 64)
+		if err == nil {
+			return result, nil
+		}
+	default:
+	}
+	if errors.Is(err, strconv.ErrRange) {
+		return nil, err //nolint:wrapcheck
+	}
+	return number.Float64() //nolint:wrapcheck
+}
+
+// Prepare the check to apply to string values, as specified by tags `minlen`, `maxlen`, `pattern`.
+//
+// Lengths are measured in characters (runes), rather than bytes.
+//
+// Returns `nil` if there is nothing to check.
+func makeStringCheck(fieldPath string, tags *tagsPkg.Tags) (func(reflect.Value) error, error) {
+	parseLength := func(key string, source *string) (int, error) {
+		if source == nil {
+			return -1, nil
+		}
+		length, err := strconv.Atoi(*source)
+		if err != nil || length < 0 {
+			return 0, fmt.Errorf("at %s, invalid `%s` value %s, expected a non-negative integer", fieldPath, key, *source)
+		}
+		return length, nil
+	}
+	minLen, err := parseLength("minlen", tags.MinLen())
+	if err != nil {
+		return nil, err
+	}
+	maxLen, err := parseLength("maxlen", tags.MaxLen())
+	if err != nil {
+		return nil, err
+	}
+	if maxLen >= 0 && minLen > maxLen {
+		return nil, fmt.Errorf("at %s, `minlen` value %d is greater than `maxlen` value %d", fieldPath, minLen, maxLen)
+	}
+	var pattern *regexp.Regexp
+	if source := tags.Pattern(); source != nil {
+		pattern, err = regexp.Compile(*source)
+		if err != nil {
+			return nil, fmt.Errorf("at %s, invalid `pattern` value %s\n\t * %w", fieldPath, *source, err)
+		}
+	}
+	if minLen < 0 && maxLen < 0 && pattern == nil {
+		return nil, nil
+	}
+	return func(value reflect.Value) error {
+		str := value.String()
+		length := utf8.RuneCountInString(str)
+		violations := []error{}
+		if minLen >= 0 && length < minLen {
+			violations = append(violations, fmt.Errorf("expected at least %d characters, got %d", minLen, length))
+		}
+		if maxLen >= 0 && length > maxLen {
+			violations = append(violations, fmt.Errorf("expected at most %d characters, got %d", maxLen, length))
+		}
+		if pattern != nil && !pattern.MatchString(str) {
+			violations = append(violations, fmt.Errorf("expected a value matching %s, got %q", pattern, str))
+		}
+		return joinViolations(violations)
+	}, nil
+}
+
+// Several constraints violated by a single value, e.g. both `minlen` and `pattern`.
+type violations []error
+
+func (v violations) Error() string {
+	messages := make([]string, len(v))
+	for i, err := range v {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "\n\t * ")
+}
+
+func (v violations) Unwrap() []error {
+	return v
+}
+
+// Combine the constraints violated by a single value into one error.
+//
+// Returns `nil` if there is no violation.
+func joinViolations(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return violations(errs)
+	}
+}
+
+// Tags that only make sense on numbers.
+var numericOnlyTags = []string{"min", "max"}
+
+// Check that tags that only make sense on numbers are not used on other types.
+func checkNumericOnlyTags(fieldPath string, fieldType reflect.Type, tags *tagsPkg.Tags) error {
+	if isNumericKind(fieldType.Kind()) {
+		return nil
+	}
+	for _, key := range numericOnlyTags {
+		if _, ok := tags.Lookup(key); ok {
+			return fmt.Errorf("at %s, tag `%s` may only be used on numbers, got %s", fieldPath, key, fieldType)
+		}
+	}
+	return nil
+}
+
+// The syntax of references to the bag of values in tags `min`, `max`, e.g. `max:"$maxPageSize"`.
+var boundReference = regexp.MustCompile(`^\$[A-Za-z_][A-Za-z0-9_]*$`)
+
+// A bound specified by tag `min` or `max`.
+type bound struct {
+	// The tag, i.e. `min` or `max`.
+	key string
+
+	// The source, as specified in the tag.
+	source string
+
+	// If the bound is a constant, its value. Otherwise, `nil`.
+	value *reflect.Value
+
+	// If the bound is a reference to the bag of values, the name of the
+	// value, without the leading `$`. Otherwise, `""`.
+	reference string
+}
+
+// Prepare the check to apply to numeric values, as specified by tags `min`, `max`.
+//
+// A bound may be either a constant, e.g. `max:"100"`, or a reference to a value
+// provided in the bag of values at deserialization time (see `DeserializeDictWithValues`),
+// e.g. `max:"$maxPageSize"`.
+//
+// The check returns the constraint violated by the value, if any, or an error if
+// a bound cannot be resolved.
+//
+// Returns `nil` if there is nothing to check.
+func makeRangeCheck(fieldPath string, fieldType reflect.Type, tags *tagsPkg.Tags, parser *shared.Parser) (func(value reflect.Value, call *callData) (violation error, err error), error) {
+	minSource := tags.Min()
+	maxSource := tags.Max()
+	if minSource == nil && maxSource == nil {
+		return nil, nil
+	}
+	if !isNumericKind(fieldType.Kind()) || parser == nil {
+		return nil, fmt.Errorf("at %s, tags `min` and `max` may only be used on numbers, got %s", fieldPath, fieldType)
+	}
+	parseBound := func(key string, source *string) (*bound, error) {
+		if source == nil {
+			return nil, nil
+		}
+		if strings.HasPrefix(*source, "$") {
+			if !boundReference.MatchString(*source) {
+				return nil, fmt.Errorf("at %s, invalid `%s` value %s, expected a number or a reference such as $name", fieldPath, key, *source)
+			}
+			return &bound{
+				key:       key,
+				source:    *source,
+				value:     nil,
+				reference: (*source)[1:],
+			}, nil
+		}
+		parsed, err := (*parser)(*source)
+		if err != nil {
+			return nil, fmt.Errorf("at %s, invalid `%s` value %s for type %s\n\t * %w", fieldPath, key, *source, fieldType, err)
+		}
+		value := reflect.ValueOf(parsed).Convert(fieldType)
+		return &bound{
+			key:       key,
+			source:    *source,
+			value:     &value,
+			reference: "",
+		}, nil
+	}
+	minBound, err := parseBound("min", minSource)
+	if err != nil {
+		return nil, err
+	}
+	maxBound, err := parseBound("max", maxSource)
+	if err != nil {
+		return nil, err
+	}
+	if minBound != nil && maxBound != nil && minBound.value != nil && maxBound.value != nil && compareNumbers(*minBound.value, *maxBound.value) > 0 {
+		return nil, fmt.Errorf("at %s, `min` value %s is greater than `max` value %s", fieldPath, *minSource, *maxSource)
+	}
+
+	// Resolve a bound, looking up references in the bag of values.
+	resolve := func(b *bound, call *callData) (reflect.Value, error) {
+		if b.value != nil {
+			return *b.value, nil
+		}
+		value, ok := call.values[b.reference]
+		if !ok {
+			return reflect.Value{}, fmt.Errorf("at %s, cannot resolve `%s` value %s, no such value was provided", fieldPath, b.key, b.source)
+		}
+		reflected := reflect.ValueOf(value)
+		if valueString, ok := value.(string); ok {
+			parsed, err := (*parser)(valueString)
+			if err == nil {
+				reflected = reflect.ValueOf(parsed)
+			}
+		}
+		if !reflected.IsValid() || !isNumericKind(reflected.Kind()) || !reflected.CanConvert(fieldType) {
+			return reflect.Value{}, fmt.Errorf("at %s, cannot resolve `%s` value %s, expected %s, got %v", fieldPath, b.key, b.source, fieldType, value)
+		}
+		// Don't let `Convert` silently wrap or truncate the bound.
+		if !fitsNumericType(reflected, fieldType) {
+			return reflect.Value{}, fmt.Errorf("at %s, cannot resolve `%s` value %s, %v is out of range for %s", fieldPath, b.key, b.source, value, fieldType)
+		}
+		if reflected.CanFloat() && fieldType.Kind() != reflect.Float32 && fieldType.Kind() != reflect.Float64 && reflected.Float() != math.Trunc(reflected.Float()) {
+			return reflect.Value{}, fmt.Errorf("at %s, cannot resolve `%s` value %s, expected an integer, got %v", fieldPath, b.key, b.source, value)
+		}
+		return reflected.Convert(fieldType), nil
+	}
+	return func(value reflect.Value, call *callData) (violation error, err error) {
+		if minBound != nil {
+			resolved, err := resolve(minBound, call)
+			if err != nil {
+				return nil, err
+			}
+			if compareNumbers(value, resolved) < 0 {
+				return fmt.Errorf("expected a value >= %v, got %v", resolved.Interface(), value.Interface()), nil
+			}
+		}
+		if maxBound != nil {
+			resolved, err := resolve(maxBound, call)
+			if err != nil {
+				return nil, err
+			}
+			if compareNumbers(value, resolved) > 0 {
+				return fmt.Errorf("expected a value <= %v, got %v", resolved.Interface(), value.Interface()), nil
+			}
+		}
+		return nil, nil
+	}, nil
+}
+
+// Prepare the check to apply to values, as specified by tag `oneof`.
+//
+// Returns `nil` if there is nothing to check.
+func makeOneOfCheck(fieldPath string, fieldType reflect.Type, tags *tagsPkg.Tags, parser *shared.Parser) (func(reflect.Value) error, error) {
+	sources := tags.OneOf()
+	if sources == nil {
+		return nil, nil
+	}
+	if parser == nil {
+		return nil, fmt.Errorf("at %s, tag `oneof` cannot be used with type %s as we don't have a parser for such values", fieldPath, fieldType)
+	}
+	if !fieldType.Comparable() {
+		return nil, fmt.Errorf("at %s, tag `oneof` cannot be used with type %s as values cannot be compared", fieldPath, fieldType)
+	}
+	allowed := make([]any, len(sources))
+	for i, source := range sources {
+		parsed, err := (*parser)(source)
+		if err != nil {
+			return nil, fmt.Errorf("at %s, invalid `oneof` value %s for type %s\n\t * %w", fieldPath, source, fieldType, err)
+		}
+		allowed[i] = reflect.ValueOf(parsed).Convert(fieldType).Interface()
+	}
+	return func(value reflect.Value) error {
+		if slices.Contains(allowed, value.Interface()) {
+			return nil
+		}
+		return fmt.Errorf("expected one of %s, got %v", strings.Join(sources, ", "), value.Interface())
+	}, nil
+}
+
+// Prepare the transformation that replaces a string input with the matching
+// `oneof` value, as specified by tag `enumCase:"insensitive"`, e.g. `ACTIVE`
+// with `active` for `oneof:"active,inactive"`.
+//
+// Inputs that match no value are left unchanged, to be rejected by the
+// `oneof` check.
+//
+// Returns `nil` if there is nothing to transform.
+func makeEnumCaseTransform(fieldPath string, tags *tagsPkg.Tags) (func(string) string, error) {
+	enumCase := tags.EnumCase()
+	if enumCase == nil {
+		return nil, nil
+	}
+	switch *enumCase {
+	case "sensitive":
+		return nil, nil
+	case "insensitive":
+	default:
+		return nil, fmt.Errorf("at %s, invalid `enumCase` value %s, expected sensitive or insensitive", fieldPath, *enumCase)
+	}
+	sources := tags.OneOf()
+	if sources == nil {
+		return nil, fmt.Errorf("at %s, tag `enumCase` may only be used along with tag `oneof`", fieldPath)
+	}
+	return func(source string) string {
+		for _, canonical := range sources {
+			if strings.EqualFold(source, canonical) {
+				return canonical
+			}
+		}
+		return source
+	}, nil
+}
+
+// Compare two numbers of the same type.
+//
+// Returns a negative number if `a < b`, 0 if `a == b`, a positive number if `a > b`.
+func compareNumbers(a reflect.Value, b reflect.Value) int {
+	switch {
+	case a.CanInt():
+		return cmp.Compare(a.Int(), b.Int())
+	case a.CanUint():
+		return cmp.Compare(a.Uint(), b.Uint())
+	case a.CanFloat():
+		return cmp.Compare(a.Float(), b.Float())
+	default:
+		panic(fmt.Sprintf("invalid call: %s is not a number", a.Type()))
+	}
+}
+
+// Determine whether a kind represents a number.
+func isNumericKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// Determine whether a kind represents an integer.
+func isIntegerKind(kind reflect.Kind) bool {
+	return isNumericKind(kind) && kind != reflect.Float32 && kind != reflect.Float64
+}
+
+// Determine whether a number may be converted to `typ` without overflowing.
+//
+// Returns `true` if either `value` or `typ` is not a number.
+func fitsNumericType(value reflect.Value, typ reflect.Type) bool {
+	if !isNumericKind(value.Kind()) || !isNumericKind(typ.Kind()) {
+		return true
+	}
+	target := reflect.New(typ).Elem()
+	switch {
+	case target.CanInt():
+		switch {
+		case value.CanInt():
+			return !target.OverflowInt(value.Int())
+		case value.CanUint():
+			return value.Uint() <= math.MaxInt64 && !target.OverflowInt(int64(value.Uint()))
+		default:
+			f := value.Float()
+			return !math.IsNaN(f) && f >= math.MinInt64 && f < math.MaxInt64 && !target.OverflowInt(int64(f))
+		}
+	case target.CanUint():
+		switch {
+		case value.CanInt():
+			return value.Int() >= 0 && !target.OverflowUint(uint64(value.Int()))
+		case value.CanUint():
+			return !target.OverflowUint(value.Uint())
+		default:
+			f := value.Float()
+			return !math.IsNaN(f) && f >= 0 && f < math.MaxUint64 && !target.OverflowUint(uint64(f))
+		}
+	default:
+		return !value.CanFloat() || !target.OverflowFloat(value.Float())
+	}
+}
+
+// Determine whether a kind represents a flat value, i.e. a boolean, a number or a string.
+func isFlatKind(kind reflect.Kind) bool {
+	return kind == reflect.Bool || kind == reflect.String || isNumericKind(kind)
+}
+
+// The built-in string transforms, by name, for tag `transform`.
+var builtinTransforms = map[string]func(string) string{
+	"trim":  strings.TrimSpace,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"collapseSpaces": func(source string) string {
+		return strings.Join(strings.Fields(source), " ")
+	},
+}
+
+// Find a string transform by name.
+//
+// Custom transforms take precedence over built-in transforms.
+func (options innerOptions) lookupTransform(name string) func(string) string {
+	if transform, ok := options.transforms[name]; ok {
+		return transform
+	}
+	return builtinTransforms[name]
+}
+
+// Prepare the transformations to apply to string inputs, as specified by tags
+// `trimPrefix`, `trimSuffix` and `transform`, in this order.
+func makeStringTransforms(fieldPath string, options innerOptions, tags *tagsPkg.Tags) ([]func(string) string, error) {
+	transforms := []func(string) string{}
+	if prefix := tags.TrimPrefix(); prefix != nil {
+		transforms = append(transforms, func(source string) string {
+			return strings.TrimPrefix(source, *prefix)
+		})
+	}
+	if suffix := tags.TrimSuffix(); suffix != nil {
+		transforms = append(transforms, func(source string) string {
+			return strings.TrimSuffix(source, *suffix)
+		})
+	}
+	if pipeline := tags.Transform(); pipeline != nil {
+		for _, name := range strings.Split(*pipeline, "|") {
+			name = strings.TrimSpace(name)
+			transform := options.lookupTransform(name)
+			if transform == nil {
+				return nil, fmt.Errorf("at %s, unknown transform %q, please register it in `Options.Transforms`", fieldPath, name)
+			}
+			transforms = append(transforms, transform)
+		}
+	}
+	return transforms, nil
+}
+
+// Tags that only make sense on flat values, i.e. neither structs, maps, slices nor arrays.
+var flatOnlyTags = []string{"coerceEmpty", "defaultEnv", "oneof"}
+
+// Tags that pointers and `Nullable` forward to the value they wrap.
+var valueTags = []string{"layout", "base", "parsers", "oneof", "enumCase"}
+
+// Determine whether `typ` forwards `valueTags` to the value it wraps,
+// in which case these tags are checked against the value.
+func forwardsValueTags(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Pointer || (typ.Kind() == reflect.Struct && typ.Implements(nullableInterface))
+}
+
+// Check that tags that only make sense on flat values are not used on other types.
+func checkFlatOnlyTags(fieldPath string, fieldType reflect.Type, tags *tagsPkg.Tags) error {
+	switch fieldType.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+	default:
+		return nil
+	}
+	for _, key := range flatOnlyTags {
+		if forwardsValueTags(fieldType) && slices.Contains(valueTags, key) {
+			continue
+		}
+		if _, ok := tags.Lookup(key); ok {
+			return fmt.Errorf("at %s, tag `%s` may only be used on flat values, got %s", fieldPath, key, fieldType)
+		}
+	}
+	return nil
+}
+
+// Tags that only make sense on strings.
+var stringOnlyTags = []string{"trimPrefix", "trimSuffix", "transform", "enumCase", "minlen", "maxlen", "pattern", "check"}
+
+// Check that tags that only make sense on strings are not used on other types.
+func checkStringOnlyTags(fieldPath string, fieldType reflect.Type, tags *tagsPkg.Tags) error {
+	if fieldType.Kind() == reflect.String {
+		return nil
+	}
+	for _, key := range stringOnlyTags {
+		if forwardsValueTags(fieldType) && slices.Contains(valueTags, key) {
+			continue
+		}
+		if _, ok := tags.Lookup(key); ok {
+			return fmt.Errorf("at %s, tag `%s` may only be used on strings, got %s", fieldPath, key, fieldType)
+		}
+	}
+	return nil
+}
+
+// Construct a parser calling `UnmarshalText`, if `typ` implements `TextUnmarshaler`.
+//
+// Returns `nil` otherwise.
+func makeTextUnmarshalerParser(typ reflect.Type) *shared.Parser {
+	if !reflect.PointerTo(typ).Implements(textUnmarshalerInterface) {
+		return nil
+	}
+	var parser shared.Parser = func(source string) (any, error) {
+		ptrResult := reflect.New(typ)
+		unmarshaler, ok := ptrResult.Interface().(encoding.TextUnmarshaler)
+		if !ok {
+			panic(fmt.Sprintf("type %s should implement TextUnmarshaler", typeName(typ)))
+		}
+		if err := unmarshaler.UnmarshalText([]byte(source)); err != nil {
+			return nil, err //nolint:wrapcheck
 		}
+		return ptrResult.Elem().Interface(), nil
 	}
+	return &parser
+}
 
-	// If a `orMethod` tag is provided, a closure to call this method.
-	orMethod, err := makeOrMethodConstructor(tags, fieldType, container)
-	if err != nil {
-		return nil, fmt.Errorf("at %s, failed to setup `orMethod`\n\t * %w", fieldPath, err)
+// Determine whether the `default` of a field of structured type should be
+// parsed with `UnmarshalText`, i.e. the type implements `TextUnmarshaler`,
+// has no parser and the default is not written as an object or a list.
+func hasTextDefault(fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags) bool {
+	defaultSource := tags.Default()
+	if defaultSource == nil {
+		return false
 	}
-	result := func(outPtr *reflect.Value, inValue shared.Value) (err error) {
-		var reflectedInput reflect.Value
+	switch fieldType.Kind() {
+	case reflect.Array, reflect.Slice, reflect.Struct, reflect.Map:
+	default:
+		return false
+	}
+	if options.lookupParser(fieldType) != nil || !reflect.PointerTo(fieldType).Implements(textUnmarshalerInterface) {
+		return false
+	}
+	trimmed := strings.TrimSpace(*defaultSource)
+	return !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[")
+}
 
-		// No defer-time validation here, as a flat value cannot implement `Validator`.
+// Construct a parser for `time.Time` values following tag `layout`.
+//
+// We reject layouts that cannot round-trip a reference time, as they would
+// reject every input.
+func makeLayoutParser(fieldPath string, layout string) (shared.Parser, error) {
+	reference := time.Date(2001, time.February, 3, 4, 5, 6, 0, time.UTC)
+	formatted := reference.Format(layout)
+	if formatted == layout {
+		return nil, fmt.Errorf("at %s, invalid `layout` value %q, it does not contain any date or time element", fieldPath, layout)
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return nil, fmt.Errorf("at %s, invalid `layout` value %q:\n\t * %w", fieldPath, layout, err)
+	}
+	return func(source string) (any, error) {
+		return time.Parse(layout, source) //nolint:wrapcheck
+	}, nil
+}
 
-		var input any
-		switch {
-		case inValue != nil:
-			// We have all the data we need, proceed.
-			input = inValue.Interface()
-		case wasPreinitialized:
-			if outPtr.CanInterface() {
-				input = outPtr.Interface()
-			} else {
-				// This is a private field that was already initialized, nothing to do here.
-				return nil
-			}
-		case defaultValue != nil:
-			input = defaultValue
-		case orMethod != nil:
-			constructed, err := (*orMethod)()
-			if err != nil {
-				err = fmt.Errorf("error in optional value at %s\n\t * %w", fieldPath, err)
-				slog.Error("Internal error during deserialization", "error", err)
-				return CustomDeserializerError{
-					Wrapped:   err,
-					Operation: "orMethod",
-					Structure: "field",
-				}
-			}
-			input = constructed
-		default:
-			return fmt.Errorf("missing value at %s, expected %s", fieldPath, typeName)
+// Construct a parser for integers following tag `base`.
+//
+// With an explicit base, the matching prefix is optional, e.g. both `0xff`
+// and `ff` are accepted with `base:"16"`. With `base:"0"`, the base is
+// detected from the prefix, as `strconv.ParseInt`.
+func makeBaseParser(fieldPath string, fieldType reflect.Type, source string) (shared.Parser, error) {
+	base, err := strconv.Atoi(source)
+	if err != nil || base == 1 || base < 0 || base > 36 {
+		return nil, fmt.Errorf("at %s, invalid `base` value %s, expected 0 or an integer between 2 and 36", fieldPath, source)
+	}
+	prefix := ""
+	switch base {
+	case 2:
+		prefix = "0b"
+	case 8:
+		prefix = "0o"
+	case 16:
+		prefix = "0x"
+	}
+	bits := fieldType.Bits()
+	signed := fieldType.Kind() >= reflect.Int && fieldType.Kind() <= reflect.Int64
+	return func(input string) (any, error) {
+		sign := ""
+		if strings.HasPrefix(input, "-") || strings.HasPrefix(input, "+") {
+			sign, input = input[:1], input[1:]
+		}
+		if prefix != "" && len(input) > len(prefix) && strings.EqualFold(input[:len(prefix)], prefix) {
+			input = input[len(prefix):]
+		}
+		if signed {
+			return strconv.ParseInt(sign+input, base, bits) //nolint:wrapcheck
 		}
+		return strconv.ParseUint(sign+input, base, bits) //nolint:wrapcheck
+	}, nil
+}
 
-		// Type check: can our value convert to the expected type?
+// The built-in parsers, by name, for tag `parsers`.
+var builtinNamedParsers = map[string]shared.Parser{
+	"rfc3339": func(source string) (any, error) {
+		return time.Parse(time.RFC3339, source) //nolint:wrapcheck
+	},
+	"unix": func(source string) (any, error) {
+		seconds, err := strconv.ParseInt(source, 10, 64)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+		return time.Unix(seconds, 0).UTC(), nil
+	},
+	"date": func(source string) (any, error) {
+		return time.Parse(time.DateOnly, source) //nolint:wrapcheck
+	},
+}
 
-		reflectedInput = reflect.ValueOf(input)
+// Find a parser by name.
+//
+// Custom parsers take precedence over built-in parsers.
+func (options innerOptions) lookupNamedParser(name string) *shared.Parser {
+	if parser, ok := options.namedParsers[name]; ok {
+		return &parser
+	}
+	if parser, ok := builtinNamedParsers[name]; ok {
+		return &parser
+	}
+	return nil
+}
 
-		if input == nil {
-			// Case 1: we're dealing with `nil`. In such a case, `CanConvert`, `Convert` and `Set`
-			// panic, so we need to do things manually.
-			switch fieldType.Kind() {
-			case reflect.Pointer:
-				fallthrough
-			case reflect.Interface:
-				// Nothing to do.
-				outPtr.SetZero()
-			default:
-				return fmt.Errorf("invalid value at %s, expected %s, got <nil>", fieldPath, typeName)
-			}
-		} else {
-			// Case 2: we're not dealing with `nil`. In such a case, let's first unwrap any `shared.Value`.
-			// Then we'll gently ask Go to pretty please `Convert` the input.
-			unwrapped, ok := input.(shared.Value)
-			if ok {
-				input = unwrapped.Interface()
-				reflectedInput = reflect.ValueOf(input)
-			}
-			ok = reflectedInput.CanConvert(fieldType)
-			if !ok {
-				// The input cannot be converted?
-				//
-				// Perhaps we can fix it.
-				recovered := false
-				var parsed any
-				if parser != nil {
-					if inputString, ok := input.(string); ok {
-						// The input is represented as a string, but we're not looking for a
-						// string. This can happen e.g. for queries, for which
-						// everything is a string, or for json bodies, in case of client error.
-						//
-						// Regardless, let's try and convert.
-						parsed, err = (*parser)(inputString)
-						if err == nil {
-							recovered = true
-						}
-					}
-				}
-				if !recovered && unmarshaler != nil {
-					parsed, err = (*unmarshaler)(input)
-					if err == nil {
-						recovered = true
-					}
-				}
-				if recovered {
-					input = parsed
-				} else {
-					return fmt.Errorf("invalid value at %s, expected %s, got %v", fieldPath, typeName, input)
-				}
-				reflectedInput = reflect.ValueOf(input)
-			}
-			reflectedInput = reflectedInput.Convert(fieldType)
-			outPtr.Set(reflectedInput)
+// Construct a parser trying the parsers specified with tag `parsers`, in
+// order, until one of them succeeds.
+//
+// If all of them fail, the error lists the error of each parser.
+func makeParserChain(fieldPath string, options innerOptions, names []string) (shared.Parser, error) {
+	chain := make([]shared.Parser, len(names))
+	for i, name := range names {
+		parser := options.lookupNamedParser(name)
+		if parser == nil {
+			return nil, fmt.Errorf("at %s, unknown parser %q, please register it in `Options.NamedParsers`", fieldPath, name)
 		}
-
-		return nil
+		chain[i] = *parser
 	}
-	return result, nil
+	return func(source string) (any, error) {
+		errs := make([]error, len(chain))
+		for i, parser := range chain {
+			result, err := parser(source)
+			if err == nil {
+				return result, nil
+			}
+			errs[i] = err
+		}
+		err := fmt.Errorf("none of the parsers %s accepts %q", strings.Join(names, ", "), source)
+		for i, name := range names {
+			err = fmt.Errorf("%w\n\t * %s: %w", err, name, errs[i])
+		}
+		return nil, err
+	}, nil
 }
 
 // Construct a dynamically-typed deserializer for any field.
@@ -1286,6 +4197,27 @@ func makeFlatFieldDeserializer(fieldPath string, fieldType reflect.Type, options
 //   - `tagName` the name of tags to use for field renamings, e.g. `query`;
 //   - `tags` the table of tags for this field.
 func makeFieldDeserializerFromReflect(fieldPath string, fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreinitialized bool, wasFlattened bool) (reflectDeserializer, error) {
+	// If the value is escaped (e.g. JSON embedded in a string), we need to unescape it
+	// before deserializing. We only unescape once.
+	if unescape := tags.Unescape(); unescape != nil {
+		innerTags := tags.Without("unescape")
+		wrapped, err := makeFieldDeserializerFromReflect(fieldPath, fieldType, options, &innerTags, container, wasPreinitialized, wasFlattened)
+		if err != nil {
+			return nil, err
+		}
+		return makeUnescapeDeserializer(fieldPath, *unescape, wrapped)
+	}
+
+	// If the value must match a JSON Schema, check it before deserializing.
+	if schemaName := tags.Schema(); schemaName != nil {
+		innerTags := tags.Without("schema")
+		wrapped, err := makeFieldDeserializerFromReflect(fieldPath, fieldType, options, &innerTags, container, wasPreinitialized, wasFlattened)
+		if err != nil {
+			return nil, err
+		}
+		return makeSchemaDeserializer(fieldPath, *schemaName, options, wrapped)
+	}
+
 	if !wasFlattened {
 		err := options.unmarshaler.Enter(fieldPath, fieldType)
 		if err != nil {
@@ -1296,7 +4228,99 @@ func makeFieldDeserializerFromReflect(fieldPath string, fieldType reflect.Type,
 		}()
 	}
 
-	var err error
+	err := checkStringOnlyTags(fieldPath, fieldType, tags)
+	if err != nil {
+		return nil, err
+	}
+	if tags.RejectEmptyKeys() && fieldType.Kind() != reflect.Map {
+		return nil, fmt.Errorf("at %s, tag `rejectEmptyKeys` may only be used on maps, got %s", fieldPath, fieldType)
+	}
+	if tags.AllowedKeys() != nil && fieldType.Kind() != reflect.Map {
+		return nil, fmt.Errorf("at %s, tag `allowedKeys` may only be used on maps, got %s", fieldPath, fieldType)
+	}
+	if coerceEmpty := tags.CoerceEmpty(); coerceEmpty != nil {
+		if *coerceEmpty != "default" {
+			return nil, fmt.Errorf("at %s, invalid `coerceEmpty` value %s, expected \"default\"", fieldPath, *coerceEmpty)
+		}
+	}
+	err = checkFlatOnlyTags(fieldPath, fieldType, tags)
+	if err != nil {
+		return nil, err
+	}
+	if tags.Unique() != nil && fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+		return nil, fmt.Errorf("at %s, tag `unique` may only be used on slices or arrays, got %s", fieldPath, fieldType)
+	}
+	if tags.UniqueBy() != nil && fieldType.Kind() != reflect.Slice && fieldType.Kind() != reflect.Array {
+		return nil, fmt.Errorf("at %s, tag `uniqueBy` may only be used on slices or arrays, got %s", fieldPath, fieldType)
+	}
+	if tags.AtLeastOne() != nil && fieldType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("at %s, tag `atLeastOne` may only be used on structs, got %s", fieldPath, fieldType)
+	}
+	if tags.AtLeast() != nil && fieldType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("at %s, tag `atLeast` may only be used on structs, got %s", fieldPath, fieldType)
+	}
+	if tags.Split() != nil && fieldType.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("at %s, tag `split` may only be used on structs, got %s", fieldPath, fieldType)
+	}
+	if tags.Base() != nil && !isIntegerKind(fieldType.Kind()) && (fieldType.Kind() != reflect.Pointer || !isIntegerKind(fieldType.Elem().Kind())) {
+		return nil, fmt.Errorf("at %s, tag `base` may only be used on integers, got %s", fieldPath, fieldType)
+	}
+	if tags.Layout() != nil && fieldType != timeType && fieldType != reflect.PointerTo(timeType) {
+		return nil, fmt.Errorf("at %s, tag `layout` may only be used on time.Time, got %s", fieldPath, fieldType)
+	}
+	if tags.Parsers() != nil && (tags.Layout() != nil || tags.Base() != nil) {
+		return nil, fmt.Errorf("at %s, tag `parsers` cannot be combined with tags `layout` or `base`", fieldPath)
+	}
+	err = checkNumericOnlyTags(fieldPath, fieldType, tags)
+	if err != nil {
+		return nil, err
+	}
+
+	// If a codec is specified, it takes over deserialization.
+	if codecName := tags.Codec(); codecName != nil {
+		return makeCodecDeserializer(fieldPath, fieldType, *codecName, options, tags, wasPreinitialized)
+	}
+
+	// Raw values are captured without deserialization.
+	if fieldType == rawCaptureType || fieldType == rawMessageType {
+		return makeRawCaptureDeserializer(fieldPath, fieldType, options, tags, wasPreinitialized)
+	}
+
+	if fieldType.Kind() == reflect.Struct && fieldType.Implements(oneOfInterface) {
+		return makeOneOfDeserializer(fieldPath, fieldType, options, tags, wasPreinitialized)
+	}
+
+	if fieldType.Kind() == reflect.Struct && fieldType.Implements(nullableInterface) {
+		return makeNullableDeserializer(fieldPath, fieldType, options, tags, wasPreinitialized)
+	}
+
+	// If the type knows how to deserialize itself from any value, this takes
+	// precedence over everything else.
+	if fieldType.Kind() != reflect.Pointer {
+		canUnmarshalValue, err := canInterface(fieldType, unmarshalValueInterface)
+		if err != nil {
+			return nil, err
+		}
+		if canUnmarshalValue {
+			return makeUnmarshalValueDeserializer(fieldPath, fieldType, tags, wasPreinitialized)
+		}
+	}
+
+	// With a `layout`, times are flat values, parsed from strings. Likewise,
+	// with `parsers`, for any struct.
+	if (fieldType == timeType && tags.Layout() != nil) || (fieldType.Kind() == reflect.Struct && tags.Parsers() != nil) {
+		return makeFlatFieldDeserializer(fieldPath, fieldType, options, tags, container, wasPreinitialized)
+	}
+
+	// With a `default` parsed by `UnmarshalText`, the structured deserializer
+	// must not see the default and missing values go to the flat deserializer.
+	textDefault := hasTextDefault(fieldType, options, tags)
+	structuredTags := tags
+	if textDefault {
+		withoutDefault := tags.Without("default")
+		structuredTags = &withoutDefault
+	}
+
 	var structured reflectDeserializer
 
 	switch fieldType.Kind() {
@@ -1305,11 +4329,19 @@ func makeFieldDeserializerFromReflect(fieldPath string, fieldType reflect.Type,
 	case reflect.Array:
 		fallthrough
 	case reflect.Slice:
-		structured, err = makeSliceDeserializer(fieldPath, fieldType, options, tags, container, wasPreinitialized)
+		structured, err = makeSliceDeserializer(fieldPath, fieldType, options, structuredTags, container, wasPreinitialized)
 	case reflect.Struct:
-		structured, err = makeStructDeserializerFromReflect(fieldPath, fieldType, options, tags, container, wasPreinitialized)
+		structured, err = makeStructDeserializerFromReflect(fieldPath, fieldType, options, structuredTags, container, wasPreinitialized, wasFlattened)
 	case reflect.Map:
-		structured, err = makeMapDeserializerFromReflect(fieldPath, fieldType, options, tags, container, wasPreinitialized)
+		structured, err = makeMapDeserializerFromReflect(fieldPath, fieldType, options, structuredTags, container, wasPreinitialized)
+	case reflect.Interface:
+		if union, ok := lookupUnion(fieldType); ok {
+			return makeUnionDeserializer(fieldPath, fieldType, union, options, tags, wasPreinitialized)
+		}
+		if defaultSource := tags.Default(); defaultSource != nil && *defaultSource == "nil" {
+			return makeNilDefaultInterfaceDeserializer(fieldPath, fieldType, options, tags, container, wasPreinitialized)
+		}
+		// Otherwise, we'll have to try with a flat field deserializer (see below).
 	default:
 		// We'll have to try with a flat field deserializer (see below).
 	}
@@ -1333,17 +4365,24 @@ func makeFieldDeserializerFromReflect(fieldPath string, fieldType reflect.Type,
 		return nil, fmt.Errorf("could not generate a deserializer for %s with type %s:\n\t * %w", fieldPath, typeName(fieldType), flatError)
 	}
 	if flatError != nil {
+		if textDefault {
+			// Only the flat deserializer knows about the default.
+			return nil, flatError
+		}
 		// We have a structured deserializer and that's the only way we can deserialize this structure.
 		return structured, nil
 	}
 	// We have both a flat and a structured deserializer. Need to try both!
-	var combined reflectDeserializer = func(slot *reflect.Value, data shared.Value) error {
-		err := structured(slot, data)
+	var combined reflectDeserializer = func(slot *reflect.Value, data shared.Value, call *callData) error {
+		if textDefault && data == nil {
+			return flat(slot, data, call)
+		}
+		err := structured(slot, data, call)
 		if err == nil || errors.As(err, &validation.Error{}) { //nolint:exhaustruct
 			// Don't try to recover from a validation error by switching to the next deserializer!
 			return err
 		}
-		err2 := flat(slot, data)
+		err2 := flat(slot, data, call)
 		if err2 == nil {
 			return nil
 		}
@@ -1352,6 +4391,104 @@ func makeFieldDeserializerFromReflect(fieldPath string, fieldType reflect.Type,
 	return combined, nil
 }
 
+// Construct a dynamically-typed deserializer for an interface field with `default:"nil"`.
+//
+// If no value is provided, the field is set to `nil`. Otherwise, the value is
+// handled as by a flat field deserializer.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `fieldType` the dynamic type for the interface being compiled;
+//   - `tags` the table of tags for this field.
+func makeNilDefaultInterfaceDeserializer(fieldPath string, fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags, container reflect.Value, wasPreinitialized bool) (reflectDeserializer, error) {
+	// `nil` has no parser, so the flat field deserializer must not see the default.
+	withoutDefault := tags.Without("default")
+	flat, err := makeFlatFieldDeserializer(fieldPath, fieldType, options, &withoutDefault, container, wasPreinitialized)
+	if err != nil {
+		return nil, fmt.Errorf("could not generate a deserializer for %s with type %s:\n\t * %w", fieldPath, typeName(fieldType), err)
+	}
+	coerceEmpty := tags.CoerceEmpty() != nil
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if coerceEmpty && inValue != nil {
+			if inputString, ok := inValue.Interface().(string); ok && inputString == "" {
+				inValue = nil
+			}
+		}
+		if inValue == nil && !wasPreinitialized {
+			outPtr.SetZero()
+			return nil
+		}
+		return flat(outPtr, inValue, call)
+	}
+	return result, nil
+}
+
+// Construct a dynamically-typed deserializer for a field whose value may be escaped,
+// e.g. JSON embedded in a string.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `format` the format of the escaped value, e.g. "json";
+//   - `wrapped` the deserializer for the unescaped value.
+func makeUnescapeDeserializer(fieldPath string, format string, wrapped reflectDeserializer) (reflectDeserializer, error) {
+	if format != JSON {
+		return nil, fmt.Errorf("at %s, invalid `unescape` value %s, expected %s", fieldPath, format, JSON)
+	}
+	driver := jsonPkg.Driver()
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue != nil {
+			if source, ok := inValue.Interface().(string); ok {
+				decoded := new(any)
+				err := driver.Unmarshal(source, decoded)
+				if err != nil {
+					return fmt.Errorf("invalid %s value at %s\n\t * %w", format, fieldPath, err)
+				}
+				inValue = driver.WrapValue(*decoded)
+			}
+			// Otherwise, the value is not escaped, use it as is.
+		}
+		return wrapped(outPtr, inValue, call)
+	}
+	return result, nil
+}
+
+// Construct a dynamically-typed deserializer for a type that implements `shared.UnmarshalValue`.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `fieldType` the dynamic type for the field being compiled;
+//   - `tags` the table of tags for this field.
+func makeUnmarshalValueDeserializer(fieldPath string, fieldType reflect.Type, tags *tagsPkg.Tags, wasPreinitialized bool) (reflectDeserializer, error) {
+	if tags.Default() != nil || tags.MethodName() != nil {
+		return nil, fmt.Errorf("at %s, type %s implements UnmarshalValue, it cannot have a `default` or `orMethod`", fieldPath, typeName(fieldType))
+	}
+	canValidate, err := implementsValidator(fieldType)
+	if err != nil {
+		return nil, err
+	}
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue == nil && wasPreinitialized {
+			// No value? That's ok, we got a value from preinitialization.
+			return nil
+		}
+		resultPtr := reflect.New(fieldType)
+		unmarshalValue, ok := resultPtr.Interface().(shared.UnmarshalValue)
+		if !ok {
+			panic("at this stage, we should have an UnmarshalValue") // We have checked this already when setting up the deserializer.
+		}
+		err := unmarshalValue.UnmarshalValue(inValue)
+		if err != nil {
+			return fmt.Errorf("at %s, expected to be able to parse a %s:\n\t * %w", fieldPath, typeName(fieldType), err)
+		}
+		if canValidate {
+			err = call.validate(fieldPath, resultPtr.Interface())
+			if err != nil {
+				return validation.WrapError(fieldPath, err)
+			}
+		}
+		outPtr.Set(resultPtr.Elem())
+		return nil
+	}
+	return result, nil
+}
+
 // Return a (mostly) human-readable type name for a Go type.
 //
 // This type name is used for user error messages.
@@ -1362,17 +4499,32 @@ func typeName(typ reflect.Type) string {
 }
 
 // A custom constructor provided with tag `orMethod`.
-type orMethodConstructor func() (any, error)
+//
+// `self` is a pointer to the struct being deserialized, used if the
+// method takes the struct as argument.
+type orMethodConstructor func(self reflect.Value) (any, error)
+
+// Return `true` if the method provided with `orMethod`, if any, takes the struct as argument.
+func orMethodTakesSelf(tags *tagsPkg.Tags, container reflect.Value) bool {
+	methodName := tags.MethodName()
+	if methodName == nil {
+		return false
+	}
+	method := container.MethodByName(*methodName)
+	return method.IsValid() && method.Type().NumIn() == 1
+}
 
-func makeOrMethodConstructor(tags *tagsPkg.Tags, fieldType reflect.Type, container reflect.Value) (*orMethodConstructor, error) {
+func makeOrMethodConstructor(path string, tags *tagsPkg.Tags, fieldType reflect.Type, container reflect.Value, timeout time.Duration) (*orMethodConstructor, error) {
 	var defaultMethodConstructor *orMethodConstructor
 	if defaultMethodConstructorName := tags.MethodName(); defaultMethodConstructorName != nil {
 		method := container.MethodByName(*defaultMethodConstructorName)
 		if method.IsValid() {
 			typ := method.Type()
 			switch {
-			case typ.NumIn() != 0:
-				return nil, fmt.Errorf("the method provided with `orMethod` MUST take no argument but takes %d arguments", typ.NumIn())
+			case typ.NumIn() > 1:
+				return nil, fmt.Errorf("the method provided with `orMethod` MUST take no argument or a single argument %s but takes %d arguments", container.Type(), typ.NumIn())
+			case typ.NumIn() == 1 && typ.In(0) != container.Type():
+				return nil, fmt.Errorf("the method provided with `orMethod` MUST take no argument or a single argument %s but takes an argument %s", container.Type(), typ.In(0))
 			case typ.NumOut() != 2: //nolint:mnd
 				return nil, fmt.Errorf("the method provided with `orMethod` MUST return (%s, error) but it returns %d value(s)", fieldType.Name(), typ.NumOut())
 			case !typ.Out(0).ConvertibleTo(fieldType):
@@ -1380,17 +4532,26 @@ func makeOrMethodConstructor(tags *tagsPkg.Tags, fieldType reflect.Type, contain
 			case !typ.Out(1).ConvertibleTo(errorInterface):
 				return nil, fmt.Errorf("the method provided with `orMethod` MUST return (%s, error) but it returns (_, %s) which is not convertible to `error`", fieldType.Name(), typ.Out(1).Name())
 			}
-			args := make([]reflect.Value, 0)
-			var methodConstructor orMethodConstructor = func() (any, error) {
-				out := method.Call(args)
-				result := out[0].Interface() // We have just checked that it MUST be convertible to `any`.
-				var err error
-				err, ok := out[1].Interface().(error) // We have just checked that it MUST be convertible to `error`.
-				if !ok {
-					// Conversion failure? This means that `out[1]` is `nil`.
-					return result, nil
+			takesSelf := typ.NumIn() == 1
+			var methodConstructor orMethodConstructor = func(self reflect.Value) (any, error) {
+				args := make([]reflect.Value, 0, 1)
+				if takesSelf {
+					if !self.IsValid() || self.Type() != typ.In(0) {
+						return nil, fmt.Errorf("the method provided with `orMethod` expects a %s, which is not available here", typ.In(0))
+					}
+					args = append(args, self)
 				}
-				return result, err
+				return callWithTimeout(timeout, *defaultMethodConstructorName, path, func() (any, error) {
+					out := method.Call(args)
+					result := out[0].Interface() // We have just checked that it MUST be convertible to `any`.
+					var err error
+					err, ok := out[1].Interface().(error) // We have just checked that it MUST be convertible to `error`.
+					if !ok {
+						// Conversion failure? This means that `out[1]` is `nil`.
+						return result, nil
+					}
+					return result, err
+				})
 			}
 			defaultMethodConstructor = &methodConstructor
 		} else {
@@ -1412,11 +4573,31 @@ func canInterface(typ reflect.Type, interfaceType reflect.Type) (bool, error) {
 	return false, nil
 }
 
+// Determine whether a type implements `validation.Validator`, `validation.ContextValidator`
+// or `validation.PathAwareValidator`.
+func implementsValidator(typ reflect.Type) (bool, error) {
+	canValidate, err := canInterface(typ, validatorInterface)
+	if err != nil {
+		return false, err
+	}
+	canValidateContext, err := canInterface(typ, contextValidatorInterface)
+	if err != nil {
+		return false, err
+	}
+	canValidateAt, err := canInterface(typ, pathAwareValidatorInterface)
+	if err != nil {
+		return false, err
+	}
+	return canValidate || canValidateContext || canValidateAt, nil
+}
+
 // Some metadata on initialization for a type.
 type initializationMetadata struct {
 	canInitializeSelf    bool
 	canDriverUnmarshal   bool
 	canUnmarshalFromDict bool
+	canSetValues         bool
+	canComputeDefaults   bool
 	willPreinitialize    bool
 }
 
@@ -1427,6 +4608,11 @@ func initializationData(path string, typ reflect.Type, options innerOptions) (in
 	if err != nil {
 		return initializationMetadata{}, err
 	}
+	canInitializeSelfContext, err := canInterface(typ, contextInitializerInterface)
+	if err != nil {
+		return initializationMetadata{}, err
+	}
+	canInitializeSelf = canInitializeSelf || canInitializeSelfContext
 
 	canDriverUnmarshal := options.unmarshaler.ShouldUnmarshal(typ)
 	canUnmarshalFromDict, err := canInterface(typ, unmarshalDictInterface)
@@ -1444,7 +4630,17 @@ func initializationData(path string, typ reflect.Type, options innerOptions) (in
 	willPreinitialize := canInitializeSelf || canDriverUnmarshal || canUnmarshalFromDict
 
 	// Early check that we're not mis-using `Validator`.
-	_, err = canInterface(typ, validatorInterface)
+	_, err = implementsValidator(typ)
+	if err != nil {
+		return initializationMetadata{}, err
+	}
+
+	canSetValues, err := canInterface(typ, valuesInterface)
+	if err != nil {
+		return initializationMetadata{}, err
+	}
+
+	canComputeDefaults, err := canInterface(typ, defaulterInterface)
 	if err != nil {
 		return initializationMetadata{}, err
 	}
@@ -1454,5 +4650,7 @@ func initializationData(path string, typ reflect.Type, options innerOptions) (in
 		canDriverUnmarshal:   canDriverUnmarshal,
 		willPreinitialize:    willPreinitialize,
 		canUnmarshalFromDict: canUnmarshalFromDict,
+		canSetValues:         canSetValues,
+		canComputeDefaults:   canComputeDefaults,
 	}, nil
 }
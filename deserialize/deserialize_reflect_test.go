@@ -8,6 +8,7 @@ import (
 
 	"github.com/pasqal-io/godasse/deserialize"
 	jsonPkg "github.com/pasqal-io/godasse/deserialize/json"
+	"github.com/pasqal-io/godasse/deserialize/shared"
 	"gotest.tools/v3/assert"
 )
 
@@ -206,3 +207,112 @@ func TestReflectKVDeserializerUnderlyingPrimitiveSlices(t *testing.T) {
 	assert.NilError(t, err)
 	assert.DeepEqual(t, *deserialized, sample)
 }
+
+func TestReflectMapDeserializerList(t *testing.T) {
+	type Test struct {
+		String string
+		Int    int
+	}
+	typ := reflect.TypeOf(Test{}) //nolint:exhaustruct
+	deserializer, err := deserialize.MakeMapDeserializerFromReflect(deserialize.JSONOptions(""), typ)
+	assert.NilError(t, err)
+
+	list := []shared.Value{}
+	unmarshaled := []any{}
+	err = json.Unmarshal([]byte(`[{"String": "abc", "Int": 123}, {"String": "def", "Int": 456}]`), &unmarshaled)
+	assert.NilError(t, err)
+	for _, entry := range unmarshaled {
+		list = append(list, jsonPkg.Driver().WrapValue(entry))
+	}
+
+	// Start with a non-empty slice, we should append to it.
+	deserialized := []Test{{String: "before", Int: 0}}
+	reflectDeserialized := reflect.ValueOf(&deserialized).Elem()
+	err = deserializer.DeserializeListTo(list, &reflectDeserialized)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, deserialized, []Test{
+		{String: "before", Int: 0},
+		{String: "abc", Int: 123},
+		{String: "def", Int: 456},
+	})
+
+	// Wrong type of slice.
+	wrongSlice := []string{}
+	reflectWrongSlice := reflect.ValueOf(&wrongSlice).Elem()
+	err = deserializer.DeserializeListTo(list, &reflectWrongSlice)
+	assert.ErrorContains(t, err, "cannot deserialize a list of Test")
+}
+
+func TestReflectMapDeserializerListValidation(t *testing.T) {
+	typ := reflect.TypeOf(ValidatedStruct{}) //nolint:exhaustruct
+	deserializer, err := deserialize.MakeMapDeserializerFromReflect(deserialize.JSONOptions(""), typ)
+	assert.NilError(t, err)
+
+	list := []shared.Value{
+		jsonPkg.Driver().WrapValue(map[string]any{"SomeEmail": "someone@example.com"}),
+		jsonPkg.Driver().WrapValue(map[string]any{"SomeEmail": "someone+example.com"}),
+	}
+	deserialized := []ValidatedStruct{}
+	reflectDeserialized := reflect.ValueOf(&deserialized).Elem()
+	err = deserializer.DeserializeListTo(list, &reflectDeserialized)
+	assert.ErrorContains(t, err, "entry 1")
+	assert.ErrorContains(t, err, "Invalid email")
+	assert.Equal(t, len(deserialized), 0, "On error, the slice should not have been modified")
+}
+
+// A custom generic container, deserializing its elements one by one.
+type Ring[T any] struct {
+	entries []T
+}
+
+func (r *Ring[T]) UnmarshalJSON(buf []byte) error {
+	unmarshaled := []any{}
+	if err := json.Unmarshal(buf, &unmarshaled); err != nil {
+		return err //nolint:wrapcheck
+	}
+	var placeholder T
+	deserializer, err := deserialize.MakeValueDeserializer(deserialize.JSONOptions("Ring"), reflect.TypeOf(placeholder))
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	r.entries = make([]T, len(unmarshaled))
+	for i, entry := range unmarshaled {
+		out := reflect.ValueOf(&r.entries[i]).Elem()
+		if err := deserializer(&out, jsonPkg.Driver().WrapValue(entry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestValueDeserializer(t *testing.T) {
+	ring := Ring[ValidatedStruct]{}
+	err := json.Unmarshal([]byte(`[{"SomeEmail": "someone@example.com"}, {"SomeEmail": "someone.else@example.com"}]`), &ring)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, ring.entries, []ValidatedStruct{{SomeEmail: "someone@example.com"}, {SomeEmail: "someone.else@example.com"}})
+
+	// Validation is performed.
+	err = json.Unmarshal([]byte(`[{"SomeEmail": "someone+example.com"}]`), &ring)
+	assert.ErrorContains(t, err, "Invalid email")
+
+	// Flat values are supported, too.
+	deserializer, err := deserialize.MakeValueDeserializer(deserialize.JSONOptions("Value"), reflect.TypeOf(uint8(0)))
+	assert.NilError(t, err)
+	var found uint8
+	out := reflect.ValueOf(&found).Elem()
+	err = deserializer(&out, jsonPkg.Driver().WrapValue(12.0))
+	assert.NilError(t, err)
+	assert.Equal(t, found, uint8(12))
+
+	err = deserializer(&out, jsonPkg.Driver().WrapValue(300.0))
+	assert.ErrorContains(t, err, "out of range")
+
+	err = deserializer(&out, nil)
+	assert.ErrorContains(t, err, "missing value at Value")
+
+	// Wrong type of destination.
+	var wrong string
+	wrongOut := reflect.ValueOf(&wrong).Elem()
+	err = deserializer(&wrongOut, jsonPkg.Driver().WrapValue(12.0))
+	assert.ErrorContains(t, err, "cannot deserialize a uint8 into a string")
+}
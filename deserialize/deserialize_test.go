@@ -2,22 +2,38 @@
 package deserialize_test
 
 import (
+	"context"
+	"crypto/sha256"
 	"encoding"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/fxamacker/cbor/v2"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/google/uuid"
 	"github.com/pasqal-io/godasse/deserialize"
 	jsonPkg "github.com/pasqal-io/godasse/deserialize/json"
 	"github.com/pasqal-io/godasse/deserialize/kvlist"
+	"github.com/pasqal-io/godasse/deserialize/pbstruct"
 	"github.com/pasqal-io/godasse/deserialize/shared"
 	"github.com/pasqal-io/godasse/validation"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	"golang.org/x/text/language"
+	"google.golang.org/protobuf/types/known/structpb"
 	"gotest.tools/v3/assert"
 )
 
@@ -720,7 +736,15 @@ type SimpleStructWithOrMethodBadArgs struct {
 }
 
 func (SimpleStructWithOrMethodBadArgs) BadArgs(string) (string, error) {
-	// This method should not take any arguments.
+	// This method should not take any arguments, other than the struct.
+	return "", nil
+}
+
+type SimpleStructWithOrMethodTooManyArgs struct {
+	SomeString string `orMethod:"TooManyArgs"`
+}
+
+func (SimpleStructWithOrMethodTooManyArgs) TooManyArgs(*SimpleStructWithOrMethodTooManyArgs, string) (string, error) {
 	return "", nil
 }
 
@@ -757,7 +781,10 @@ func TestOrMethodBadSetup(t *testing.T) {
 	assert.Equal(t, err.Error(), "could not generate a deserializer for SimpleStructWithOrMethodBadName.SomeString with type string:\n\t * at SimpleStructWithOrMethodBadName.SomeString, failed to setup `orMethod`\n\t * method IDoNotExist provided with `orMethod` doesn't seem to exist - note that the method must be public", "We should fail early if the orMethod doesn't exist")
 
 	_, err = deserialize.MakeMapDeserializer[SimpleStructWithOrMethodBadArgs](deserialize.JSONOptions(""))
-	assert.Equal(t, err.Error(), "could not generate a deserializer for SimpleStructWithOrMethodBadArgs.SomeString with type string:\n\t * at SimpleStructWithOrMethodBadArgs.SomeString, failed to setup `orMethod`\n\t * the method provided with `orMethod` MUST take no argument but takes 1 arguments", "We should fail early if orMethod args are incorrect")
+	assert.Equal(t, err.Error(), "could not generate a deserializer for SimpleStructWithOrMethodBadArgs.SomeString with type string:\n\t * at SimpleStructWithOrMethodBadArgs.SomeString, failed to setup `orMethod`\n\t * the method provided with `orMethod` MUST take no argument or a single argument *deserialize_test.SimpleStructWithOrMethodBadArgs but takes an argument string", "We should fail early if orMethod args are incorrect")
+
+	_, err = deserialize.MakeMapDeserializer[SimpleStructWithOrMethodTooManyArgs](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "the method provided with `orMethod` MUST take no argument or a single argument *deserialize_test.SimpleStructWithOrMethodTooManyArgs but takes 2 arguments", "We should fail early if orMethod args are incorrect")
 
 	_, err = deserialize.MakeMapDeserializer[SimpleStructWithOrMethodBadOut1](deserialize.JSONOptions(""))
 	assert.Equal(t, err.Error(), "could not generate a deserializer for SimpleStructWithOrMethodBadOut1.SomeInt with type int:\n\t * at SimpleStructWithOrMethodBadOut1.SomeInt, failed to setup `orMethod`\n\t * the method provided with `orMethod` MUST return (int, error) but it returns (string, _) which is not convertible to `int`", "We should fail early if first result is incorrect")
@@ -1077,12 +1104,53 @@ func TestMap(t *testing.T) {
 }
 
 func TestBadMapMap(t *testing.T) {
+	type BadKey struct {
+		A int
+	}
 	type BadMap struct {
-		Field map[int]string
+		Field map[BadKey]string
 	}
 
 	_, err := deserialize.MakeMapDeserializer[BadMap](deserialize.JSONOptions(""))
-	assert.ErrorContains(t, err, "invalid map type at BadMap.Field, only map[string]T can be converted into a deserializer", "We should have detected that we cannot convert maps with such keys")
+	assert.ErrorContains(t, err, "invalid map type at BadMap.Field, keys must be parseable from a string or implement TextUnmarshaler", "We should have detected that we cannot convert maps with such keys")
+}
+
+// ------ Test that we can deserialize maps with non-string keys.
+
+type MapKeyEnum string
+
+type StructWithNonStringKeys struct {
+	UUIDs  map[uuid.UUID]int  `json:"uuids"`
+	Ints   map[int]string     `json:"ints"`
+	Uints  map[uint8]bool     `json:"uints"`
+	Named  map[MapKeyEnum]int `json:"named"`
+	Floats map[float64]string `json:"floats"`
+}
+
+func TestMapNonStringKeys(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithNonStringKeys](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	id := uuid.New()
+	found, err := deserializer.DeserializeString(fmt.Sprintf(`{"uuids": {"%s": 1}, "ints": {"-1": "minus one", "2": "two"}, "uints": {"255": true}, "named": {"abc": 3}, "floats": {"1.5": "one and a half"}}`, id))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNonStringKeys{
+		UUIDs:  map[uuid.UUID]int{id: 1},
+		Ints:   map[int]string{-1: "minus one", 2: "two"},
+		Uints:  map[uint8]bool{255: true},
+		Named:  map[MapKeyEnum]int{"abc": 3},
+		Floats: map[float64]string{1.5: "one and a half"},
+	})
+
+	// Keys that fail to parse are reported.
+	_, err = deserializer.DeserializeString(`{"uuids": {"not a uuid": 1}, "ints": {}, "uints": {}, "named": {}, "floats": {}}`)
+	assert.ErrorContains(t, err, `invalid key "not a uuid" at StructWithNonStringKeys.uuids, expected UUID`)
+
+	_, err = deserializer.DeserializeString(`{"uuids": {}, "ints": {"abc": "def"}, "uints": {}, "named": {}, "floats": {}}`)
+	assert.ErrorContains(t, err, `invalid key "abc" at StructWithNonStringKeys.ints, expected int`)
+
+	_, err = deserializer.DeserializeString(`{"uuids": {}, "ints": {}, "uints": {"256": true}, "named": {}, "floats": {}}`)
+	assert.ErrorContains(t, err, `invalid key "256" at StructWithNonStringKeys.uints, expected uint8`)
 }
 func TestInvalidDefaultValues(t *testing.T) {
 	type RandomStruct struct {
@@ -1263,6 +1331,58 @@ func TestDeserializeUUIDJSON(t *testing.T) {
 
 // ------
 
+// ------ Test that `default` works with TextUnmarshaler.
+
+type TextUnmarshalerTenant struct {
+	name string
+}
+
+func (t *TextUnmarshalerTenant) UnmarshalText(source []byte) error {
+	if len(source) == 0 {
+		return errors.New("empty tenant")
+	}
+	t.name = strings.ToLower(string(source))
+	return nil
+}
+
+type StructWithTextUnmarshalerDefault struct {
+	Owner  TextUnmarshalerUUID   `json:"owner" query:"owner" default:"00000000-0000-0000-0000-000000000001"`
+	Tenant TextUnmarshalerTenant `json:"tenant" query:"tenant" default:"Main"`
+}
+
+func TestTextUnmarshalerDefault(t *testing.T) {
+	owner := TextUnmarshalerUUID(uuid.MustParse("00000000-0000-0000-0000-000000000001"))
+	other := TextUnmarshalerUUID(uuid.New())
+
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithTextUnmarshalerDefault](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Owner, owner)
+	assert.Equal(t, found.Tenant.name, "main")
+
+	found, err = deserializer.DeserializeString(fmt.Sprintf(`{"owner": "%s", "tenant": "Other"}`, uuid.UUID(other)))
+	assert.NilError(t, err)
+	assert.Equal(t, found.Owner, other)
+	assert.Equal(t, found.Tenant.name, "other")
+
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithTextUnmarshalerDefault](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{})
+	assert.NilError(t, err)
+	assert.Equal(t, kvFound.Owner, owner)
+	assert.Equal(t, kvFound.Tenant.name, "main")
+
+	// Invalid defaults are rejected when setting up the deserializer.
+	type InvalidDefault struct {
+		Owner TextUnmarshalerUUID `json:"owner" default:"not-a-uuid"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "cannot parse default value at InvalidDefault.owner")
+}
+
+// ------
+
 // ------ Test that we can deserialize uuid through kvlist.
 
 func TestDeserializeUUIDKV(t *testing.T) {
@@ -1428,6 +1548,30 @@ func TestKVCannotDeserializeChan(t *testing.T) {
 	assert.ErrorContains(t, err, "chan int")
 }
 
+// ------ Test that we can deserialize path parameters from a map of strings
+
+type PathParams struct {
+	OrgID  uint64    `path:"orgID"`
+	UserID uuid.UUID `path:"userID"`
+	Tab    string    `path:"tab" default:"profile"`
+}
+
+func TestDeserializePathParams(t *testing.T) {
+	deserializer, err := deserialize.MakeKVListDeserializer[PathParams](deserialize.PathOptions(""))
+	assert.NilError(t, err)
+
+	userID := uuid.New()
+	found, err := deserializer.DeserializePathParams(map[string]string{"orgID": "42", "userID": userID.String()})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, PathParams{OrgID: 42, UserID: userID, Tab: "profile"})
+
+	_, err = deserializer.DeserializePathParams(map[string]string{"orgID": "forty-two", "userID": userID.String()})
+	assert.ErrorContains(t, err, "PathParams.orgID")
+
+	_, err = deserializer.DeserializePathParams(map[string]string{"userID": userID.String()})
+	assert.ErrorContains(t, err, "missing value at PathParams.orgID")
+}
+
 // ------ Test that KVList calls validation
 
 type CustomStructWithValidation struct {
@@ -1494,7 +1638,10 @@ func TestMapDeserializerFlattened(t *testing.T) {
 		Regular Inner
 	}
 
-	deserializer, err := deserialize.MakeMapDeserializer[Outer](deserialize.JSONOptions(""))
+	// `Flattened` and `Inner` both read keys `Left` and `Right`.
+	options := deserialize.JSONOptions("")
+	options.AllowFlattenCollisions = true
+	deserializer, err := deserialize.MakeMapDeserializer[Outer](options)
 	assert.NilError(t, err)
 
 	data := `
@@ -1536,7 +1683,10 @@ func TestKVDeserializerFlattened(t *testing.T) {
 		Inner
 	}
 
-	deserializer, err := deserialize.MakeKVListDeserializer[Outer](deserialize.QueryOptions(""))
+	// `Flattened` and `Inner` both read keys `Left` and `Right`.
+	options := deserialize.QueryOptions("")
+	options.AllowFlattenCollisions = true
+	deserializer, err := deserialize.MakeKVListDeserializer[Outer](options)
 	assert.NilError(t, err)
 
 	data := make(map[string][]string)
@@ -1580,3 +1730,5313 @@ func TestKVDeserializeUnderlyingPrimitiveSlices(t *testing.T) {
 	assert.NilError(t, err)
 	assert.DeepEqual(t, *deserialized, sample)
 }
+
+// ------ Test that we can inject values into Initialize/Validate.
+
+type StructWithValues struct {
+	Locale  string `json:"locale"`
+	tenant  string
+	locales string
+}
+
+func (s *StructWithValues) SetValues(values map[string]any) {
+	if tenant, ok := values["tenant"].(string); ok {
+		s.tenant = tenant
+	}
+	if locales, ok := values["locales"].(string); ok {
+		s.locales = locales
+	}
+	// This should not affect the caller.
+	values["tenant"] = "mutated"
+}
+
+func (s *StructWithValues) Initialize() error {
+	// Values have been injected before initialization.
+	s.tenant = strings.ToUpper(s.tenant)
+	return nil
+}
+
+func (s *StructWithValues) Validate() error {
+	if s.locales == "" {
+		return nil
+	}
+	for _, locale := range strings.Split(s.locales, ",") {
+		if locale == s.Locale {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported locale %s", s.Locale)
+}
+
+var _ validation.Values = &StructWithValues{}
+
+func TestDeserializeWithValues(t *testing.T) {
+	type Outer struct {
+		Inner StructWithValues `json:"inner"`
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[Outer](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	dict := jsonPkg.JSON{
+		"inner": map[string]any{
+			"locale": "fr",
+		},
+	}
+	values := map[string]any{
+		"tenant":  "pasqal",
+		"locales": "en,fr",
+	}
+
+	// Without values, nothing is injected.
+	found, err := deserializer.DeserializeDict(dict)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Inner.tenant, "")
+
+	// With values, they're visible to both Initialize and Validate.
+	found, err = deserializer.DeserializeDictWithValues(dict, values)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Inner.tenant, "PASQAL")
+	assert.Equal(t, found.Inner.Locale, "fr")
+
+	// The bag received by SetValues is a copy.
+	assert.Equal(t, values["tenant"], "pasqal")
+
+	values["locales"] = "en"
+	_, err = deserializer.DeserializeDictWithValues(dict, values)
+	assert.ErrorContains(t, err, "unsupported locale fr")
+}
+
+// ------ Test that we can reject unknown fields.
+
+func TestDisallowUnknownFields(t *testing.T) {
+	type Inner struct {
+		Left  string
+		Right string
+	}
+	type Embedded struct {
+		Middle string
+	}
+	type Outer struct {
+		Flattened Inner `flatten:""`
+		Embedded
+		Regular  Inner `json:"regular"`
+		Custom   StructSupportBothUnmarshalerAndDictUnmarshaler
+		Ignored  string `json:"-" initialized:""`
+		internal string `initialized:""`
+	}
+	options := deserialize.JSONOptions("")
+	options.DisallowUnknownFields = true
+	deserializer, err := deserialize.MakeMapDeserializer[Outer](options)
+	assert.NilError(t, err)
+
+	// All keys are known.
+	data := `
+	{
+		"Left": "flattened_left",
+		"Right": "flattened_right",
+		"Middle": "embedded_middle",
+		"regular": {
+			"Left": "regular_left",
+			"Right": "regular_right"
+		},
+		"Custom": {
+			"Field": "custom",
+			"NotAField": "but Custom handles unmarshaling itself"
+		}
+	}`
+	found, err := deserializer.DeserializeString(data)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Middle, "embedded_middle")
+	assert.Equal(t, found.Custom.Field, "test has succeeded with custom")
+
+	// Unknown key in the outer struct.
+	data = `
+	{
+		"Left": "flattened_left",
+		"Right": "flattened_right",
+		"Middle": "embedded_middle",
+		"regular": {
+			"Left": "regular_left",
+			"Right": "regular_right"
+		},
+		"Custom": {
+			"Field": "custom"
+		},
+		"Regular": {}
+	}`
+	_, err = deserializer.DeserializeString(data)
+	assert.ErrorContains(t, err, "unexpected field Regular at Outer")
+
+	// Renamed-to-private and private fields are unknown.
+	data = `
+	{
+		"Left": "flattened_left",
+		"Right": "flattened_right",
+		"Middle": "embedded_middle",
+		"regular": {
+			"Left": "regular_left",
+			"Right": "regular_right"
+		},
+		"Custom": {
+			"Field": "custom"
+		},
+		"Ignored": ""
+	}`
+	_, err = deserializer.DeserializeString(data)
+	assert.ErrorContains(t, err, "unexpected field Ignored at Outer")
+
+	// Unknown key in a nested struct.
+	data = `
+	{
+		"Left": "flattened_left",
+		"Right": "flattened_right",
+		"Middle": "embedded_middle",
+		"regular": {
+			"Left": "regular_left",
+			"Right": "regular_right",
+			"Center": "regular_center"
+		},
+		"Custom": {
+			"Field": "custom"
+		}
+	}`
+	_, err = deserializer.DeserializeString(data)
+	assert.ErrorContains(t, err, "unexpected field Center at Outer.regular")
+
+	// By default, unknown keys are ignored.
+	deserializer, err = deserialize.MakeMapDeserializer[Outer](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeString(data)
+	assert.NilError(t, err)
+}
+
+func TestDisallowUnknownFieldsKVList(t *testing.T) {
+	options := deserialize.QueryOptions("")
+	options.DisallowUnknownFields = true
+	deserializer, err := deserialize.MakeKVListDeserializer[QueryWithNested](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeKVList(kvlist.KVList{"name": []string{"a"}, "tag": []string{"x", "y"}, "location.address.city": []string{"Paris"}})
+	assert.NilError(t, err)
+	assert.Equal(t, found.Location.Address.City, "Paris")
+
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"name": []string{"a"}, "nmae": []string{"b"}, "location.address.city": []string{"Paris"}})
+	assert.ErrorContains(t, err, "unexpected field nmae at QueryWithNested")
+
+	// Dotted keys are checked against the nested struct.
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"name": []string{"a"}, "location.address.cty": []string{"Paris"}})
+	assert.ErrorContains(t, err, "unexpected field cty at QueryWithNested.location.address")
+
+	// By default, unknown keys are ignored.
+	deserializer, err = deserialize.MakeKVListDeserializer[QueryWithNested](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"name": []string{"a"}, "nmae": []string{"b"}, "location.address.city": []string{"Paris"}})
+	assert.NilError(t, err)
+
+	// For env deserializers, only variables with the prefix are checked.
+	type Config struct {
+		Port int `env:"PORT"`
+	}
+	envOptions := deserialize.EnvOptions("MYAPP_")
+	envOptions.DisallowUnknownFields = true
+	envDeserializer, err := deserialize.MakeEnvDeserializer[Config](envOptions)
+	assert.NilError(t, err)
+	config, err := envDeserializer.DeserializeEnviron([]string{"MYAPP_PORT=80", "HOME=/root"})
+	assert.NilError(t, err)
+	assert.Equal(t, config.Port, 80)
+	_, err = envDeserializer.DeserializeEnviron([]string{"MYAPP_PORT=80", "MYAPP_PROT=8080"})
+	assert.ErrorContains(t, err, "unexpected field PROT at Config")
+
+	envOptions.EnvPrefix = ""
+	_, err = deserialize.MakeEnvDeserializer[Config](envOptions)
+	assert.ErrorContains(t, err, "option DisallowUnknownFields requires option EnvPrefix for env deserializers")
+}
+
+// ------ Test that we can trim prefixes and suffixes.
+
+func TestTrimPrefixSuffix(t *testing.T) {
+	type Token string
+	type Struct struct {
+		Authorization Token  `json:"authorization" trimPrefix:"Bearer "`
+		Quoted        string `json:"quoted" trimPrefix:"<<" trimSuffix:">>"`
+		Untouched     string `json:"untouched" trimPrefix:"Bearer " default:"Bearer default"`
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[Struct](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"authorization": "Bearer xyz", "quoted": "<<abc>>"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Struct{
+		Authorization: "xyz",
+		Quoted:        "abc",
+		Untouched:     "Bearer default",
+	})
+
+	// Values without the prefix are stored as-is.
+	found, err = deserializer.DeserializeString(`{"authorization": "xyz", "quoted": "abc>>", "untouched": "Bearer 123"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Struct{
+		Authorization: "xyz",
+		Quoted:        "abc",
+		Untouched:     "123",
+	})
+
+	// Same thing with queries.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[Struct](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	kvList := map[string][]string{
+		"Authorization": {"Bearer xyz"},
+		"Quoted":        {"<<abc>>"},
+	}
+	found, err = kvDeserializer.DeserializeKVList(kvList)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Authorization, Token("xyz"))
+	assert.Equal(t, found.Quoted, "abc")
+}
+
+func TestTrimPrefixOnlyStrings(t *testing.T) {
+	type IntStruct struct {
+		Field int `trimPrefix:"0x"`
+	}
+	_, err := deserialize.MakeMapDeserializer[IntStruct](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `trimPrefix` may only be used on strings")
+
+	type SliceStruct struct {
+		Field []string `trimSuffix:";"`
+	}
+	_, err = deserialize.MakeMapDeserializer[SliceStruct](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `trimSuffix` may only be used on strings")
+}
+
+// ------ Test that we can deserialize from a stream.
+
+func TestDeserializeReader(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[Pair[int, SimpleStruct]](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeReader(strings.NewReader(`{"left": 1, "right": {"SomeString": "abc"}}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Pair[int, SimpleStruct]{Left: 1, Right: SimpleStruct{SomeString: "abc"}})
+
+	_, err = deserializer.DeserializeReader(strings.NewReader(`{"left": 1, "right": {"SomeString": "abc"}} {}`))
+	assert.ErrorContains(t, err, "invalid data after top-level value")
+
+	_, err = deserializer.DeserializeReader(strings.NewReader(`{"left": 1,`))
+	assert.ErrorContains(t, err, "failed to deserialize source")
+}
+
+// ------ Test loading files, with a format picked from the extension.
+
+type LoadedConfig struct {
+	Name string   `json:"name" yaml:"name"`
+	Port uint16   `json:"port" yaml:"port"`
+	Tags []string `json:"tags" yaml:"tags"`
+	Mode string   `json:"mode" yaml:"mode" default:"release"`
+}
+
+func TestLoadFile(t *testing.T) {
+	expected := LoadedConfig{
+		Name: "server",
+		Port: 8080,
+		Tags: []string{"blue", "green"},
+		Mode: "release",
+	}
+	fromJSON, err := deserialize.LoadFile[LoadedConfig]("testdata/config.json")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *fromJSON, expected)
+
+	fromYAML, err := deserialize.LoadFile[LoadedConfig]("testdata/config.yaml")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *fromYAML, expected)
+
+	_, err = deserialize.LoadFile[LoadedConfig]("testdata/config.toml")
+	assert.ErrorContains(t, err, "cannot load testdata/config.toml, unsupported file extension \".toml\"")
+
+	_, err = deserialize.LoadFile[LoadedConfig]("testdata/missing.json")
+	assert.ErrorContains(t, err, "cannot load testdata/missing.json")
+	assert.Assert(t, errors.Is(err, os.ErrNotExist))
+
+	invalid := filepath.Join(t.TempDir(), "invalid.yml")
+	assert.NilError(t, os.WriteFile(invalid, []byte("name: server\nport: high\ntags: []\n"), 0o600))
+	_, err = deserialize.LoadFile[LoadedConfig](invalid)
+	assert.ErrorContains(t, err, "invalid value at LoadedConfig.port, expected uint16, got high")
+}
+
+// A driver that does not support streaming.
+type bufferingDriver struct {
+	shared.Driver
+	calls *int
+}
+
+func (d bufferingDriver) Unmarshal(in any, out *any) error {
+	*d.calls++
+	return d.Driver.Unmarshal(in, out) //nolint:wrapcheck
+}
+
+func TestDeserializeReaderFallback(t *testing.T) {
+	calls := 0
+	options := deserialize.JSONOptions("")
+	options.Unmarshaler = func() shared.Driver {
+		return bufferingDriver{
+			Driver: jsonPkg.Driver(),
+			calls:  &calls,
+		}
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[SimpleStruct](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeReader(strings.NewReader(`{"SomeString": "abc"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, found.SomeString, "abc")
+	assert.Equal(t, calls, 1, "We should have fallen back to Unmarshal")
+}
+
+// ------ Test that we can deserialize lists with partial success.
+
+func TestDeserializeListResults(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[ValidatedStruct](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	list := []shared.Value{
+		jsonPkg.Driver().WrapValue(map[string]any{"SomeEmail": "someone@example.com"}),
+		jsonPkg.Driver().WrapValue(map[string]any{"SomeEmail": "someone+example.com"}),
+		jsonPkg.Driver().WrapValue(map[string]any{}),
+		jsonPkg.Driver().WrapValue("not an object"),
+		jsonPkg.Driver().WrapValue(map[string]any{"SomeEmail": "someone.else@example.com"}),
+	}
+	results := deserializer.DeserializeListResults(list)
+	assert.Equal(t, len(results), len(list))
+	for i, result := range results {
+		assert.Equal(t, result.Index, i)
+	}
+
+	assert.NilError(t, results[0].Err)
+	assert.Equal(t, results[0].Value.SomeEmail, "someone@example.com")
+
+	assert.Assert(t, results[1].Value == nil)
+	assert.ErrorContains(t, results[1].Err, "entry 1")
+	assert.ErrorContains(t, results[1].Err, "Invalid email")
+	assert.Assert(t, errors.As(results[1].Err, &validation.Error{}))
+
+	assert.Assert(t, results[2].Value == nil)
+	assert.ErrorContains(t, results[2].Err, "missing value at ValidatedStruct.SomeEmail")
+
+	assert.Assert(t, results[3].Value == nil)
+	assert.ErrorContains(t, results[3].Err, "expected an object")
+	assert.Assert(t, errors.As(results[3].Err, &deserialize.TypeMismatchError{}))
+
+	// We haven't stopped at the first error.
+	assert.NilError(t, results[4].Err)
+	assert.Equal(t, results[4].Value.SomeEmail, "someone.else@example.com")
+}
+
+// ------ Test that types may deserialize themselves from any value.
+
+// A type that accepts both `"x"` and `{"name": "x"}`.
+type PolymorphicName struct {
+	Name string
+}
+
+func (p *PolymorphicName) UnmarshalValue(value shared.Value) error {
+	if value == nil {
+		return errors.New("missing name")
+	}
+	if dict, ok := value.AsDict(); ok {
+		if name, ok := dict.Lookup("name"); ok {
+			value = name
+		}
+	}
+	name, ok := value.Interface().(string)
+	if !ok {
+		return fmt.Errorf("expected a string or an object with a string `name`, got %v", value.Interface())
+	}
+	p.Name = name
+	return nil
+}
+
+func (p *PolymorphicName) Validate() error {
+	if p.Name == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+var _ shared.UnmarshalValue = &PolymorphicName{}
+
+func TestUnmarshalValue(t *testing.T) {
+	type Struct struct {
+		First  PolymorphicName  `json:"first"`
+		Second PolymorphicName  `json:"second"`
+		Third  *PolymorphicName `json:"third"`
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[Struct](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"first": "abc", "second": {"name": "def"}, "third": "ghi"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Struct{
+		First:  PolymorphicName{Name: "abc"},
+		Second: PolymorphicName{Name: "def"},
+		Third:  &PolymorphicName{Name: "ghi"},
+	})
+
+	_, err = deserializer.DeserializeString(`{"first": "abc", "second": 123, "third": "ghi"}`)
+	assert.ErrorContains(t, err, "at Struct.second, expected to be able to parse a PolymorphicName")
+
+	_, err = deserializer.DeserializeString(`{"first": "abc", "third": "ghi"}`)
+	assert.ErrorContains(t, err, "missing name")
+
+	_, err = deserializer.DeserializeString(`{"first": "", "second": "def", "third": "ghi"}`)
+	assert.ErrorContains(t, err, "empty name")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+}
+
+// ------ Test that we can fall back to other tags for renamings.
+
+func TestFallbackTagNames(t *testing.T) {
+	type Struct struct {
+		JSONOnly  string `json:"jsonOnly"`
+		Both      string `query:"query_both" json:"jsonBoth"`
+		Neither   string
+		QueryOnly string `query:"query_only"`
+	}
+	options := deserialize.QueryOptions("")
+	options.FallbackTagNames = []string{"json"}
+	deserializer, err := deserialize.MakeKVListDeserializer[Struct](options)
+	assert.NilError(t, err)
+
+	kvList := map[string][]string{
+		"jsonOnly":   {"a"},
+		"query_both": {"b"},
+		"Neither":    {"c"},
+		"query_only": {"d"},
+	}
+	found, err := deserializer.DeserializeKVList(kvList)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Struct{
+		JSONOnly:  "a",
+		Both:      "b",
+		Neither:   "c",
+		QueryOnly: "d",
+	})
+
+	// Same thing with the reflect-based deserializer.
+	reflectDeserializer, err := deserialize.MakeKVDeserializerFromReflect(options, reflect.TypeOf(Struct{}))
+	assert.NilError(t, err)
+	reflectFound := new(Struct)
+	reflectOut := reflect.ValueOf(reflectFound).Elem()
+	err = reflectDeserializer.DeserializeKVListTo(kvList, &reflectOut)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *reflectFound, *found)
+
+	// Without fallback, `jsonOnly` is not recognized.
+	deserializer, err = deserialize.MakeKVListDeserializer[Struct](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeKVList(kvList)
+	assert.ErrorContains(t, err, "missing value at Struct.JSONOnly")
+}
+
+// ------ Test that `orMethod` is only called when a value is missing.
+
+var orMethodCalls = map[string]int{}
+
+type StructWithLazyOrMethods struct {
+	Flat   string            `json:"flat" orMethod:"MakeFlat"`
+	Struct SimpleStruct      `json:"struct" orMethod:"MakeStruct"`
+	Slice  []string          `json:"slice" orMethod:"MakeSlice"`
+	Ptr    *SimpleStruct     `json:"ptr" orMethod:"MakePtr"`
+	Map    map[string]string `json:"map" orMethod:"MakeMap"`
+}
+
+func (StructWithLazyOrMethods) MakeFlat() (string, error) {
+	orMethodCalls["flat"]++
+	return "default", nil
+}
+func (StructWithLazyOrMethods) MakeStruct() (SimpleStruct, error) {
+	orMethodCalls["struct"]++
+	return SimpleStruct{SomeString: "default"}, nil
+}
+func (StructWithLazyOrMethods) MakeSlice() ([]string, error) {
+	orMethodCalls["slice"]++
+	return []string{"default"}, nil
+}
+func (StructWithLazyOrMethods) MakePtr() (*SimpleStruct, error) {
+	orMethodCalls["ptr"]++
+	return &SimpleStruct{SomeString: "default"}, nil
+}
+func (StructWithLazyOrMethods) MakeMap() (map[string]string, error) {
+	orMethodCalls["map"]++
+	return map[string]string{"default": "default"}, nil
+}
+
+func TestOrMethodNotCalledWhenValueIsPresent(t *testing.T) {
+	clear(orMethodCalls)
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithLazyOrMethods](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{
+		"flat": "value",
+		"struct": {"SomeString": "value"},
+		"slice": ["value"],
+		"ptr": {"SomeString": "value"},
+		"map": {"key": "value"}
+	}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithLazyOrMethods{
+		Flat:   "value",
+		Struct: SimpleStruct{SomeString: "value"},
+		Slice:  []string{"value"},
+		Ptr:    &SimpleStruct{SomeString: "value"},
+		Map:    map[string]string{"key": "value"},
+	})
+	assert.DeepEqual(t, orMethodCalls, map[string]int{})
+
+	// Now, without values.
+	found, err = deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithLazyOrMethods{
+		Flat:   "default",
+		Struct: SimpleStruct{SomeString: "default"},
+		Slice:  []string{"default"},
+		Ptr:    &SimpleStruct{SomeString: "default"},
+		Map:    map[string]string{"default": "default"},
+	})
+	assert.DeepEqual(t, orMethodCalls, map[string]int{
+		"flat":   1,
+		"struct": 1,
+		"slice":  1,
+		"ptr":    1,
+		"map":    1,
+	})
+}
+
+// ------ Test slices of pointers.
+
+func TestSliceOfPointers(t *testing.T) {
+	type Struct struct {
+		Pointers []*string `json:"pointers"`
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[Struct](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// `null` entries are kept in place as `nil`, they are not dropped.
+	found, err := deserializer.DeserializeString(`{"pointers": ["abc", null, "def"]}`)
+	assert.NilError(t, err)
+	assert.Equal(t, len(found.Pointers), 3)
+	assert.Equal(t, *found.Pointers[0], "abc")
+	assert.Assert(t, found.Pointers[1] == nil)
+	assert.Equal(t, *found.Pointers[2], "def")
+}
+
+func TestSliceOfStructsWithNilDefaultPointers(t *testing.T) {
+	type Element struct {
+		Name    string  `json:"name"`
+		Pointer *string `json:"pointer" default:"nil"`
+	}
+	type Struct struct {
+		Elements []Element `json:"elements"`
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[Struct](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"elements": [{"name": "first"}, {"name": "second", "pointer": "abc"}, {"name": "third"}]}`)
+	assert.NilError(t, err)
+	assert.Equal(t, len(found.Elements), 3)
+	assert.Equal(t, found.Elements[0].Name, "first")
+	assert.Assert(t, found.Elements[0].Pointer == nil)
+	assert.Equal(t, found.Elements[1].Name, "second")
+	assert.Equal(t, *found.Elements[1].Pointer, "abc")
+	assert.Equal(t, found.Elements[2].Name, "third")
+	assert.Assert(t, found.Elements[2].Pointer == nil)
+}
+
+// ------ Test that we can unwrap an envelope.
+
+func TestEnvelope(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.Envelope = "data"
+	deserializer, err := deserialize.MakeMapDeserializer[Pair[int, string]](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"data": {"left": 1, "right": "abc"}, "meta": {"page": 2}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Pair[int, string]{Left: 1, Right: "abc"})
+
+	// Envelope is missing.
+	_, err = deserializer.DeserializeString(`{"left": 1, "right": "abc"}`)
+	assert.ErrorContains(t, err, "missing envelope at Pair[int,string].data, expected an object")
+	missingErr := deserialize.MissingFieldError{}
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "Pair[int,string].data")
+
+	// Envelope is not an object.
+	_, err = deserializer.DeserializeString(`{"data": [1, "abc"]}`)
+	assert.ErrorContains(t, err, "invalid value at Pair[int,string].data, expected an object, got slice")
+	assert.Assert(t, errors.As(err, &deserialize.TypeMismatchError{}))
+
+	// Lists: each entry has its own envelope.
+	list := []shared.Value{
+		jsonPkg.Driver().WrapValue(map[string]any{"data": map[string]any{"left": 1.0, "right": "abc"}}),
+		jsonPkg.Driver().WrapValue(map[string]any{"data": map[string]any{"left": 2.0, "right": "def"}}),
+	}
+	foundList, err := deserializer.DeserializeList(list)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, foundList, []Pair[int, string]{{Left: 1, Right: "abc"}, {Left: 2, Right: "def"}})
+
+	// Same thing with reflection.
+	reflectDeserializer, err := deserialize.MakeMapDeserializerFromReflect(options, reflect.TypeOf(Pair[int, string]{}))
+	assert.NilError(t, err)
+	reflectFound := Pair[int, string]{}
+	reflectOut := reflect.ValueOf(&reflectFound).Elem()
+	err = reflectDeserializer.DeserializeDictTo(jsonPkg.JSON{"data": map[string]any{"left": 1.0, "right": "abc"}}, &reflectOut)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reflectFound, Pair[int, string]{Left: 1, Right: "abc"})
+
+	reflectFoundList := []Pair[int, string]{}
+	reflectOutList := reflect.ValueOf(&reflectFoundList).Elem()
+	err = reflectDeserializer.DeserializeListTo(list, &reflectOutList)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reflectFoundList, foundList)
+
+	// Without envelope, the same struct deserializes from the top-level object.
+	deserializer, err = deserialize.MakeMapDeserializer[Pair[int, string]](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeString(`{"left": 1, "right": "abc"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Pair[int, string]{Left: 1, Right: "abc"})
+
+	// Envelopes make no sense for KVList.
+	options = deserialize.QueryOptions("")
+	options.Envelope = "data"
+	_, err = deserialize.MakeKVListDeserializer[Pair[int, string]](options)
+	assert.ErrorContains(t, err, "option Envelope is not supported")
+}
+
+// ------ Test that `ComputeDefaults()` is called after population and before validation.
+
+type StructWithComputedDefaults struct {
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Full  string `json:"full" default:""`
+	Upper string `json:"-" initialized:""`
+}
+
+func (s *StructWithComputedDefaults) ComputeDefaults() error {
+	if s.Full == "" {
+		s.Full = s.First + " " + s.Last
+	}
+	if s.First == "error" {
+		return errors.New("cannot compute defaults")
+	}
+	return nil
+}
+
+func (s *StructWithComputedDefaults) Validate() error {
+	if s.Full == " " {
+		return errors.New("full name is empty")
+	}
+	// Modifications performed by `Validate()` are kept.
+	s.Upper = strings.ToUpper(s.Full)
+	return nil
+}
+
+var _ validation.Defaulter = &StructWithComputedDefaults{}
+var _ validation.Validator = &StructWithComputedDefaults{}
+
+func TestComputeDefaults(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithComputedDefaults](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"first": "Ada", "last": "Lovelace"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithComputedDefaults{First: "Ada", Last: "Lovelace", Full: "Ada Lovelace", Upper: "ADA LOVELACE"})
+
+	// Explicit values are not overwritten.
+	found, err = deserializer.DeserializeString(`{"first": "Ada", "last": "Lovelace", "full": "Countess of Lovelace"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithComputedDefaults{First: "Ada", Last: "Lovelace", Full: "Countess of Lovelace", Upper: "COUNTESS OF LOVELACE"})
+
+	// Validation sees the computed value.
+	_, err = deserializer.DeserializeString(`{"first": "", "last": ""}`)
+	assert.ErrorContains(t, err, "full name is empty")
+
+	// Errors in `ComputeDefaults()` are reported.
+	_, err = deserializer.DeserializeString(`{"first": "error", "last": "Lovelace"}`)
+	assert.ErrorContains(t, err, "cannot compute defaults")
+	customErr := deserialize.CustomDeserializerError{}
+	assert.Check(t, errors.As(err, &customErr))
+	assert.Equal(t, customErr.Operation, "defaulter")
+
+	// Nested structs also compute their defaults.
+	nested, err := deserialize.MakeMapDeserializer[Pair[int, StructWithComputedDefaults]](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	foundPair, err := nested.DeserializeString(`{"left": 1, "right": {"first": "Ada", "last": "Lovelace"}}`)
+	assert.NilError(t, err)
+	assert.Equal(t, foundPair.Right.Full, "Ada Lovelace")
+	assert.Equal(t, foundPair.Right.Upper, "ADA LOVELACE")
+}
+
+// ------ Test that `Finalize()` is called once, on the top-level struct, after validation.
+
+type FinalizedItem struct {
+	Price int `json:"price"`
+}
+
+func (s *FinalizedItem) Validate() error {
+	if s.Price < 0 {
+		return errors.New("negative price")
+	}
+	return nil
+}
+
+// `Finalize()` is not called on nested structs.
+func (s *FinalizedItem) Finalize() error {
+	return errors.New("nested structs should not be finalized")
+}
+
+type FinalizedOrder struct {
+	Items     []FinalizedItem `json:"items"`
+	Budget    int             `json:"budget"`
+	total     int             `initialized:""`
+	validated bool            `initialized:""`
+}
+
+func (s *FinalizedOrder) Validate() error {
+	s.validated = true
+	return nil
+}
+
+func (s *FinalizedOrder) Finalize() error {
+	if !s.validated {
+		return errors.New("finalized before validation")
+	}
+	for _, item := range s.Items {
+		s.total += item.Price
+	}
+	if s.total > s.Budget {
+		return fmt.Errorf("total %d exceeds budget %d", s.total, s.Budget)
+	}
+	return nil
+}
+
+var _ validation.Finalizer = &FinalizedOrder{}
+
+type FinalizedRange struct {
+	Min int `query:"min"`
+	Max int `query:"max"`
+}
+
+func (s *FinalizedRange) Finalize() error {
+	if s.Min > s.Max {
+		return errors.New("expected min <= max")
+	}
+	return nil
+}
+
+func TestFinalize(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[FinalizedOrder](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"items": [{"price": 3}, {"price": 4}], "budget": 10}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.total, 7)
+	assert.Assert(t, found.validated)
+
+	_, err = deserializer.DeserializeString(`{"items": [{"price": 3}, {"price": 8}], "budget": 10}`)
+	assert.Error(t, err, "validation error at FinalizedOrder:\n\t * total 11 exceeds budget 10")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// Validation errors in children prevent finalization.
+	_, err = deserializer.DeserializeString(`{"items": [{"price": -3}], "budget": 10}`)
+	assert.ErrorContains(t, err, "negative price")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[FinalizedRange](deserialize.QueryOptions("search"))
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"min": []string{"1"}, "max": []string{"2"}})
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"min": []string{"2"}, "max": []string{"1"}})
+	assert.ErrorContains(t, err, "validation error at search.FinalizedRange:\n\t * expected min <= max")
+}
+
+// ------ Test that a struct may declare dynamic fields at runtime.
+
+type DynamicForm struct {
+	Kind   string         `json:"kind"`
+	Values map[string]any `json:"-" initialized:""`
+}
+
+func (f *DynamicForm) Schema() map[string]reflect.Type {
+	switch f.Kind {
+	case "person":
+		return map[string]reflect.Type{
+			"name": reflect.TypeOf(""),
+			"age":  reflect.TypeOf(uint8(0)),
+		}
+	case "conflict":
+		return map[string]reflect.Type{
+			"kind": reflect.TypeOf(""),
+		}
+	default:
+		return nil
+	}
+}
+
+func (f *DynamicForm) SetDynamicFields(values map[string]any) {
+	f.Values = values
+}
+
+func (f *DynamicForm) Validate() error {
+	if f.Kind == "person" && f.Values["name"] == "" {
+		return errors.New("empty name")
+	}
+	return nil
+}
+
+var _ shared.DynamicFields = &DynamicForm{}
+
+func TestDynamicFields(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[DynamicForm](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"kind": "person", "name": "Ada", "age": 36}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Values, map[string]any{"name": "Ada", "age": uint8(36)})
+
+	found, err = deserializer.DeserializeString(`{"kind": "empty"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Values, map[string]any{})
+
+	// Dynamic fields are typed.
+	_, err = deserializer.DeserializeString(`{"kind": "person", "name": "Ada", "age": 360}`)
+	assert.ErrorContains(t, err, "DynamicForm.age")
+	_, err = deserializer.DeserializeString(`{"kind": "person", "name": "Ada", "age": "old"}`)
+	assert.ErrorContains(t, err, "invalid value at DynamicForm.age")
+
+	// Dynamic fields are required.
+	_, err = deserializer.DeserializeString(`{"kind": "person", "name": "Ada"}`)
+	assert.ErrorContains(t, err, "missing value at DynamicForm.age")
+
+	// Dynamic fields are set before validation.
+	_, err = deserializer.DeserializeString(`{"kind": "person", "name": "", "age": 36}`)
+	assert.ErrorContains(t, err, "validation error at DynamicForm:\n\t * empty name")
+
+	// Dynamic fields may not shadow regular fields.
+	_, err = deserializer.DeserializeString(`{"kind": "conflict"}`)
+	assert.ErrorContains(t, err, "at DynamicForm, dynamic field kind conflicts with a field of the same name")
+
+	// Dynamic fields are not unknown fields.
+	options := deserialize.JSONOptions("")
+	options.DisallowUnknownFields = true
+	strict, err := deserialize.MakeMapDeserializer[DynamicForm](options)
+	assert.NilError(t, err)
+	_, err = strict.DeserializeString(`{"kind": "person", "name": "Ada", "age": 36}`)
+	assert.NilError(t, err)
+	_, err = strict.DeserializeString(`{"kind": "empty", "name": "Ada"}`)
+	assert.ErrorContains(t, err, "unexpected field name at DynamicForm")
+
+	// Nested dynamic structs.
+	nested, err := deserialize.MakeMapDeserializer[Pair[int, DynamicForm]](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	foundPair, err := nested.DeserializeString(`{"left": 1, "right": {"kind": "person", "name": "Ada", "age": 36}}`)
+	assert.NilError(t, err)
+	assert.Equal(t, foundPair.Right.Values["age"], uint8(36))
+}
+
+// ------ Test that `OneOf2` attempts each variant in turn.
+
+type OneOfAuthor struct {
+	Name  string `json:"name"`
+	Email string `json:"email" default:""`
+}
+
+type StructWithOneOf struct {
+	Author deserialize.OneOf2[string, OneOfAuthor]  `json:"author"`
+	Editor *deserialize.OneOf2[string, OneOfAuthor] `json:"editor" default:"nil"`
+	Email  deserialize.OneOf2[Email, int]           `json:"email"`
+}
+
+func TestOneOf2(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithOneOf](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// A string is deserialized as the first variant.
+	found, err := deserializer.DeserializeString(`{"author": "Ada", "email": 3}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Author.Which, 1)
+	assert.Equal(t, found.Author.A, "Ada")
+	assert.Assert(t, found.Editor == nil)
+	assert.Equal(t, found.Email.Which, 2)
+	assert.Equal(t, found.Email.B, 3)
+
+	// An object is deserialized as the second variant, with defaults.
+	found, err = deserializer.DeserializeString(`{"author": {"name": "Ada"}, "editor": {"name": "Charles", "email": "charles@example.com"}, "email": "ada@example.com"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Author.Which, 2)
+	assert.DeepEqual(t, found.Author.B, OneOfAuthor{Name: "Ada", Email: ""})
+	assert.Equal(t, found.Editor.Which, 2)
+	assert.Equal(t, found.Editor.B.Email, "charles@example.com")
+	assert.Equal(t, found.Email.Which, 1)
+	assert.Equal(t, found.Email.A, Email("ada@example.com"))
+
+	// If neither variant matches, both errors are reported.
+	_, err = deserializer.DeserializeString(`{"author": [], "email": 3}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithOneOf.author, expected string or OneOfAuthor\n\t * ")
+	assert.ErrorContains(t, err, "expected string, got []")
+	assert.ErrorContains(t, err, "expected an object of type OneOfAuthor")
+	_, err = deserializer.DeserializeString(`{"author": {}, "email": 3}`)
+	assert.ErrorContains(t, err, "missing value at StructWithOneOf.author.name")
+
+	// Validation errors are reported immediately.
+	_, err = deserializer.DeserializeString(`{"author": "Ada", "email": "ada"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithOneOf.email:\n\t * invalid email ada")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// Values are required.
+	_, err = deserializer.DeserializeString(`{"email": 3}`)
+	assert.ErrorContains(t, err, "missing value at StructWithOneOf.author, expected string or OneOfAuthor")
+
+	// `OneOf2` does not support `default`, use a pointer with `default:"nil"` instead.
+	type WithDefault struct {
+		Author deserialize.OneOf2[string, OneOfAuthor] `json:"author" default:"Ada"`
+	}
+	_, err = deserialize.MakeMapDeserializer[WithDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "is a OneOf2, it cannot have a `default` or `orMethod`")
+}
+
+// ------ Test that we can deserialize YAML.
+
+type YAMLConfig struct {
+	Name    string         `yaml:"name"`
+	Port    uint16         `yaml:"port" default:"8080"`
+	Ratio   float64        `yaml:"ratio"`
+	Debug   bool           `yaml:"debug"`
+	ID      uuid.UUID      `yaml:"id"`
+	Since   time.Time      `yaml:"since"`
+	Tags    []string       `yaml:"tags"`
+	Labels  map[int]string `yaml:"labels"`
+	Backend YAMLBackend    `yaml:"backend"`
+}
+
+type YAMLBackend struct {
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+}
+
+func TestYAML(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[YAMLConfig](deserialize.YAMLOptions(""))
+	assert.NilError(t, err)
+
+	id := uuid.New()
+	since := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := fmt.Sprintf(`
+name: server
+ratio: 0.5
+debug: true
+id: %s
+since: %s
+tags:
+  - a
+  - b
+labels:
+  1: one
+  2: two
+backend:
+  host: localhost
+  port: "1234"
+`, id, since.Format(time.RFC3339))
+	found, err := deserializer.DeserializeString(source)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, YAMLConfig{
+		Name:    "server",
+		Port:    8080,
+		Ratio:   0.5,
+		Debug:   true,
+		ID:      id,
+		Since:   since,
+		Tags:    []string{"a", "b"},
+		Labels:  map[int]string{1: "one", 2: "two"},
+		Backend: YAMLBackend{Host: "localhost", Port: "1234"},
+	})
+
+	// Missing fields are detected.
+	_, err = deserializer.DeserializeString(strings.Replace(source, "ratio: 0.5\n", "", 1))
+	assert.ErrorContains(t, err, "missing value at YAMLConfig.ratio")
+
+	// Type errors are detected.
+	_, err = deserializer.DeserializeString(strings.Replace(source, "ratio: 0.5", "ratio: abc", 1))
+	assert.ErrorContains(t, err, "invalid value at YAMLConfig.ratio, expected float64, got abc")
+
+	// Syntax errors are detected.
+	_, err = deserializer.DeserializeString("name: [")
+	assert.ErrorContains(t, err, "failed to deserialize source")
+}
+
+// ------ Test that raw values are captured for later deserialization.
+
+type WebhookEnvelope struct {
+	Type    string            `json:"type" yaml:"type"`
+	Payload json.RawMessage   `json:"payload" yaml:"payload"`
+	Meta    shared.RawCapture `json:"meta" yaml:"meta" default:"{}"`
+}
+
+type WebhookPing struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+}
+
+func TestRawCapture(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[WebhookEnvelope](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"type": "ping", "payload": {"message": "hello", "count": 3}, "meta": ["a", 1]}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Type, "ping")
+	assert.Equal(t, string(found.Meta), `["a", 1]`)
+
+	// Second phase, once we know the type.
+	pingDeserializer, err := deserialize.MakeMapDeserializer[WebhookPing](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	ping, err := pingDeserializer.DeserializeBytes(found.Payload)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *ping, WebhookPing{Message: "hello", Count: 3})
+
+	// Defaults are used verbatim.
+	found, err = deserializer.DeserializeString(`{"type": "ping", "payload": null}`)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found.Payload), "null")
+	assert.Equal(t, string(found.Meta), "{}")
+
+	_, err = deserializer.DeserializeString(`{"type": "ping"}`)
+	assert.ErrorContains(t, err, "missing value at WebhookEnvelope.payload, expected json.RawMessage")
+
+	// Values are captured verbatim, including large integers, whitespace and the order of keys.
+	source := `{"type": "ping", "payload": {"id": 12345678901234567891, "b": [1, 2.50], "a": "\u00e9"}}`
+	found, err = deserializer.DeserializeString(source)
+	assert.NilError(t, err)
+	assert.Equal(t, string(found.Payload), `{"id": 12345678901234567891, "b": [1, 2.50], "a": "\u00e9"}`)
+	found, err = deserializer.DeserializeReader(strings.NewReader(source))
+	assert.NilError(t, err)
+	assert.Equal(t, string(found.Payload), `{"id": 12345678901234567891, "b": [1, 2.50], "a": "\u00e9"}`)
+
+	// Values built in memory are re-encoded.
+	found, err = deserializer.DeserializeDict(jsonPkg.JSON{"type": "ping", "payload": jsonPkg.JSON{"count": 3}})
+	assert.NilError(t, err)
+	assert.Equal(t, string(found.Payload), `{"count":3}`)
+
+	// Drivers that cannot keep the bytes they decode cannot capture raw values.
+	_, err = deserialize.MakeMapDeserializer[WebhookEnvelope](deserialize.YAMLOptions(""))
+	assert.ErrorContains(t, err, "cannot capture raw values of type json.RawMessage as this unmarshaler does not keep the bytes it decodes")
+	_, err = deserialize.MakeKVListDeserializer[WebhookEnvelope](deserialize.QueryOptions(""))
+	assert.ErrorContains(t, err, "cannot capture raw values of type json.RawMessage")
+}
+
+// ------ Test that we can deserialize CBOR.
+
+type CBORReading struct {
+	Device  uuid.UUID         `cbor:"device"`
+	Payload []byte            `cbor:"payload"`
+	Counter uint64            `cbor:"counter"`
+	Offset  int32             `cbor:"offset"`
+	Celsius float64           `cbor:"celsius"`
+	Labels  map[int]string    `cbor:"labels"`
+	Samples []int16           `cbor:"samples"`
+	Meta    CBORReadingMeta   `cbor:"meta"`
+	Extra   map[string]string `cbor:"extra" default:"{}"`
+}
+
+type CBORReadingMeta struct {
+	Firmware string `cbor:"firmware"`
+	Battery  uint8  `cbor:"battery"`
+}
+
+func TestCBORPrimitives(t *testing.T) {
+	before := PrimitiveTypesStruct{
+		SomeBool:    true,
+		SomeString:  "text",
+		SomeFloat32: -1.0,
+		SomeFloat64: -2.0,
+		SomeInt:     -1,
+		SomeInt8:    -2,
+		SomeInt16:   -3,
+		SomeInt32:   -4,
+		SomeInt64:   -5,
+		SomeUint8:   6,
+		SomeUint16:  7,
+		SomeUint32:  8,
+		SomeUint64:  9,
+	}
+	buf, err := cbor.Marshal(before)
+	assert.NilError(t, err)
+
+	deserializer, err := deserialize.MakeMapDeserializer[PrimitiveTypesStruct](deserialize.CBOROptions(""))
+	assert.NilError(t, err)
+	after, err := deserializer.DeserializeBytes(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, *after, before, "We should have recovered the same struct")
+
+	// Integers that do not fit are rejected.
+	buf, err = cbor.Marshal(map[string]any{"SomeBool": true, "SomeString": "text", "SomeFloat32": 0, "SomeFloat64": 0, "SomeInt": 0, "SomeInt8": 300, "SomeInt16": 0, "SomeInt32": 0, "SomeInt64": 0, "SomeUint8": 0, "SomeUint16": 0, "SomeUint32": 0, "SomeUint64": 0})
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeBytes(buf)
+	assert.ErrorContains(t, err, "out of range")
+}
+
+func TestCBOR(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[CBORReading](deserialize.CBOROptions(""))
+	assert.NilError(t, err)
+
+	id := uuid.New()
+	before := CBORReading{
+		Device:  id,
+		Payload: []byte{0x00, 0x01, 0xfe, 0xff},
+		Counter: 1 << 60,
+		Offset:  -42,
+		Celsius: 21.5,
+		Labels:  map[int]string{1: "one", 2: "two"},
+		Samples: []int16{-1, 0, 1},
+		Meta: CBORReadingMeta{
+			Firmware: "1.2.3",
+			Battery:  97,
+		},
+		Extra: map[string]string{},
+	}
+	// `payload` arrives as a CBOR byte string, `device` as the byte string of a UUID.
+	buf, err := cbor.Marshal(before)
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeBytes(buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, before)
+
+	// Streams are supported, too.
+	found, err = deserializer.DeserializeReader(strings.NewReader(string(buf)))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, before)
+
+	// A UUID may also arrive as a text string.
+	source := map[string]any{
+		"device":  id.String(),
+		"payload": []byte{},
+		"counter": 0,
+		"offset":  0,
+		"celsius": 0.0,
+		"labels":  map[int]string{},
+		"samples": []int16{},
+		"meta":    map[string]any{"firmware": "", "battery": 0},
+	}
+	buf, err = cbor.Marshal(source)
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeBytes(buf)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Device, id)
+
+	// A number is not a byte string.
+	source["payload"] = 12
+	buf, err = cbor.Marshal(source)
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeBytes(buf)
+	assert.ErrorContains(t, err, "CBORReading.payload")
+
+	// Documents must be maps.
+	buf, err = cbor.Marshal([]int{1, 2, 3})
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeBytes(buf)
+	assert.ErrorContains(t, err, "failed to deserialize as a dictionary")
+}
+
+// ------ Test that we can opt into deserializing numbers from booleans.
+
+type StructWithNumericFlags struct {
+	Int   int     `json:"int"`
+	Uint8 uint8   `json:"uint8"`
+	Float float64 `json:"float"`
+}
+
+func TestBoolsAsNumbers(t *testing.T) {
+	source := `{"int": true, "uint8": false, "float": true}`
+
+	// By default, booleans are rejected.
+	strict, err := deserialize.MakeMapDeserializer[StructWithNumericFlags](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	_, err = strict.DeserializeString(`{"int": true, "uint8": 0, "float": 0}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithNumericFlags.int, expected int, got true")
+
+	// With the option, they're converted to 0/1.
+	options := deserialize.JSONOptions("")
+	options.BoolsAsNumbers = true
+	lenient, err := deserialize.MakeMapDeserializer[StructWithNumericFlags](options)
+	assert.NilError(t, err)
+	found, err := lenient.DeserializeString(source)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNumericFlags{Int: 1, Uint8: 0, Float: 1})
+
+	// Numbers still work.
+	found, err = lenient.DeserializeString(`{"int": 5, "uint8": 6, "float": 7.5}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNumericFlags{Int: 5, Uint8: 6, Float: 7.5})
+
+	// This does not affect non-numeric fields.
+	stringDeserializer, err := deserialize.MakeMapDeserializer[Pair[string, int]](options)
+	assert.NilError(t, err)
+	_, err = stringDeserializer.DeserializeString(`{"left": true, "right": true}`)
+	assert.ErrorContains(t, err, "invalid value at Pair[string,int].left, expected string, got true")
+}
+
+// ------ Test that we can deserialize `url.Values` and `http.Header`.
+
+type StructWithURLValues struct {
+	Query   url.Values  `json:"query"`
+	Header  http.Header `json:"header"`
+	Default url.Values  `json:"default" default:"{}"`
+}
+
+func TestURLValuesAndHTTPHeader(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithURLValues](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"query": {"a": ["b", "c"], "d": []}, "header": {"X-Foo": ["bar"]}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithURLValues{
+		Query:   url.Values{"a": []string{"b", "c"}, "d": []string{}},
+		Header:  http.Header{"X-Foo": []string{"bar"}},
+		Default: url.Values{},
+	})
+	assert.Equal(t, found.Query.Get("a"), "b")
+	assert.Equal(t, found.Header.Get("x-foo"), "bar")
+
+	// Values must be lists of strings.
+	_, err = deserializer.DeserializeString(`{"query": {"a": "b"}, "header": {}}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithURLValues.query[], expected an array of type []string, got b")
+
+	_, err = deserializer.DeserializeString(`{"query": {"a": [1]}, "header": {}}`)
+	assert.ErrorContains(t, err, "StructWithURLValues.query")
+
+	// Missing values are detected.
+	_, err = deserializer.DeserializeString(`{"header": {}}`)
+	assert.ErrorContains(t, err, "StructWithURLValues.query")
+}
+
+// ------ Test that we can deserialize from environment variables.
+
+type EnvConfig struct {
+	Port    uint16    `env:"PORT"`
+	Host    string    `env:"HOST" default:"localhost"`
+	Debug   bool      `env:"DEBUG" default:"false"`
+	Servers []string  `env:"SERVERS"`
+	Ports   []int     `env:"PORTS" default:"[]"`
+	ID      uuid.UUID `env:"ID"`
+}
+
+func TestEnv(t *testing.T) {
+	deserializer, err := deserialize.MakeEnvDeserializer[EnvConfig](deserialize.EnvOptions("MYAPP_"))
+	assert.NilError(t, err)
+
+	id := uuid.New()
+	environ := []string{
+		"PATH=/usr/bin",
+		"PORT=1",
+		"MYAPP_PORT=8080",
+		"MYAPP_SERVERS=a.example.com,b.example.com",
+		"MYAPP_ID=" + id.String(),
+		"MYAPP_UNKNOWN=abc",
+	}
+	found, err := deserializer.DeserializeEnviron(environ)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, EnvConfig{
+		Port:    8080,
+		Host:    "localhost",
+		Debug:   false,
+		Servers: []string{"a.example.com", "b.example.com"},
+		Ports:   []int{},
+		ID:      id,
+	})
+
+	// Values containing the separator are not split for non-list fields.
+	found, err = deserializer.DeserializeEnviron(append(environ, "MYAPP_HOST=a,b", "MYAPP_PORTS=1,2,3", "MYAPP_DEBUG=true"))
+	assert.NilError(t, err)
+	assert.Equal(t, found.Host, "a,b")
+	assert.DeepEqual(t, found.Ports, []int{1, 2, 3})
+	assert.Equal(t, found.Debug, true)
+
+	// Empty lists.
+	found, err = deserializer.DeserializeEnviron(append(environ, "MYAPP_PORTS="))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Ports, []int{})
+
+	// Errors are detected.
+	_, err = deserializer.DeserializeEnviron([]string{"MYAPP_SERVERS=a", "MYAPP_ID=" + id.String()})
+	assert.ErrorContains(t, err, "missing value at EnvConfig.PORT")
+
+	_, err = deserializer.DeserializeEnviron(append(environ, "MYAPP_PORTS=1,abc"))
+	assert.ErrorContains(t, err, "EnvConfig.PORTS[1]")
+
+	// Custom separators.
+	options := deserialize.EnvOptions("MYAPP_")
+	options.ListSeparator = ":"
+	deserializer, err = deserialize.MakeEnvDeserializer[EnvConfig](options)
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeEnviron(append(environ, "MYAPP_PORTS=1:2"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Ports, []int{1, 2})
+	assert.DeepEqual(t, found.Servers, []string{"a.example.com,b.example.com"})
+
+	// Without prefix.
+	deserializer, err = deserialize.MakeEnvDeserializer[EnvConfig](deserialize.EnvOptions(""))
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeEnviron([]string{"PORT=1", "SERVERS=a", "ID=" + id.String()})
+	assert.NilError(t, err)
+	assert.Equal(t, found.Port, uint16(1))
+
+	// Actual environment.
+	t.Setenv("MYAPP_PORT", "1234")
+	t.Setenv("MYAPP_SERVERS", "a")
+	t.Setenv("MYAPP_ID", id.String())
+	deserializer, err = deserialize.MakeEnvDeserializer[EnvConfig](deserialize.EnvOptions("MYAPP_"))
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeEnv()
+	assert.NilError(t, err)
+	assert.Equal(t, found.Port, uint16(1234))
+}
+
+// ------ Test that we can deserialize JSON embedded in a string.
+
+type StructWithEscapedJSON struct {
+	Payload  Pair[int, string]   `json:"payload" unescape:"json"`
+	List     []int               `json:"list" unescape:"json"`
+	Optional *Pair[int, string]  `json:"optional" unescape:"json"`
+	Nested   StructWithEscapedID `json:"nested" unescape:"json"`
+}
+
+type StructWithEscapedID struct {
+	Inner string `json:"inner" unescape:"json"`
+}
+
+func TestUnescapeJSON(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithEscapedJSON](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{
+		"payload": "{\"left\": 1, \"right\": \"abc\"}",
+		"list": "[1, 2, 3]",
+		"optional": "{\"left\": 2, \"right\": \"def\"}",
+		"nested": "{\"inner\": \"\\\"def\\\"\"}"
+	}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithEscapedJSON{
+		Payload:  Pair[int, string]{Left: 1, Right: "abc"},
+		List:     []int{1, 2, 3},
+		Optional: &Pair[int, string]{Left: 2, Right: "def"},
+		Nested:   StructWithEscapedID{Inner: "def"},
+	})
+
+	// Values that are not escaped are accepted as is.
+	found, err = deserializer.DeserializeString(`{
+		"payload": {"left": 1, "right": "abc"},
+		"list": [1, 2, 3],
+		"optional": {"left": 2, "right": "def"},
+		"nested": {"inner": "\"def\""}
+	}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Payload, Pair[int, string]{Left: 1, Right: "abc"})
+
+	// We only unescape once.
+	_, err = deserializer.DeserializeString(`{
+		"payload": "\"{\\\"left\\\": 1, \\\"right\\\": \\\"abc\\\"}\"",
+		"list": "[1, 2, 3]",
+		"optional": "{\"left\": 2, \"right\": \"def\"}",
+		"nested": "{\"inner\": \"\\\"def\\\"\"}"
+	}`)
+	assert.ErrorContains(t, err, "StructWithEscapedJSON.payload")
+
+	// Invalid JSON is reported.
+	_, err = deserializer.DeserializeString(`{
+		"payload": "{\"left\": 1,",
+		"list": "[1, 2, 3]",
+		"optional": "{\"left\": 2, \"right\": \"def\"}",
+		"nested": "{\"inner\": \"\\\"def\\\"\"}"
+	}`)
+	assert.ErrorContains(t, err, "invalid json value at StructWithEscapedJSON.payload")
+
+	// Only JSON is supported.
+	type InvalidUnescape struct {
+		Field Pair[int, string] `unescape:"xml"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidUnescape](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `unescape` value xml, expected json")
+}
+
+// ------ Test that `Nullable` distinguishes between absent, null and present.
+
+type StructWithNullables struct {
+	Int    deserialize.Nullable[int]               `json:"int"`
+	String deserialize.Nullable[string]            `json:"string"`
+	ID     deserialize.Nullable[uuid.UUID]         `json:"id"`
+	Pair   deserialize.Nullable[Pair[int, string]] `json:"pair"`
+	Name   deserialize.Nullable[PolymorphicName]   `json:"name"`
+	List   deserialize.Nullable[[]int]             `json:"list"`
+}
+
+func TestNullable(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithNullables](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// Absent.
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNullables{})
+
+	// Present but null.
+	found, err = deserializer.DeserializeString(`{"int": null, "string": null, "id": null, "pair": null, "name": null, "list": null}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNullables{
+		Int:    deserialize.Nullable[int]{Set: true, Null: true},
+		String: deserialize.Nullable[string]{Set: true, Null: true},
+		ID:     deserialize.Nullable[uuid.UUID]{Set: true, Null: true},
+		Pair:   deserialize.Nullable[Pair[int, string]]{Set: true, Null: true},
+		Name:   deserialize.Nullable[PolymorphicName]{Set: true, Null: true},
+		List:   deserialize.Nullable[[]int]{Set: true, Null: true},
+	})
+
+	// Present with a value.
+	id := uuid.New()
+	found, err = deserializer.DeserializeString(fmt.Sprintf(`{"int": 1, "string": "abc", "id": "%s", "pair": {"left": 2, "right": "def"}, "name": "ghi", "list": [3, 4]}`, id))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNullables{
+		Int:    deserialize.Nullable[int]{Set: true, Value: 1},
+		String: deserialize.Nullable[string]{Set: true, Value: "abc"},
+		ID:     deserialize.Nullable[uuid.UUID]{Set: true, Value: id},
+		Pair:   deserialize.Nullable[Pair[int, string]]{Set: true, Value: Pair[int, string]{Left: 2, Right: "def"}},
+		Name:   deserialize.Nullable[PolymorphicName]{Set: true, Value: PolymorphicName{Name: "ghi"}},
+		List:   deserialize.Nullable[[]int]{Set: true, Value: []int{3, 4}},
+	})
+
+	// Zero values are distinct from null.
+	found, err = deserializer.DeserializeString(`{"int": 0, "string": ""}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Int, deserialize.Nullable[int]{Set: true, Value: 0})
+	assert.DeepEqual(t, found.String, deserialize.Nullable[string]{Set: true, Value: ""})
+
+	// Type errors are reported.
+	_, err = deserializer.DeserializeString(`{"int": "abc"}`)
+	assert.ErrorContains(t, err, "at StructWithNullables.int, expected to be able to parse a Nullable[int]")
+
+	_, err = deserializer.DeserializeString(`{"string": 123}`)
+	assert.ErrorContains(t, err, "at StructWithNullables.string")
+}
+
+type NullableRetry struct {
+	Attempts int `json:"attempts" yaml:"attempts" default:"3"`
+	Delay    int `json:"delay" yaml:"delay"`
+}
+
+func (r *NullableRetry) Validate() error {
+	if r.Attempts > 10 {
+		return errors.New("too many attempts")
+	}
+	return nil
+}
+
+type StructWithNullableRetry struct {
+	Retry deserialize.Nullable[NullableRetry] `json:"retry" yaml:"retry"`
+}
+
+func TestNullableStruct(t *testing.T) {
+	// Tags and validators of the value are applied.
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithNullableRetry](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"retry": {"delay": 5}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Retry, deserialize.Nullable[NullableRetry]{Set: true, Value: NullableRetry{Attempts: 3, Delay: 5}})
+
+	_, err = deserializer.DeserializeString(`{"retry": {}}`)
+	assert.ErrorContains(t, err, "missing value at StructWithNullableRetry.retry.delay")
+
+	_, err = deserializer.DeserializeString(`{"retry": {"attempts": 20, "delay": 5}}`)
+	assert.ErrorContains(t, err, "too many attempts")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// The value is deserialized with the same driver, e.g. from YAML.
+	yamlDeserializer, err := deserialize.MakeMapDeserializer[StructWithNullableRetry](deserialize.YAMLOptions(""))
+	assert.NilError(t, err)
+	found, err = yamlDeserializer.DeserializeString("retry:\n  attempts: 2\n  delay: 5\n")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Retry, deserialize.Nullable[NullableRetry]{Set: true, Value: NullableRetry{Attempts: 2, Delay: 5}})
+	found, err = yamlDeserializer.DeserializeString("retry: null\n")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Retry, deserialize.Nullable[NullableRetry]{Set: true, Null: true})
+
+	// `default` and `orMethod` are rejected.
+	type NullableWithDefault struct {
+		Retry deserialize.Nullable[int] `json:"retry" default:"1"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NullableWithDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "type Nullable[int] is a Nullable, it cannot have a `default` or `orMethod`")
+}
+
+// ------ Test that we can register custom parsers.
+
+type StructWithDurations struct {
+	Timeout  time.Duration            `json:"timeout"`
+	Retry    time.Duration            `json:"retry" default:"1m"`
+	Backoffs []time.Duration          `json:"backoffs" default:"[]"`
+	ByDelay  map[time.Duration]string `json:"byDelay" default:"{}"`
+}
+
+func TestCustomParsers(t *testing.T) {
+	parsers := map[reflect.Type]shared.Parser{
+		reflect.TypeOf(time.Duration(0)): func(source string) (any, error) {
+			return time.ParseDuration(source)
+		},
+	}
+
+	// Without custom parsers, durations are parsed as integers.
+	_, err := deserialize.MakeMapDeserializer[StructWithDurations](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "cannot parse default value at StructWithDurations.retry")
+
+	// With custom parsers, from JSON.
+	options := deserialize.JSONOptions("")
+	options.Parsers = parsers
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithDurations](options)
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"timeout": "30s", "backoffs": ["1s", "2s"], "byDelay": {"1h": "long"}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithDurations{
+		Timeout:  30 * time.Second,
+		Retry:    time.Minute,
+		Backoffs: []time.Duration{time.Second, 2 * time.Second},
+		ByDelay:  map[time.Duration]string{time.Hour: "long"},
+	})
+
+	// Numbers are still accepted.
+	found, err = deserializer.DeserializeString(`{"timeout": 5}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Timeout, time.Duration(5))
+
+	// Errors are reported.
+	_, err = deserializer.DeserializeString(`{"timeout": "soon"}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithDurations.timeout")
+
+	// With custom parsers, from query strings.
+	type QueryWithDurations struct {
+		Timeout  time.Duration   `query:"timeout"`
+		Backoffs []time.Duration `query:"backoffs"`
+	}
+	queryOptions := deserialize.QueryOptions("")
+	queryOptions.Parsers = parsers
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[QueryWithDurations](queryOptions)
+	assert.NilError(t, err)
+	foundKV, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"timeout": []string{"30s"}, "backoffs": []string{"1s", "1h"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *foundKV, QueryWithDurations{
+		Timeout:  30 * time.Second,
+		Backoffs: []time.Duration{time.Second, time.Hour},
+	})
+}
+
+// ------ Test that `required` fields must be present, even if pre-initialized.
+
+type StructWithRequiredFields struct {
+	Name  string `json:"name" query:"name" required:""`
+	Count int    `json:"count" query:"count"`
+	Page  int    `json:"page" query:"page" required:""`
+}
+
+func (s *StructWithRequiredFields) Initialize() error {
+	s.Name = "anonymous"
+	s.Count = 10
+	s.Page = 1
+	return nil
+}
+
+var _ validation.Initializer = &StructWithRequiredFields{}
+
+func TestRequired(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithRequiredFields](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"name": "abc", "page": 0}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithRequiredFields{Name: "abc", Count: 10, Page: 0})
+
+	// Pre-initialization doesn't suffice for required fields.
+	_, err = deserializer.DeserializeString(`{"page": 2}`)
+	assert.ErrorContains(t, err, "missing required value at StructWithRequiredFields.name")
+
+	_, err = deserializer.DeserializeString(`{"name": "abc"}`)
+	assert.ErrorContains(t, err, "missing required value at StructWithRequiredFields.page")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithRequiredFields](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	foundKV, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"name": []string{"abc"}, "page": []string{"3"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *foundKV, StructWithRequiredFields{Name: "abc", Count: 10, Page: 3})
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"name": []string{"abc"}})
+	assert.ErrorContains(t, err, "missing required value at StructWithRequiredFields.page")
+
+	// Also works with tag `initialized`.
+	type Container struct {
+		Inner Pair[string, int] `json:"inner" initialized:""`
+		Other struct {
+			Value int `json:"value" required:""`
+		} `json:"other" initialized:""`
+	}
+	containerDeserializer, err := deserialize.MakeMapDeserializer[Container](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	_, err = containerDeserializer.DeserializeString(`{"inner": {}, "other": {"value": 1}}`)
+	assert.NilError(t, err)
+	_, err = containerDeserializer.DeserializeString(`{"inner": {}, "other": {}}`)
+	assert.ErrorContains(t, err, "missing required value at Container.other.value")
+
+	// `required` conflicts with `default`.
+	type Conflict struct {
+		Field int `required:"" default:"0"`
+	}
+	_, err = deserialize.MakeMapDeserializer[Conflict](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "is `required` but also has a `default` or `orMethod` declaration")
+}
+
+// ------ Test that we can validate fields against a JSON Schema.
+
+type StructWithSchema struct {
+	Config Pair[int, string] `json:"config" schema:"config"`
+	Tags   []string          `json:"tags" schema:"tags" default:"[]"`
+}
+
+func TestSchema(t *testing.T) {
+	configSchema := jsonschema.MustCompileString("config.json", `{
+		"type": "object",
+		"properties": {
+			"left": {"type": "integer", "minimum": 0},
+			"right": {"type": "string", "pattern": "^[a-z]+$"}
+		},
+		"required": ["left", "right"]
+	}`)
+	tagsSchema := jsonschema.MustCompileString("tags.json", `{
+		"type": "array",
+		"items": {"type": "string"},
+		"uniqueItems": true
+	}`)
+	options := deserialize.JSONOptions("")
+	options.Schemas = map[string]*jsonschema.Schema{
+		"config": configSchema,
+		"tags":   tagsSchema,
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithSchema](options)
+	assert.NilError(t, err)
+
+	// Passing subtree.
+	found, err := deserializer.DeserializeString(`{"config": {"left": 1, "right": "abc"}, "tags": ["a", "b"]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithSchema{
+		Config: Pair[int, string]{Left: 1, Right: "abc"},
+		Tags:   []string{"a", "b"},
+	})
+
+	// Missing values are not checked against the schema.
+	found, err = deserializer.DeserializeString(`{"config": {"left": 1, "right": "abc"}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Tags, []string{})
+
+	// Failing subtree.
+	_, err = deserializer.DeserializeString(`{"config": {"left": -1, "right": "abc"}}`)
+	assert.ErrorContains(t, err, "validation error at StructWithSchema.config")
+	assert.ErrorContains(t, err, "value does not match schema config")
+	assert.ErrorContains(t, err, "must be >= 0")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"config": {"left": 1, "right": "ABC"}, "tags": ["a", "b"]}`)
+	assert.ErrorContains(t, err, "StructWithSchema.config")
+
+	_, err = deserializer.DeserializeString(`{"config": {"left": 1, "right": "abc"}, "tags": ["a", "a"]}`)
+	assert.ErrorContains(t, err, "value does not match schema tags")
+
+	// Unknown schemas are detected early.
+	_, err = deserialize.MakeMapDeserializer[StructWithSchema](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "unknown schema config")
+}
+
+// ------ Test that maps may reject empty keys.
+
+type StructWithNonEmptyKeys struct {
+	Strict  map[string]int `json:"strict" rejectEmptyKeys:""`
+	Lenient map[string]int `json:"lenient"`
+}
+
+func TestRejectEmptyKeys(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithNonEmptyKeys](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"strict": {"a": 1}, "lenient": {"": 2}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithNonEmptyKeys{
+		Strict:  map[string]int{"a": 1},
+		Lenient: map[string]int{"": 2},
+	})
+
+	_, err = deserializer.DeserializeString(`{"strict": {"a": 1, "": 2}, "lenient": {}}`)
+	assert.ErrorContains(t, err, "invalid empty key at StructWithNonEmptyKeys.strict")
+
+	// The tag only makes sense on maps.
+	type Invalid struct {
+		Field string `rejectEmptyKeys:""`
+	}
+	_, err = deserialize.MakeMapDeserializer[Invalid](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `rejectEmptyKeys` may only be used on maps")
+}
+
+// ------ Test that `min` and `max` tags are enforced.
+
+type StructWithRanges struct {
+	Percent uint8   `json:"percent" query:"percent" min:"0" max:"100"`
+	Delta   int     `json:"delta" query:"delta" min:"-10"`
+	Ratio   float64 `json:"ratio" query:"ratio" max:"1.5" default:"1"`
+}
+
+func TestMinMax(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithRanges](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"percent": 100, "delta": -10, "ratio": 1.5}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithRanges{Percent: 100, Delta: -10, Ratio: 1.5})
+
+	found, err = deserializer.DeserializeString(`{"percent": 0, "delta": 1000}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithRanges{Percent: 0, Delta: 1000, Ratio: 1})
+
+	_, err = deserializer.DeserializeString(`{"percent": 101, "delta": 0}`)
+	assert.ErrorContains(t, err, "validation error at StructWithRanges.percent:\n\t * expected a value <= 100, got 101")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"percent": 50, "delta": -11}`)
+	assert.ErrorContains(t, err, "validation error at StructWithRanges.delta:\n\t * expected a value >= -10, got -11")
+
+	_, err = deserializer.DeserializeString(`{"percent": 50, "delta": 0, "ratio": 1.51}`)
+	assert.ErrorContains(t, err, "validation error at StructWithRanges.ratio:\n\t * expected a value <= 1.5, got 1.51")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithRanges](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"percent": []string{"50"}, "delta": []string{"-10"}})
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"percent": []string{"50"}, "delta": []string{"-11"}})
+	assert.ErrorContains(t, err, "validation error at StructWithRanges.delta")
+
+	// Bounds are parsed with the type of the field.
+	type NegativeUint struct {
+		Field uint8 `min:"-1"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NegativeUint](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `min` value -1 for type uint8")
+
+	type InvertedBounds struct {
+		Field int `min:"10" max:"0"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvertedBounds](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "`min` value 10 is greater than `max` value 0")
+
+	type NotANumber struct {
+		Field string `min:"10"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotANumber](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `min` may only be used on numbers")
+}
+
+// ------ Test that named numeric types may implement `Validator`.
+
+type Percentage int
+
+func (p *Percentage) Validate() error {
+	if *p < 0 || *p > 100 {
+		return fmt.Errorf("expected a percentage, got %d", *p)
+	}
+	return nil
+}
+
+var _ validation.Validator = new(Percentage)
+
+type StructWithPercentage struct {
+	Ratio  Percentage   `json:"ratio" query:"ratio"`
+	Ratios []Percentage `json:"ratios" query:"ratios" default:"[]"`
+}
+
+func TestValidateNamedNumber(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithPercentage](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"ratio": 42, "ratios": [0, 100]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithPercentage{Ratio: 42, Ratios: []Percentage{0, 100}})
+
+	// Numbers provided as strings are parsed, then validated.
+	found, err = deserializer.DeserializeString(`{"ratio": "42"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Ratio, Percentage(42))
+
+	_, err = deserializer.DeserializeString(`{"ratio": 101}`)
+	assert.ErrorContains(t, err, "validation error at StructWithPercentage.ratio:\n\t * expected a percentage, got 101")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"ratio": "-1"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithPercentage.ratio:\n\t * expected a percentage, got -1")
+
+	_, err = deserializer.DeserializeString(`{"ratio": 0, "ratios": [50, 200]}`)
+	assert.ErrorContains(t, err, "validation error at StructWithPercentage.ratios[]:\n\t * expected a percentage, got 200")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithPercentage](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"ratio": []string{"42"}})
+	assert.NilError(t, err)
+	assert.Equal(t, kvFound.Ratio, Percentage(42))
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"ratio": []string{"101"}})
+	assert.ErrorContains(t, err, "validation error at StructWithPercentage.ratio:\n\t * expected a percentage, got 101")
+}
+
+// ------ Test that named string types may implement `Validator`.
+
+type Email string
+
+func (e *Email) Validate() error {
+	if !strings.Contains(string(*e), "@") {
+		return fmt.Errorf("invalid email %s", *e)
+	}
+	return nil
+}
+
+var _ validation.Validator = new(Email)
+
+type StructWithEmail struct {
+	Email   Email  `json:"email" query:"email"`
+	Contact *Email `json:"contact" query:"contact"`
+	Backup  Email  `json:"backup" query:"backup" default:"nobody"`
+}
+
+func TestValidateNamedString(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithEmail](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"email": "alice@example.com", "contact": "bob@example.com", "backup": "carol@example.com"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Email, Email("alice@example.com"))
+	assert.Equal(t, *found.Contact, Email("bob@example.com"))
+
+	_, err = deserializer.DeserializeString(`{"email": "alice", "contact": "bob@example.com", "backup": "carol@example.com"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithEmail.email:\n\t * invalid email alice")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"email": "alice@example.com", "contact": "bob", "backup": "carol@example.com"}`)
+	assert.ErrorContains(t, err, "invalid email bob")
+
+	// Default values are validated, too.
+	_, err = deserializer.DeserializeString(`{"email": "alice@example.com", "contact": "bob@example.com"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithEmail.backup:\n\t * invalid email nobody")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithEmail](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"email": []string{"alice@example.com"}, "contact": []string{"bob@example.com"}, "backup": []string{"carol@example.com"}})
+	assert.NilError(t, err)
+	assert.Equal(t, kvFound.Email, Email("alice@example.com"))
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"email": []string{"alice"}, "contact": []string{"bob@example.com"}, "backup": []string{"carol@example.com"}})
+	assert.ErrorContains(t, err, "validation error at StructWithEmail.email:\n\t * invalid email alice")
+}
+
+// ------ Test that named primitive types are validated inside collections.
+
+type StructWithEmailCollections struct {
+	List []Email          `json:"list"`
+	ByID map[string]Email `json:"byID"`
+	Pair [2]Email         `json:"pair"`
+}
+
+func TestValidateNamedStringInCollections(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithEmailCollections](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"list": ["a@example.com"], "byID": {"b": "b@example.com"}, "pair": ["c@example.com", "d@example.com"]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.List, []Email{"a@example.com"})
+	assert.Equal(t, found.ByID["b"], Email("b@example.com"))
+	assert.Equal(t, found.Pair[1], Email("d@example.com"))
+
+	_, err = deserializer.DeserializeString(`{"list": ["a"], "byID": {}, "pair": ["c@example.com", "d@example.com"]}`)
+	assert.ErrorContains(t, err, "invalid email a")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"list": [], "byID": {"b": "b"}, "pair": ["c@example.com", "d@example.com"]}`)
+	assert.ErrorContains(t, err, "invalid email b")
+
+	_, err = deserializer.DeserializeString(`{"list": [], "byID": {}, "pair": ["c@example.com", "d"]}`)
+	assert.ErrorContains(t, err, "invalid email d")
+}
+
+// ------ Test that `when` only deserializes fields whose flag is enabled.
+
+type StructWithFeatureFlags struct {
+	Name   string   `json:"name"`
+	Beta   string   `json:"beta" when:"betaEnabled"`
+	Labels []string `json:"labels" when:"labelsEnabled,reject"`
+	Theme  string   `json:"theme" when:"themesEnabled" default:"light"`
+}
+
+func TestWhen(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithFeatureFlags](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	dict := jsonPkg.JSON{"name": "abc", "beta": "xyz", "labels": []any{"a"}, "theme": "dark"}
+
+	// All flags enabled.
+	found, err := deserializer.DeserializeDictWithValues(dict, map[string]any{"betaEnabled": true, "labelsEnabled": "true", "themesEnabled": true})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithFeatureFlags{Name: "abc", Beta: "xyz", Labels: []string{"a"}, Theme: "dark"})
+
+	// When enabled, fields behave as usual.
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"name": "abc"}, map[string]any{"betaEnabled": true})
+	assert.ErrorContains(t, err, "missing value at StructWithFeatureFlags.beta")
+
+	// Disabled fields ignore their value and fall back to their default, if any...
+	found, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"name": "abc", "beta": "xyz", "theme": "dark"}, map[string]any{"betaEnabled": false})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithFeatureFlags{Name: "abc", Beta: "", Labels: nil, Theme: "light"})
+
+	// ... or reject it, if requested.
+	_, err = deserializer.DeserializeDictWithValues(dict, map[string]any{"betaEnabled": true})
+	assert.ErrorContains(t, err, "field StructWithFeatureFlags.labels is disabled, it requires labelsEnabled")
+
+	// Without a bag of values, all flags are disabled.
+	found, err = deserializer.DeserializeDict(jsonPkg.JSON{"name": "abc", "beta": "xyz"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithFeatureFlags{Name: "abc", Beta: "", Labels: nil, Theme: "light"})
+
+	// The tag is checked when setting up the deserializer.
+	type InvalidMode struct {
+		Field string `json:"field" when:"flag,drop"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidMode](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidMode.field, invalid `when` value")
+
+	type Required struct {
+		Field string `json:"field" when:"flag" required:""`
+	}
+	_, err = deserialize.MakeMapDeserializer[Required](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "that is both `required` and `when`")
+}
+
+// ------ Test that `base` parses integers from strings in another base.
+
+type StructWithBase struct {
+	ID     uint32  `query:"id" json:"id" base:"16"`
+	Offset int8    `query:"offset" json:"offset" base:"0"`
+	Parent *uint32 `query:"parent" json:"parent" base:"16" default:"nil"`
+	Mask   uint8   `query:"mask" json:"mask" base:"2" default:"1010"`
+}
+
+func TestBase(t *testing.T) {
+	deserializer, err := deserialize.MakeKVListDeserializer[StructWithBase](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+
+	parent := uint32(0xcafe)
+	found, err := deserializer.DeserializeKVList(kvlist.KVList{"id": []string{"0xFF"}, "offset": []string{"-0o17"}, "parent": []string{"cafe"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithBase{ID: 0xff, Offset: -0o17, Parent: &parent, Mask: 0b1010})
+
+	found, err = deserializer.DeserializeKVList(kvlist.KVList{"id": []string{"ff"}, "offset": []string{"42"}, "mask": []string{"0b11"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithBase{ID: 0xff, Offset: 42, Parent: nil, Mask: 0b11})
+
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"id": []string{"0xfg"}, "offset": []string{"0"}})
+	assert.ErrorContains(t, err, "invalid value at StructWithBase.id")
+
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"id": []string{"1ffffffff"}, "offset": []string{"0"}})
+	assert.ErrorContains(t, err, "out of range")
+
+	// Numbers decoded as numbers are not affected.
+	jsonDeserializer, err := deserialize.MakeMapDeserializer[StructWithBase](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err = jsonDeserializer.DeserializeString(`{"id": 10, "offset": "0x10"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithBase{ID: 10, Offset: 16, Parent: nil, Mask: 0b1010})
+
+	// The tag is checked when setting up the deserializer.
+	type InvalidBase struct {
+		ID uint32 `json:"id" base:"1"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidBase](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidBase.id, invalid `base` value 1")
+
+	type NotAnInteger struct {
+		ID float64 `json:"id" base:"16"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAnInteger](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at NotAnInteger.id, tag `base` may only be used on integers, got float64")
+}
+
+// ------ Test that `Locale` parses locale-formatted numbers.
+
+type LocalizedPrice struct {
+	Amount   float64 `query:"amount" json:"amount"`
+	Quantity int     `query:"quantity" json:"quantity"`
+	Discount float64 `query:"discount" json:"discount" default:"0.5"`
+}
+
+func TestLocale(t *testing.T) {
+	// German, with decimal commas.
+	options := deserialize.QueryOptions("")
+	options.Locale = language.German
+	deserializer, err := deserialize.MakeKVListDeserializer[LocalizedPrice](options)
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeKVList(kvlist.KVList{"amount": []string{"1.234,5"}, "quantity": []string{"-1.000"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, LocalizedPrice{Amount: 1234.5, Quantity: -1000, Discount: 0.5})
+
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"amount": []string{"1,2,3"}, "quantity": []string{"1"}})
+	assert.ErrorContains(t, err, "invalid value at LocalizedPrice.amount")
+
+	// French, with (non-breaking) spaces between groups.
+	options.Locale = language.French
+	deserializer, err = deserialize.MakeKVListDeserializer[LocalizedPrice](options)
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeKVList(kvlist.KVList{"amount": []string{"1\u00a0234,5"}, "quantity": []string{"12 000"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, LocalizedPrice{Amount: 1234.5, Quantity: 12000, Discount: 0.5})
+
+	// Arabic, with native digits.
+	options.Locale = language.Arabic
+	deserializer, err = deserialize.MakeKVListDeserializer[LocalizedPrice](options)
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeKVList(kvlist.KVList{"amount": []string{"١٬٢٣٤٫٥"}, "quantity": []string{"٤٢"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, LocalizedPrice{Amount: 1234.5, Quantity: 42, Discount: 0.5})
+
+	// Numbers decoded as numbers are not affected.
+	jsonOptions := deserialize.JSONOptions("")
+	jsonOptions.Locale = language.German
+	jsonDeserializer, err := deserialize.MakeMapDeserializer[LocalizedPrice](jsonOptions)
+	assert.NilError(t, err)
+	found, err = jsonDeserializer.DeserializeString(`{"amount": 1.5, "quantity": "2.000"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, LocalizedPrice{Amount: 1.5, Quantity: 2000, Discount: 0.5})
+
+	// Without a locale, no localization.
+	deserializer, err = deserialize.MakeKVListDeserializer[LocalizedPrice](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"amount": []string{"1234,5"}, "quantity": []string{"1"}})
+	assert.ErrorContains(t, err, "invalid value at LocalizedPrice.amount")
+}
+
+// ------ Test that `layout` parses times from strings with a custom layout.
+
+type StructWithLayout struct {
+	Day   time.Time  `json:"day" query:"day" layout:"2006-01-02"`
+	Until *time.Time `json:"until" query:"until" layout:"2006-01-02"`
+	Since time.Time  `json:"since" query:"since" layout:"Jan 2, 2006" default:"Jan 1, 2000"`
+}
+
+func TestLayout(t *testing.T) {
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, time.March, 31, 0, 0, 0, 0, time.UTC)
+	since := time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithLayout](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"day": "2024-03-01", "until": "2024-03-31"}`)
+	assert.NilError(t, err)
+	assert.Assert(t, found.Day.Equal(day))
+	assert.Assert(t, found.Until.Equal(until))
+	assert.Assert(t, found.Since.Equal(since))
+
+	// Inputs that do not follow the layout fall back to the driver.
+	found, err = deserializer.DeserializeString(`{"day": "2024-03-01T00:00:00Z", "until": "2024-03-31"}`)
+	assert.NilError(t, err)
+	assert.Assert(t, found.Day.Equal(day))
+
+	_, err = deserializer.DeserializeString(`{"day": "March 1st", "until": "2024-03-31"}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithLayout.day")
+
+	// Date-only query parameters.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithLayout](deserialize.QueryOptions("")) //nolint:exhaustruct
+	assert.NilError(t, err)
+	kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"day": []string{"2024-03-01"}, "until": []string{"2024-03-31"}, "since": []string{"Jan 1, 2000"}})
+	assert.NilError(t, err)
+	assert.Assert(t, kvFound.Day.Equal(day))
+	assert.Assert(t, kvFound.Until.Equal(until))
+	assert.Assert(t, kvFound.Since.Equal(since))
+
+	// The tag is checked when setting up the deserializer.
+	type InvalidLayout struct {
+		Day time.Time `json:"day" layout:"today"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidLayout](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidLayout.day, invalid `layout` value \"today\"")
+
+	type NotATime struct {
+		Day string `json:"day" layout:"2006-01-02"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotATime](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at NotATime.day, tag `layout` may only be used on time.Time, got string")
+}
+
+// ------ Test that `ValidateAt()` receives the path of the value.
+
+type PathAwareLine struct {
+	Quantity int `json:"quantity"`
+}
+
+func (l *PathAwareLine) ValidateAt(path string) error {
+	if l.Quantity <= 0 {
+		return fmt.Errorf("%s.quantity must be positive", path)
+	}
+	return nil
+}
+
+// `ValidateAt()` takes precedence over `Validate()`.
+func (l *PathAwareLine) Validate() error {
+	return errors.New("Validate() should not be called")
+}
+
+var _ validation.PathAwareValidator = &PathAwareLine{}
+
+type PathAwareSku string
+
+func (s *PathAwareSku) ValidateAt(path string) error {
+	if *s == "" {
+		return fmt.Errorf("empty sku at %s", path)
+	}
+	return nil
+}
+
+type PathAwareCart struct {
+	Lines []PathAwareLine `json:"lines"`
+	Sku   PathAwareSku    `json:"sku"`
+}
+
+func TestPathAwareValidator(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[PathAwareCart](deserialize.JSONOptions("cart"))
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"lines": [{"quantity": 1}, {"quantity": 2}], "sku": "abc"}`)
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"lines": [{"quantity": 1}, {"quantity": 0}], "sku": "abc"}`)
+	// The path is the path of the type, so indices are not specified.
+	assert.ErrorContains(t, err, "error while deserializing cart.PathAwareCart.lines[1]:\n\t * validation error at cart.PathAwareCart.lines[]:\n\t * cart.PathAwareCart.lines[].quantity must be positive")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"lines": [], "sku": ""}`)
+	assert.ErrorContains(t, err, "validation error at cart.PathAwareCart.sku:\n\t * empty sku at cart.PathAwareCart.sku")
+
+	// `validation.Validate` also passes the path.
+	err = validation.Validate(&PathAwareCart{Lines: []PathAwareLine{{Quantity: 0}}, Sku: "abc"})
+	assert.ErrorContains(t, err, "deserialize_test.PathAwareCart.Lines[0].quantity must be positive")
+}
+
+// ------ Test that `min` and `max` may reference the bag of values.
+
+type StructWithDynamicRanges struct {
+	PageSize int `json:"pageSize" min:"1" max:"$maxPageSize"`
+}
+
+func TestMinMaxFromValues(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithDynamicRanges](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 50}, map[string]any{"maxPageSize": 50})
+	assert.NilError(t, err)
+	assert.Equal(t, found.PageSize, 50)
+
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 51}, map[string]any{"maxPageSize": 50})
+	assert.ErrorContains(t, err, "validation error at StructWithDynamicRanges.pageSize:\n\t * expected a value <= 50, got 51")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// The bound may be provided with a different numeric type or as a string.
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 51}, map[string]any{"maxPageSize": uint8(100)})
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 51}, map[string]any{"maxPageSize": "10"})
+	assert.ErrorContains(t, err, "expected a value <= 10, got 51")
+
+	// Constant bounds still apply.
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 0}, map[string]any{"maxPageSize": 50})
+	assert.ErrorContains(t, err, "expected a value >= 1, got 0")
+
+	// The value must be provided and must be a number.
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 10}, map[string]any{})
+	assert.ErrorContains(t, err, "cannot resolve `max` value $maxPageSize, no such value was provided")
+	_, err = deserializer.DeserializeDict(jsonPkg.JSON{"pageSize": 10})
+	assert.ErrorContains(t, err, "cannot resolve `max` value $maxPageSize, no such value was provided")
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 10}, map[string]any{"maxPageSize": "many"})
+	assert.ErrorContains(t, err, "cannot resolve `max` value $maxPageSize, expected int, got many")
+
+	// The value must fit the type of the field, without truncation.
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 2}, map[string]any{"maxPageSize": 2.7})
+	assert.ErrorContains(t, err, "cannot resolve `max` value $maxPageSize, expected an integer, got 2.7")
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 2}, map[string]any{"maxPageSize": 1e20})
+	assert.ErrorContains(t, err, "cannot resolve `max` value $maxPageSize, 1e+20 is out of range for int")
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 2}, map[string]any{"maxPageSize": uint64(18446744073709551615)})
+	assert.ErrorContains(t, err, "cannot resolve `max` value $maxPageSize, 18446744073709551615 is out of range for int")
+	_, err = deserializer.DeserializeDictWithValues(jsonPkg.JSON{"pageSize": 2}, map[string]any{"maxPageSize": 3.0})
+	assert.NilError(t, err)
+
+	// References must be well-formed.
+	type BadReference struct {
+		Field int `max:"$max-page-size"`
+	}
+	_, err = deserialize.MakeMapDeserializer[BadReference](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `max` value $max-page-size, expected a number or a reference such as $name")
+}
+
+// ------ Test that `minlen`, `maxlen` and `pattern` tags are enforced.
+
+type StructWithStringConstraints struct {
+	Name     string `json:"name" query:"name" minlen:"1" maxlen:"5"`
+	Slug     string `json:"slug" query:"slug" pattern:"^[a-z]+(-[a-z]+){0,2}$"`
+	Nickname string `json:"nickname" query:"nickname" maxlen:"3" default:""`
+}
+
+func TestStringConstraints(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithStringConstraints](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"name": "héllo", "slug": "abc-def"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithStringConstraints{Name: "héllo", Slug: "abc-def"})
+
+	_, err = deserializer.DeserializeString(`{"name": "", "slug": "abc"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithStringConstraints.name:\n\t * expected at least 1 characters, got 0")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"name": "abcdef", "slug": "abc"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithStringConstraints.name:\n\t * expected at most 5 characters, got 6")
+
+	_, err = deserializer.DeserializeString(`{"name": "abc", "slug": "Abc"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithStringConstraints.slug:\n\t * expected a value matching ^[a-z]+(-[a-z]+){0,2}$, got \"Abc\"")
+
+	_, err = deserializer.DeserializeString(`{"name": "abc", "slug": "abc", "nickname": "abcd"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithStringConstraints.nickname")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithStringConstraints](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"name": []string{"abc"}, "slug": []string{"abc"}})
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"name": []string{"abc"}, "slug": []string{"a b"}})
+	assert.ErrorContains(t, err, "validation error at StructWithStringConstraints.slug")
+
+	// Invalid constraints are detected early.
+	type InvalidPattern struct {
+		Field string `pattern:"[a-z"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidPattern](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `pattern` value [a-z")
+
+	type InvalidLength struct {
+		Field string `minlen:"-1"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidLength](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `minlen` value -1")
+
+	type NotAString struct {
+		Field int `pattern:"^[0-9]+$"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAString](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `pattern` may only be used on strings")
+}
+
+// ------ Test that `check` tags are enforced.
+
+type StructWithChecks struct {
+	Card   string `json:"card" check:"luhn" default:"79927398713"`
+	ISBN   string `json:"isbn" check:"isbn" default:"080442957X"`
+	ISBN10 string `json:"isbn10" check:"isbn10" default:"0-306-40615-2"`
+	ISBN13 string `json:"isbn13" check:"isbn13" default:"978-0-306-40615-7"`
+	IBAN   string `json:"iban" check:"iban" default:"DE89370400440532013000"`
+}
+
+func TestFormatChecks(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithChecks](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// Valid values.
+	found, err := deserializer.DeserializeString(`{"card": "4111 1111 1111 1111", "isbn": "9780306406157", "iban": "GB82 WEST 1234 5698 7654 32"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithChecks{
+		Card:   "4111 1111 1111 1111",
+		ISBN:   "9780306406157",
+		ISBN10: "0-306-40615-2",
+		ISBN13: "978-0-306-40615-7",
+		IBAN:   "GB82 WEST 1234 5698 7654 32",
+	})
+
+	// Invalid values.
+	for _, example := range []struct {
+		source   string
+		expected string
+	}{
+		{`{"card": "4111111111111112"}`, "validation error at StructWithChecks.card:\n\t * failed check luhn\n\t * invalid checksum"},
+		{`{"card": "4111-abc"}`, "validation error at StructWithChecks.card:\n\t * failed check luhn"},
+		{`{"isbn": "0306406153"}`, "validation error at StructWithChecks.isbn:\n\t * failed check isbn\n\t * invalid checksum"},
+		{`{"isbn": "123"}`, "validation error at StructWithChecks.isbn:\n\t * failed check isbn\n\t * expected 13 digits, got 3"},
+		{`{"isbn10": "030640615X"}`, "validation error at StructWithChecks.isbn10:\n\t * failed check isbn10"},
+		{`{"isbn13": "9780306406158"}`, "validation error at StructWithChecks.isbn13:\n\t * failed check isbn13"},
+		{`{"iban": "GB82WEST12345698765433"}`, "validation error at StructWithChecks.iban:\n\t * failed check iban\n\t * invalid checksum"},
+		{`{"iban": "gb82west12345698765432"}`, "validation error at StructWithChecks.iban:\n\t * failed check iban\n\t * expected a country code"},
+	} {
+		_, err = deserializer.DeserializeString(example.source)
+		assert.ErrorContains(t, err, example.expected, example.source)
+		assert.Assert(t, errors.As(err, &validation.Error{}), example.source)
+	}
+
+	// Unknown checks are detected early.
+	type UnknownCheck struct {
+		Field string `check:"crc32"`
+	}
+	_, err = deserialize.MakeMapDeserializer[UnknownCheck](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `check` value \"crc32\"")
+}
+
+// ------ Test that we can deserialize JSON5.
+
+type JSON5Config struct {
+	Name    string            `json:"name"`
+	Port    int               `json:"port" default:"8080"`
+	Ratio   float64           `json:"ratio"`
+	ID      uuid.UUID         `json:"id"`
+	Tags    []string          `json:"tags"`
+	Backend Pair[string, int] `json:"backend"`
+}
+
+func TestJSON5(t *testing.T) {
+	json5Deserializer, err := deserialize.MakeMapDeserializer[JSON5Config](deserialize.JSON5Options(""))
+	assert.NilError(t, err)
+	jsonDeserializer, err := deserialize.MakeMapDeserializer[JSON5Config](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	id := uuid.New()
+	found, err := json5Deserializer.DeserializeString(fmt.Sprintf(`
+	// A comment.
+	{
+		name: 'server', /* unquoted keys, single quotes */
+		ratio: .5,
+		id: "%s",
+		tags: ["a", "b",], // Trailing comma.
+		backend: {
+			left: "localhost",
+			right: 0x10,
+		},
+	}`, id))
+	assert.NilError(t, err)
+	expected := JSON5Config{
+		Name:    "server",
+		Port:    8080,
+		Ratio:   0.5,
+		ID:      id,
+		Tags:    []string{"a", "b"},
+		Backend: Pair[string, int]{Left: "localhost", Right: 16},
+	}
+	assert.DeepEqual(t, *found, expected)
+
+	// Plain JSON is valid JSON5 and produces the same result.
+	serialized, err := json.Marshal(expected)
+	assert.NilError(t, err)
+	fromJSON, err := jsonDeserializer.DeserializeBytes(serialized)
+	assert.NilError(t, err)
+	fromJSON5, err := json5Deserializer.DeserializeBytes(serialized)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *fromJSON5, *fromJSON)
+	assert.DeepEqual(t, *fromJSON5, expected)
+
+	// Missing fields are still detected.
+	_, err = json5Deserializer.DeserializeString(`{name: 'server', ratio: 1, id: "` + id.String() + `", tags: [],}`)
+	assert.ErrorContains(t, err, "missing object value at JSON5Config.backend")
+
+	// Syntax errors are detected.
+	_, err = json5Deserializer.DeserializeString(`{name: }`)
+	assert.ErrorContains(t, err, "failed to deserialize source")
+}
+
+// ------ Test that `oneof` tags are enforced.
+
+type Color string
+
+type StructWithEnums struct {
+	Color    Color   `json:"color" query:"color" oneof:"red, green,blue"`
+	Level    uint8   `json:"level" query:"level" oneof:"1,2,3" default:"1"`
+	Priority float64 `json:"priority" query:"priority" oneof:"0.5,1"`
+}
+
+func TestOneOf(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithEnums](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"color": "green", "level": 3, "priority": 0.5}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithEnums{Color: "green", Level: 3, Priority: 0.5})
+
+	_, err = deserializer.DeserializeString(`{"color": "purple", "priority": 1}`)
+	assert.ErrorContains(t, err, "validation error at StructWithEnums.color:\n\t * expected one of red, green, blue, got purple")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"color": "red", "level": 4, "priority": 1}`)
+	assert.ErrorContains(t, err, "validation error at StructWithEnums.level:\n\t * expected one of 1, 2, 3, got 4")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithEnums](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	foundKV, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"color": []string{"blue"}, "level": []string{"2"}, "priority": []string{"1"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *foundKV, StructWithEnums{Color: "blue", Level: 2, Priority: 1})
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"color": []string{"blue"}, "priority": []string{"2"}})
+	assert.ErrorContains(t, err, "validation error at StructWithEnums.priority:\n\t * expected one of 0.5, 1, got 2")
+
+	// Typos are caught early.
+	type InvalidEnum struct {
+		Field uint8 `oneof:"1,2,three"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidEnum](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `oneof` value three for type uint8")
+
+	// Pointers and `Nullable` check the value they wrap.
+	type StructWithOptionalEnums struct {
+		Color  *string                      `json:"color" oneof:"red,green" default:"nil"`
+		Status deserialize.Nullable[string] `json:"status" oneof:"active,inactive" enumCase:"insensitive"`
+	}
+	optionalDeserializer, err := deserialize.MakeMapDeserializer[StructWithOptionalEnums](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	foundOptional, err := optionalDeserializer.DeserializeString(`{"color": "red", "status": "ACTIVE"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, *foundOptional.Color, "red")
+	assert.DeepEqual(t, foundOptional.Status, deserialize.Nullable[string]{Set: true, Null: false, Value: "active"})
+	foundOptional, err = optionalDeserializer.DeserializeString(`{"status": null}`)
+	assert.NilError(t, err)
+	assert.Assert(t, foundOptional.Color == nil)
+	_, err = optionalDeserializer.DeserializeString(`{"color": "purple"}`)
+	assert.ErrorContains(t, err, "expected one of red, green, got purple")
+	_, err = optionalDeserializer.DeserializeString(`{"status": "pending"}`)
+	assert.ErrorContains(t, err, "expected one of active, inactive, got pending")
+
+	// Other types cannot have a `oneof`.
+	type EnumSlice struct {
+		Field []string `oneof:"a,b"`
+	}
+	_, err = deserialize.MakeMapDeserializer[EnumSlice](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `oneof` may only be used on flat values, got []string")
+}
+
+// ------ Test that `enumCase:"insensitive"` matches `oneof` values regardless of case.
+
+type StructWithCaseInsensitiveEnum struct {
+	Status Color  `json:"status" query:"status" oneof:"active,inactive" enumCase:"insensitive"`
+	Code   string `json:"code" query:"code" oneof:"A,B" enumCase:"sensitive" default:"A"`
+}
+
+func TestEnumCase(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithCaseInsensitiveEnum](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// The canonical value is stored.
+	found, err := deserializer.DeserializeString(`{"status": "ACTIVE"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithCaseInsensitiveEnum{Status: "active", Code: "A"})
+
+	_, err = deserializer.DeserializeString(`{"status": "pending"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithCaseInsensitiveEnum.status:\n\t * expected one of active, inactive, got pending")
+
+	// Other fields remain case-sensitive.
+	_, err = deserializer.DeserializeString(`{"status": "Inactive", "code": "b"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithCaseInsensitiveEnum.code:\n\t * expected one of A, B, got b")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithCaseInsensitiveEnum](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	foundKV, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"status": []string{"InActive"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *foundKV, StructWithCaseInsensitiveEnum{Status: "inactive", Code: "A"})
+
+	// Misuses are caught early.
+	type EnumCaseWithoutOneOf struct {
+		Field string `enumCase:"insensitive"`
+	}
+	_, err = deserialize.MakeMapDeserializer[EnumCaseWithoutOneOf](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `enumCase` may only be used along with tag `oneof`")
+	type InvalidEnumCase struct {
+		Field string `oneof:"a,b" enumCase:"lower"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidEnumCase](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `enumCase` value lower, expected sensitive or insensitive")
+}
+
+// ------ Test that a context is passed to `ContextInitializer` and `ContextValidator`.
+
+type tenantKey struct{}
+
+type StructWithTenant struct {
+	Name   string `json:"name"`
+	tenant string
+}
+
+func (s *StructWithTenant) InitializeContext(ctx context.Context) error {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	if !ok {
+		return errors.New("missing tenant")
+	}
+	s.tenant = tenant
+	return nil
+}
+
+func (s *StructWithTenant) ValidateContext(ctx context.Context) error {
+	if s.Name == ctx.Value(tenantKey{}) {
+		return fmt.Errorf("name %s is reserved", s.Name)
+	}
+	return nil
+}
+
+// This method should never be called, as `ValidateContext` takes precedence.
+func (s *StructWithTenant) Validate() error {
+	return errors.New("Validate should not be called")
+}
+
+var _ validation.ContextInitializer = new(StructWithTenant)
+var _ validation.ContextValidator = new(StructWithTenant)
+
+type StructWithTenantContainer struct {
+	Inner StructWithTenant `json:"inner"`
+}
+
+func TestContext(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithTenant](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	found, err := deserializer.DeserializeDictContext(ctx, jsonPkg.JSON{"name": "Alice"})
+	assert.NilError(t, err)
+	assert.Equal(t, found.Name, "Alice")
+	assert.Equal(t, found.tenant, "acme")
+
+	found, err = deserializer.DeserializeBytesContext(ctx, []byte(`{"name": "Bob"}`))
+	assert.NilError(t, err)
+	assert.Equal(t, found.tenant, "acme")
+
+	_, err = deserializer.DeserializeDictContext(ctx, jsonPkg.JSON{"name": "acme"})
+	assert.ErrorContains(t, err, "validation error at StructWithTenant:\n\t * name acme is reserved")
+
+	// Without a context, we receive `context.Background()`.
+	_, err = deserializer.DeserializeDict(jsonPkg.JSON{"name": "Alice"})
+	assert.ErrorContains(t, err, "missing tenant")
+
+	// The context is passed at every depth.
+	containerDeserializer, err := deserialize.MakeMapDeserializer[StructWithTenantContainer](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	container, err := containerDeserializer.DeserializeDictContext(ctx, jsonPkg.JSON{"inner": map[string]any{"name": "Alice"}})
+	assert.NilError(t, err)
+	assert.Equal(t, container.Inner.tenant, "acme")
+
+	_, err = containerDeserializer.DeserializeDictContext(ctx, jsonPkg.JSON{"inner": map[string]any{"name": "acme"}})
+	assert.ErrorContains(t, err, "validation error at StructWithTenantContainer.inner:\n\t * name acme is reserved")
+}
+
+// ------ Test that `checksumOf` checks a checksum against its sibling fields.
+
+type ChecksummedPayment struct {
+	Amount   uint64 `json:"amount"`
+	Currency string `json:"currency"`
+	Checksum string `json:"checksum" checksumOf:"amount,currency" hash:"sha256"`
+}
+
+type ChecksummedReading struct {
+	Payload []byte `cbor:"payload"`
+	CRC     []byte `cbor:"crc" checksumOf:"payload" hash:"crc32"`
+}
+
+func TestChecksum(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[ChecksummedPayment](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	sum := sha256.Sum256([]byte("1250\nEUR"))
+	checksum := hex.EncodeToString(sum[:])
+	found, err := deserializer.DeserializeString(fmt.Sprintf(`{"amount": 1250, "currency": "EUR", "checksum": "%s"}`, checksum))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, ChecksummedPayment{Amount: 1250, Currency: "EUR", Checksum: checksum})
+
+	// Hex checksums are case-insensitive.
+	_, err = deserializer.DeserializeString(fmt.Sprintf(`{"amount": 1250, "currency": "EUR", "checksum": "%s"}`, strings.ToUpper(checksum)))
+	assert.NilError(t, err)
+
+	// Tampered payloads are rejected.
+	_, err = deserializer.DeserializeString(fmt.Sprintf(`{"amount": 9250, "currency": "EUR", "checksum": "%s"}`, checksum))
+	assert.ErrorContains(t, err, "validation error at ChecksummedPayment.checksum:\n\t * checksum mismatch")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// Raw checksums.
+	readingDeserializer, err := deserialize.MakeMapDeserializer[ChecksummedReading](deserialize.CBOROptions(""))
+	assert.NilError(t, err)
+	payload := []byte{0x01, 0x02, 0x03}
+	crc := binary.BigEndian.AppendUint32(nil, crc32.ChecksumIEEE(payload))
+	buf, err := cbor.Marshal(map[string]any{"payload": payload, "crc": crc})
+	assert.NilError(t, err)
+	reading, err := readingDeserializer.DeserializeBytes(buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, reading.CRC, crc)
+
+	buf, err = cbor.Marshal(map[string]any{"payload": []byte{0x01, 0x02, 0x04}, "crc": crc})
+	assert.NilError(t, err)
+	_, err = readingDeserializer.DeserializeBytes(buf)
+	assert.ErrorContains(t, err, "checksum mismatch")
+
+	// The tags are checked when setting up the deserializer.
+	type MissingHash struct {
+		Amount   uint64 `json:"amount"`
+		Checksum string `json:"checksum" checksumOf:"amount"`
+	}
+	_, err = deserialize.MakeMapDeserializer[MissingHash](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at MissingHash.checksum, tag `checksumOf` requires tag `hash`")
+
+	type UnknownHash struct {
+		Amount   uint64 `json:"amount"`
+		Checksum string `json:"checksum" checksumOf:"amount" hash:"sha3"`
+	}
+	_, err = deserialize.MakeMapDeserializer[UnknownHash](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `hash` value sha3, expected one of crc32, md5, sha1, sha256, sha512")
+
+	type UnknownField struct {
+		Checksum string `json:"checksum" checksumOf:"amount" hash:"sha256"`
+	}
+	_, err = deserialize.MakeMapDeserializer[UnknownField](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `checksumOf` value, there is no field amount in this struct")
+
+	type NotAString struct {
+		Amount   uint64 `json:"amount"`
+		Checksum int    `json:"checksum" checksumOf:"amount" hash:"sha256"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAString](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `checksumOf` may only be used on strings or []byte, got int")
+}
+
+// ------ Test that `enumWhen` constrains a field depending on its siblings.
+
+type Measure struct {
+	Unit  string `json:"unit" query:"unit"`
+	Value string `json:"value" query:"value" enumWhen:"unit=temp:[C,F,K]; unit=length:[m,km]"`
+}
+
+func TestEnumWhen(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[Measure](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"unit": "temp", "value": "K"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Measure{Unit: "temp", Value: "K"})
+
+	_, err = deserializer.DeserializeString(`{"unit": "length", "value": "km"}`)
+	assert.NilError(t, err)
+
+	// No rule for this unit, anything goes.
+	_, err = deserializer.DeserializeString(`{"unit": "weight", "value": "kg"}`)
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"unit": "temp", "value": "km"}`)
+	assert.ErrorContains(t, err, "validation error at Measure.value:\n\t * expected one of C, F, K when unit is temp, got km")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"unit": "length", "value": "C"}`)
+	assert.ErrorContains(t, err, "validation error at Measure.value:\n\t * expected one of m, km when unit is length, got C")
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[Measure](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"unit": []string{"temp"}, "value": []string{"F"}})
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"unit": []string{"temp"}, "value": []string{"m"}})
+	assert.ErrorContains(t, err, "expected one of C, F, K when unit is temp, got m")
+
+	// Rules are checked when setting up the deserializer.
+	type UnknownSibling struct {
+		Value string `enumWhen:"unit=temp:[C,F,K]"`
+	}
+	_, err = deserialize.MakeMapDeserializer[UnknownSibling](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `enumWhen` rule, there is no field unit in this struct")
+
+	type IllFormed struct {
+		Unit  string `json:"unit"`
+		Value string `json:"value" enumWhen:"unit=temp:C,F,K"`
+	}
+	_, err = deserialize.MakeMapDeserializer[IllFormed](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `enumWhen` rule \"unit=temp:C,F,K\", expected a list such as `[a,b,...]`")
+}
+
+// ------ Test that compiled deserializers are cached without mixing options.
+
+func TestCachedDeserializers(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		deserializer, err := deserialize.MakeMapDeserializer[SimpleStruct](deserialize.JSONOptions(""))
+		assert.NilError(t, err)
+		found, err := deserializer.DeserializeString(`{"SomeString": "abc", "unknown": 0}`)
+		assert.NilError(t, err)
+		assert.Equal(t, found.SomeString, "abc")
+
+		options := deserialize.JSONOptions("")
+		options.DisallowUnknownFields = true
+		strict, err := deserialize.MakeMapDeserializer[SimpleStruct](options)
+		assert.NilError(t, err)
+		_, err = strict.DeserializeString(`{"SomeString": "abc", "unknown": 0}`)
+		assert.ErrorContains(t, err, "unknown")
+
+		// Same type, different driver.
+		kvDeserializer, err := deserialize.MakeKVListDeserializer[SimpleStruct](deserialize.QueryOptions(""))
+		assert.NilError(t, err)
+		kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"SomeString": []string{"def"}})
+		assert.NilError(t, err)
+		assert.Equal(t, kvFound.SomeString, "def")
+
+		// Same type, different root path.
+		rooted, err := deserialize.MakeMapDeserializer[SimpleStruct](deserialize.JSONOptions("GET /root"))
+		assert.NilError(t, err)
+		_, err = rooted.DeserializeString(`{}`)
+		assert.ErrorContains(t, err, "GET /root.SimpleStruct")
+	}
+}
+
+// A driver with some configuration.
+type configuredDriver struct {
+	shared.Driver
+
+	// If `true`, reject every type.
+	reject bool
+}
+
+func (d configuredDriver) Enter(path string, typ reflect.Type) error {
+	if d.reject {
+		return fmt.Errorf("at %s, rejected by driver", path)
+	}
+	return d.Driver.Enter(path, typ) //nolint:wrapcheck
+}
+
+func TestCachedDeserializersCustomDriver(t *testing.T) {
+	// Two drivers with the same type but a different configuration don't share deserializers.
+	for _, reject := range []bool{false, true} {
+		options := deserialize.JSONOptions("")
+		options.Unmarshaler = func() shared.Driver {
+			return configuredDriver{Driver: jsonPkg.Driver(), reject: reject}
+		}
+		_, err := deserialize.MakeMapDeserializer[SimpleStruct](options)
+		if reject {
+			assert.ErrorContains(t, err, "rejected by driver")
+		} else {
+			assert.NilError(t, err)
+		}
+	}
+}
+
+func BenchmarkMakeMapDeserializer(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		_, err := deserialize.MakeMapDeserializer[PrimitiveTypesStruct](deserialize.JSONOptions(""))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeserializeFlatStruct(b *testing.B) {
+	deserializer, err := deserialize.MakeMapDeserializer[PrimitiveTypesStruct](deserialize.JSONOptions(""))
+	if err != nil {
+		b.Fatal(err)
+	}
+	dict := jsonPkg.JSON{
+		"SomeBool":    true,
+		"SomeString":  "abc",
+		"SomeFloat32": 1.5,
+		"SomeFloat64": 2.5,
+		"SomeInt":     -1,
+		"SomeInt8":    -8,
+		"SomeInt16":   -16,
+		"SomeInt32":   -32,
+		"SomeInt64":   -64,
+		"SomeUint8":   8,
+		"SomeUint16":  16,
+		"SomeUint32":  32,
+		"SomeUint64":  64,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := deserializer.DeserializeDict(dict)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDeserializeFlatStructPooled(b *testing.B) {
+	options := deserialize.JSONOptions("")
+	options.Pool = true
+	deserializer, err := deserialize.MakeMapDeserializer[PrimitiveTypesStruct](options)
+	if err != nil {
+		b.Fatal(err)
+	}
+	dict := jsonPkg.JSON{
+		"SomeBool":    true,
+		"SomeString":  "abc",
+		"SomeFloat32": 1.5,
+		"SomeFloat64": 2.5,
+		"SomeInt":     -1,
+		"SomeInt8":    -8,
+		"SomeInt16":   -16,
+		"SomeInt32":   -32,
+		"SomeInt64":   -64,
+		"SomeUint8":   8,
+		"SomeUint16":  16,
+		"SomeUint32":  32,
+		"SomeUint64":  64,
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			found, err := deserializer.DeserializeDictPooled(dict)
+			if err != nil {
+				b.Fatal(err)
+			}
+			found.Release()
+		}
+	})
+}
+
+// ------ Test that `Options.Pool` reuses top-level values.
+
+func TestPool(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.Pool = true
+	deserializer, err := deserialize.MakeMapDeserializer[Pair[int, []string]](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeBytesPooled([]byte(`{"left": 1, "right": ["a", "b"]}`))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found.Value, Pair[int, []string]{Left: 1, Right: []string{"a", "b"}})
+
+	// Values are zeroed when released.
+	value := found.Value
+	found.Release()
+	assert.Check(t, found.Value == nil)
+	assert.DeepEqual(t, *value, Pair[int, []string]{Left: 0, Right: nil})
+	found.Release() // Harmless.
+
+	found, err = deserializer.DeserializeDictPooled(jsonPkg.JSON{"left": 2, "right": []any{"c"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found.Value, Pair[int, []string]{Left: 2, Right: []string{"c"}})
+	found.Release()
+
+	// Errors are reported.
+	_, err = deserializer.DeserializeDictPooled(jsonPkg.JSON{"left": 3})
+	assert.ErrorContains(t, err, "missing value at Pair[int,[]string].right")
+	_, err = deserializer.DeserializeBytesPooled([]byte(`{`))
+	assert.ErrorContains(t, err, "failed to deserialize source")
+
+	// Without `Pool`, pooled calls still work but values are not reused.
+	unpooled, err := deserialize.MakeMapDeserializer[Pair[int, []string]](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err = unpooled.DeserializeDictPooled(jsonPkg.JSON{"left": 4, "right": []any{}})
+	assert.NilError(t, err)
+	value = found.Value
+	found.Release()
+	assert.Equal(t, value.Left, 4)
+}
+
+// ------ Test deserializing from several layers of dicts.
+
+type LayeredTLS struct {
+	Cert string `json:"cert"`
+	Key  string `json:"key"`
+}
+
+type LayeredConfig struct {
+	Host  string     `json:"host"`
+	Port  int        `json:"port"`
+	Debug bool       `json:"debug"`
+	TLS   LayeredTLS `json:"tls"`
+}
+
+func TestDeserializeLayered(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[LayeredConfig](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	flags := jsonPkg.JSON{"debug": true}
+	env := jsonPkg.JSON{"port": 9090, "tls": map[string]any{"cert": "env.pem", "key": "env.key"}}
+	defaults := jsonPkg.JSON{
+		"host":  "localhost",
+		"port":  8080,
+		"debug": false,
+		"tls":   map[string]any{"cert": "default.pem", "key": "default.key"},
+	}
+
+	found, err := deserializer.DeserializeLayered([]shared.Dict{flags, env, defaults})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, LayeredConfig{
+		Host:  "localhost",
+		Port:  9090,
+		Debug: true,
+		TLS:   LayeredTLS{Cert: "env.pem", Key: "env.key"},
+	})
+
+	// Nested structs are not merged: they are taken as a whole from the first layer.
+	env = jsonPkg.JSON{"tls": map[string]any{"cert": "env.pem"}}
+	_, err = deserializer.DeserializeLayered([]shared.Dict{flags, env, defaults})
+	assert.ErrorContains(t, err, "missing value at LayeredConfig.tls.key")
+
+	// Unknown fields are detected across all layers.
+	options := deserialize.JSONOptions("")
+	options.DisallowUnknownFields = true
+	strict, err := deserialize.MakeMapDeserializer[LayeredConfig](options)
+	assert.NilError(t, err)
+	_, err = strict.DeserializeLayered([]shared.Dict{flags, jsonPkg.JSON{"verbose": true}, defaults})
+	assert.ErrorContains(t, err, "unexpected field verbose at LayeredConfig")
+
+	// No layers at all.
+	_, err = deserializer.DeserializeLayered([]shared.Dict{})
+	assert.ErrorContains(t, err, "missing")
+}
+
+// ------ Test that `coerceEmpty` falls back to the default value.
+
+type StructWithCoerceEmpty struct {
+	Limit  int    `query:"limit" default:"20" coerceEmpty:"default"`
+	Offset int    `query:"offset" default:"0"`
+	Name   string `query:"name" default:"anonymous" coerceEmpty:"default"`
+}
+
+func TestCoerceEmpty(t *testing.T) {
+	deserializer, err := deserialize.MakeKVListDeserializer[StructWithCoerceEmpty](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeKVList(kvlist.KVList{"limit": []string{""}, "name": []string{""}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithCoerceEmpty{Limit: 20, Offset: 0, Name: "anonymous"})
+
+	found, err = deserializer.DeserializeKVList(kvlist.KVList{"limit": []string{"50"}, "name": []string{"bob"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithCoerceEmpty{Limit: 50, Offset: 0, Name: "bob"})
+
+	// Without `coerceEmpty`, an empty string is not a valid int.
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{"offset": []string{""}})
+	assert.ErrorContains(t, err, "invalid value at StructWithCoerceEmpty.offset")
+
+	// The tag is checked when setting up the deserializer.
+	type InvalidCoerceEmpty struct {
+		Limit int `coerceEmpty:"zero"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidCoerceEmpty](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `coerceEmpty` value zero, expected \"default\"")
+
+	type NotFlat struct {
+		Limits []int `coerceEmpty:"default"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotFlat](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `coerceEmpty` may only be used on flat values")
+}
+
+// ------ Test object literals as `default` for structs and maps.
+
+type ServerConfig struct {
+	Host    string `json:"host"`
+	Port    int    `json:"port"`
+	Timeout int    `json:"timeout" default:"30"`
+}
+
+type StructWithObjectDefaults struct {
+	Server ServerConfig      `json:"server" default:"{\"host\": \"localhost\", \"port\": 8080}"`
+	Labels map[string]string `json:"labels" default:"{\"env\": \"dev\"}"`
+}
+
+func TestObjectDefaults(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithObjectDefaults](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithObjectDefaults{
+		Server: ServerConfig{Host: "localhost", Port: 8080, Timeout: 30},
+		Labels: map[string]string{"env": "dev"},
+	})
+
+	// Provided values replace the default value entirely.
+	found, err = deserializer.DeserializeString(`{"server": {"host": "example.com", "port": 443}, "labels": {}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithObjectDefaults{
+		Server: ServerConfig{Host: "example.com", Port: 443, Timeout: 30},
+		Labels: map[string]string{},
+	})
+
+	// Deserializing twice doesn't share the default value.
+	found.Labels["env"] = "prod"
+	found, err = deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Labels["env"], "dev")
+
+	// Invalid defaults are rejected when setting up the deserializer.
+	type InvalidJSONDefault struct {
+		Server ServerConfig `json:"server" default:"{host: localhost"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidJSONDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidJSONDefault.server, invalid `default` value, expected an object, got: {host: localhost")
+
+	type NotAnObjectDefault struct {
+		Labels map[string]string `json:"labels" default:"[1, 2]"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAnObjectDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `default` value, expected an object, got: [1, 2]")
+
+	type MismatchedDefault struct {
+		Server ServerConfig `json:"server" default:"{\"host\": \"localhost\", \"port\": \"abc\"}"`
+	}
+	_, err = deserialize.MakeMapDeserializer[MismatchedDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at MismatchedDefault.server, invalid `default` value, cannot deserialize object")
+	assert.ErrorContains(t, err, "invalid value at MismatchedDefault.server.port, expected int, got abc")
+
+	type MismatchedPointerDefault struct {
+		Labels *map[string]int `json:"labels" default:"{\"a\": \"b\"}"`
+	}
+	_, err = deserialize.MakeMapDeserializer[MismatchedPointerDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at MismatchedPointerDefault.labels, invalid `default` value, cannot deserialize object")
+
+	// Drivers that cannot parse object literals only support `{}`.
+	type QueryWithObjectDefault struct {
+		Server ServerConfig `query:"server" default:"{\"host\": \"localhost\"}"`
+	}
+	_, err = deserialize.MakeKVListDeserializer[QueryWithObjectDefault](deserialize.QueryOptions(""))
+	assert.ErrorContains(t, err, "at QueryWithObjectDefault.server, invalid `default` value, driver *kvlist.driver does not support object literals, only `{}`")
+}
+
+// ------ Test that `atLeastOne` requires one of several keys in a nested object.
+
+type ContactInfo struct {
+	Email *string `json:"email" default:"nil"`
+	Phone *string `json:"phone" default:"nil"`
+}
+
+type StructWithContact struct {
+	Name    string      `json:"name"`
+	Contact ContactInfo `json:"contact" atLeastOne:"email,phone"`
+}
+
+func TestAtLeastOne(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithContact](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"name": "alice", "contact": {"phone": "555-1234"}}`)
+	assert.NilError(t, err)
+	assert.Assert(t, found.Contact.Email == nil)
+	assert.Equal(t, *found.Contact.Phone, "555-1234")
+
+	_, err = deserializer.DeserializeString(`{"name": "alice", "contact": {"email": "alice@example.com", "phone": "555-1234"}}`)
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"name": "alice", "contact": {}}`)
+	assert.ErrorContains(t, err, "missing value at StructWithContact.contact, expected at least one of email, phone")
+	missingErr := deserialize.MissingFieldError{}
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "StructWithContact.contact")
+
+	// The tag is checked when setting up the deserializer.
+	type UnknownKey struct {
+		Contact ContactInfo `json:"contact" atLeastOne:"email,fax"`
+	}
+	_, err = deserialize.MakeMapDeserializer[UnknownKey](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `atLeastOne` value, there is no field fax in this struct")
+
+	type NotAStruct struct {
+		Contact map[string]string `json:"contact" atLeastOne:"email,phone"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAStruct](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `atLeastOne` may only be used on structs")
+}
+
+// ------ Test that `atLeast` requires a number of keys in a nested object.
+
+type ProfilePatch struct {
+	Name  *string `json:"name" default:"nil"`
+	Email *string `json:"email" default:"nil"`
+	Phone *string `json:"phone" default:"nil"`
+}
+
+type StructWithProfilePatch struct {
+	Patch ProfilePatch `json:"patch" atLeast:"2"`
+}
+
+func TestAtLeast(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithProfilePatch](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"patch": {"name": "alice", "phone": "555-1234"}}`)
+	assert.NilError(t, err)
+	assert.Equal(t, *found.Patch.Name, "alice")
+	assert.Assert(t, found.Patch.Email == nil)
+
+	_, err = deserializer.DeserializeString(`{"patch": {"name": "alice", "email": "alice@example.com", "phone": "555-1234"}}`)
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"patch": {"name": "alice"}}`)
+	assert.ErrorContains(t, err, "missing value at StructWithProfilePatch.patch, expected at least 2 fields")
+	missingErr := deserialize.MissingFieldError{}
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "StructWithProfilePatch.patch")
+
+	// Unknown keys do not count.
+	_, err = deserializer.DeserializeString(`{"patch": {"name": "alice", "fax": "555-0000"}}`)
+	assert.ErrorContains(t, err, "expected at least 2 fields")
+
+	// The tag is checked when setting up the deserializer.
+	type TooMany struct {
+		Patch ProfilePatch `json:"patch" atLeast:"4"`
+	}
+	_, err = deserialize.MakeMapDeserializer[TooMany](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `atLeast` value 4, struct ProfilePatch only has 3 fields")
+
+	type NotANumber struct {
+		Patch ProfilePatch `json:"patch" atLeast:"some"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotANumber](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `atLeast` value some, expected a positive integer")
+
+	type NotAStruct struct {
+		Patch map[string]string `json:"patch" atLeast:"2"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAStruct](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `atLeast` may only be used on structs")
+}
+
+// ------ Test array literals as `default` for slices and arrays.
+
+type StructWithListDefaults struct {
+	Ports   []int          `json:"ports" default:"[80, 443]"`
+	Servers []ServerConfig `json:"servers" default:"[{\"host\": \"a\", \"port\": 1}, {\"host\": \"b\", \"port\": 2}]"`
+	RGB     [3]uint8       `json:"rgb" default:"[255, 0, 0]"`
+}
+
+func TestListDefaults(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithListDefaults](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithListDefaults{
+		Ports: []int{80, 443},
+		Servers: []ServerConfig{
+			{Host: "a", Port: 1, Timeout: 30},
+			{Host: "b", Port: 2, Timeout: 30},
+		},
+		RGB: [3]uint8{255, 0, 0},
+	})
+
+	// Deserializing twice doesn't share the default value.
+	found.Ports[0] = 8080
+	found, err = deserializer.DeserializeString(`{"rgb": [0, 0, 255]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Ports, []int{80, 443})
+	assert.Equal(t, found.RGB, [3]uint8{0, 0, 255})
+
+	// Invalid defaults are rejected when setting up the deserializer.
+	type InvalidElement struct {
+		Ports []uint8 `json:"ports" default:"[80, \"https\"]"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidElement](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidElement.ports, invalid `default` value, cannot deserialize element 1")
+
+	type InvalidLength struct {
+		RGB [3]uint8 `json:"rgb" default:"[255, 0]"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidLength](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidLength.rgb, invalid `default` value, expecting 3 elements, got 2")
+
+	type NotAList struct {
+		Ports []int `json:"ports" default:"{\"a\": 1}"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAList](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `default` value, expected an array")
+}
+
+// ------ Test that `unique` detects duplicate elements.
+
+type StructWithUniqueLists struct {
+	Tags   []string `json:"tags" query:"tags" unique:"drop"`
+	Labels []string `json:"labels" query:"labels" unique:"error" default:"[]"`
+}
+
+func TestUnique(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithUniqueLists](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"tags": ["a", "b", "a", "c", "b"], "labels": ["x", "y"]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithUniqueLists{Tags: []string{"a", "b", "c"}, Labels: []string{"x", "y"}})
+
+	_, err = deserializer.DeserializeString(`{"tags": [], "labels": ["x", "y", "x"]}`)
+	assert.ErrorContains(t, err, "validation error at StructWithUniqueLists.labels:\n\t * duplicate value x")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// Same thing with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithUniqueLists](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"tags": []string{"a", "a"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, kvFound.Tags, []string{"a"})
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"tags": []string{"a"}, "labels": []string{"x", "x"}})
+	assert.ErrorContains(t, err, "duplicate value x")
+
+	// The tag is checked when setting up the deserializer.
+	type NotComparable struct {
+		Lists [][]string `json:"lists" unique:"error"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotComparable](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `unique` cannot be used with type [][]string as elements cannot be compared")
+
+	type InvalidMode struct {
+		Tags []string `json:"tags" unique:"sometimes"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidMode](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `unique` value sometimes, expected \"error\" or \"drop\"")
+
+	type DropFromArray struct {
+		Tags [2]string `json:"tags" unique:"drop"`
+	}
+	_, err = deserialize.MakeMapDeserializer[DropFromArray](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "`unique:\"drop\"` cannot be used on arrays")
+
+	type NotAList struct {
+		Tag string `json:"tag" unique:"error"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAList](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `unique` may only be used on slices or arrays")
+}
+
+// ------ Test that `uniqueBy` detects elements with the same field.
+
+type UniqueByUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type StructWithUniqueByList struct {
+	Users    []UniqueByUser  `json:"users" uniqueBy:"Email"`
+	Managers []*UniqueByUser `json:"managers" uniqueBy:"Email" default:"[]"`
+}
+
+func TestUniqueBy(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithUniqueByList](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"users": [{"name": "Alice", "email": "alice@example.com"}, {"name": "Alice", "email": "alice2@example.com"}]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithUniqueByList{
+		Users: []UniqueByUser{
+			{Name: "Alice", Email: "alice@example.com"},
+			{Name: "Alice", Email: "alice2@example.com"},
+		},
+		Managers: []*UniqueByUser{},
+	})
+
+	_, err = deserializer.DeserializeString(`{"users": [{"name": "Alice", "email": "alice@example.com"}, {"name": "Bob", "email": "bob@example.com"}, {"name": "Eve", "email": "alice@example.com"}]}`)
+	assert.ErrorContains(t, err, "validation error at StructWithUniqueByList.users[2].email:\n\t * duplicate value alice@example.com, already used at index 0")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"users": [], "managers": [{"name": "Bob", "email": "bob@example.com"}, {"name": "Bob", "email": "bob@example.com"}]}`)
+	assert.ErrorContains(t, err, "validation error at StructWithUniqueByList.managers[1].email")
+
+	// The tag is checked when setting up the deserializer.
+	type NoSuchField struct {
+		Users []UniqueByUser `json:"users" uniqueBy:"Phone"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NoSuchField](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at NoSuchField.users, invalid `uniqueBy` value Phone, struct UniqueByUser has no such field")
+
+	type NotStructs struct {
+		Emails []string `json:"emails" uniqueBy:"Email"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotStructs](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `uniqueBy` may only be used on slices of structs")
+}
+
+// ------ Test that `defaultEnv` reads default values from the environment.
+
+type StructWithDefaultEnv struct {
+	Port    uint16  `json:"port" defaultEnv:"GODASSE_TEST_PORT" default:"8080"`
+	Host    string  `json:"host" defaultEnv:"GODASSE_TEST_HOST"`
+	Timeout *uint32 `json:"timeout" defaultEnv:"GODASSE_TEST_TIMEOUT" default:"nil"`
+}
+
+func TestDefaultEnv(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithDefaultEnv](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	t.Setenv("GODASSE_TEST_HOST", "example.com")
+
+	// Variables unset, fall back to `default`.
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithDefaultEnv{Port: 8080, Host: "example.com", Timeout: nil})
+
+	// Variables set.
+	t.Setenv("GODASSE_TEST_PORT", "443")
+	t.Setenv("GODASSE_TEST_TIMEOUT", "30")
+	found, err = deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	timeout := uint32(30)
+	assert.DeepEqual(t, *found, StructWithDefaultEnv{Port: 443, Host: "example.com", Timeout: &timeout})
+
+	// Provided values take precedence.
+	found, err = deserializer.DeserializeString(`{"port": 80, "host": "localhost", "timeout": 5}`)
+	assert.NilError(t, err)
+	timeout = 5
+	assert.DeepEqual(t, *found, StructWithDefaultEnv{Port: 80, Host: "localhost", Timeout: &timeout})
+
+	// Invalid content.
+	t.Setenv("GODASSE_TEST_PORT", "not a port")
+	_, err = deserializer.DeserializeString(`{"host": "localhost", "timeout": 5}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithDefaultEnv.port, environment variable GODASSE_TEST_PORT should be a uint16")
+
+	// Variable unset and no `default`.
+	assert.NilError(t, os.Unsetenv("GODASSE_TEST_HOST"))
+	_, err = deserializer.DeserializeString(`{"port": 80, "timeout": 5}`)
+	assert.ErrorContains(t, err, "missing value at StructWithDefaultEnv.host")
+
+	// The tag is checked when setting up the deserializer.
+	type NotFlat struct {
+		Hosts []string `json:"hosts" defaultEnv:"GODASSE_TEST_HOSTS"`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotFlat](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `defaultEnv` may only be used on flat values")
+
+	type Required struct {
+		Host string `json:"host" defaultEnv:"GODASSE_TEST_HOST" required:""`
+	}
+	_, err = deserialize.MakeMapDeserializer[Required](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "is `required` but also has a `defaultEnv` declaration")
+}
+
+// ------ Test that fields may be decoded with a codec.
+
+// An amount of money, in cents, provided as a string such as "12.34 EUR".
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+type moneyCodec struct{}
+
+func (moneyCodec) Decode(value shared.Value, typ reflect.Type) (reflect.Value, error) {
+	if value == nil {
+		return reflect.Value{}, errors.New("missing amount")
+	}
+	source, ok := value.Interface().(string)
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("expected a string, got %v", value.Interface())
+	}
+	var units, cents int64
+	var currency string
+	_, err := fmt.Sscanf(source, "%d.%d %s", &units, &cents, &currency)
+	if err != nil {
+		return reflect.Value{}, err //nolint:wrapcheck
+	}
+	return reflect.ValueOf(Money{Cents: units*100 + cents, Currency: currency}).Convert(typ), nil
+}
+
+type brokenCodec struct{}
+
+func (brokenCodec) Decode(shared.Value, reflect.Type) (reflect.Value, error) {
+	return reflect.ValueOf("not money"), nil
+}
+
+type StructWithCodec struct {
+	Price    Money `json:"price" codec:"money"`
+	Quantity int   `json:"quantity" default:"1"`
+}
+
+func TestCodec(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.Codecs = map[string]shared.Codec{
+		"money": moneyCodec{},
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithCodec](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"price": "12.34 EUR"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithCodec{Price: Money{Cents: 1234, Currency: "EUR"}, Quantity: 1})
+
+	// The codec decides how to handle missing values.
+	_, err = deserializer.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "at StructWithCodec.price, expected to be able to decode a Money with codec money")
+	assert.ErrorContains(t, err, "missing amount")
+
+	_, err = deserializer.DeserializeString(`{"price": 12}`)
+	assert.ErrorContains(t, err, "expected a string, got 12")
+
+	// The codec must produce values of the expected type.
+	options.Codecs["money"] = brokenCodec{}
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithCodec](options)
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeString(`{"price": "12.34 EUR"}`)
+	assert.ErrorContains(t, err, "at StructWithCodec.price, codec money did not produce a Money")
+
+	// Unknown codecs are detected early.
+	_, err = deserialize.MakeMapDeserializer[StructWithCodec](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "unknown codec money")
+
+	// Codecs conflict with `default`.
+	type Conflict struct {
+		Price Money `json:"price" codec:"money" default:"0.00 EUR"`
+	}
+	_, err = deserialize.MakeMapDeserializer[Conflict](options)
+	assert.ErrorContains(t, err, "it cannot have a `default` or `orMethod`")
+}
+
+// ------ Test that `orMethod` may take the struct being deserialized as argument.
+
+type Booking struct {
+	StartDate int64  `json:"start"`
+	EndDate   int64  `json:"end" orMethod:"DefaultEndDate"`
+	Label     string `json:"label" orMethod:"DefaultLabel"`
+}
+
+// By default, a booking lasts one hour.
+func (Booking) DefaultEndDate(self *Booking) (int64, error) {
+	return self.StartDate + 3600, nil
+}
+
+func (Booking) DefaultLabel() (string, error) {
+	return "booking", nil
+}
+
+type BookingList struct {
+	Bookings []Booking `json:"bookings"`
+	Main     Booking   `json:"main"`
+}
+
+func TestOrMethodSelf(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[Booking](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"start": 1000}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Booking{StartDate: 1000, EndDate: 4600, Label: "booking"})
+
+	found, err = deserializer.DeserializeString(`{"start": 1000, "end": 2000, "label": "meeting"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Booking{StartDate: 1000, EndDate: 2000, Label: "meeting"})
+
+	// Nested structs see their own fields.
+	listDeserializer, err := deserialize.MakeMapDeserializer[BookingList](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	list, err := listDeserializer.DeserializeString(`{"bookings": [{"start": 10}, {"start": 20, "end": 30}], "main": {"start": 5}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *list, BookingList{
+		Bookings: []Booking{
+			{StartDate: 10, EndDate: 3610, Label: "booking"},
+			{StartDate: 20, EndDate: 30, Label: "booking"},
+		},
+		Main: Booking{StartDate: 5, EndDate: 3605, Label: "booking"},
+	})
+}
+
+// ------ Test that KVList deserializers may deserialize lists from indexed keys.
+
+type QueryItem struct {
+	Name     string `query:"name"`
+	Quantity int    `query:"quantity" default:"1"`
+}
+
+func TestKVListSlice(t *testing.T) {
+	deserializer, err := deserialize.MakeKVListDeserializer[QueryItem](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+
+	// Sparse and unordered indices, other keys are ignored.
+	found, err := deserializer.DeserializeKVListSlice(kvlist.KVList{
+		"item[10].name":    []string{"c"},
+		"item[2].name":     []string{"b"},
+		"item[2].quantity": []string{"5"},
+		"item[0].name":     []string{"a"},
+		"page":             []string{"3"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found, []QueryItem{
+		{Name: "a", Quantity: 1},
+		{Name: "b", Quantity: 5},
+		{Name: "c", Quantity: 1},
+	})
+
+	found, err = deserializer.DeserializeKVListSlice(kvlist.KVList{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found, []QueryItem{})
+
+	// Errors mention the original index.
+	_, err = deserializer.DeserializeKVListSlice(kvlist.KVList{
+		"item[0].name":     []string{"a"},
+		"item[3].quantity": []string{"5"},
+	})
+	assert.ErrorContains(t, err, "failed to deserialize entry 3")
+	assert.ErrorContains(t, err, "missing value at QueryItem.name")
+
+	_, err = deserializer.DeserializeKVListSlice(kvlist.KVList{
+		"item[0].name":  []string{"a"},
+		"other[1].name": []string{"b"},
+	})
+	assert.ErrorContains(t, err, `cannot mix indexed keys with prefixes "item" and "other"`)
+}
+
+// ------ Test that pointers to structs and maps may default to an object.
+
+type StructWithPointerObjectDefaults struct {
+	Server   *ServerConfig      `json:"server" default:"{}"`
+	Fallback *ServerConfig      `json:"fallback" default:"{\"host\": \"localhost\", \"port\": 8080}"`
+	Labels   *map[string]string `json:"labels" default:"{}"`
+	Optional *ServerConfig      `json:"optional" default:"nil"`
+}
+
+func TestPointerObjectDefaults(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithPointerObjectDefaults](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// `ServerConfig` requires `host` and `port`, so `{}` is not enough.
+	_, err = deserializer.DeserializeString(`{"fallback": {"host": "example.com", "port": 80}}`)
+	assert.ErrorContains(t, err, "missing value at StructWithPointerObjectDefaults.server*.")
+
+	found, err := deserializer.DeserializeString(`{"server": {"host": "example.com", "port": 443}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithPointerObjectDefaults{
+		Server:   &ServerConfig{Host: "example.com", Port: 443, Timeout: 30},
+		Fallback: &ServerConfig{Host: "localhost", Port: 8080, Timeout: 30},
+		Labels:   &map[string]string{},
+		Optional: nil,
+	})
+
+	// Other defaults are rejected.
+	type InvalidDefault struct {
+		Server *ServerConfig `json:"server" default:"localhost"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "invalid `default` value, expected an object")
+
+	type InvalidScalarDefault struct {
+		Port *int `json:"port" default:"{}"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidScalarDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "The only supported `default` value for pointers is \"nil\"")
+}
+
+// ------ Test that a pointer to a fully defaulted struct may default to `{}`.
+
+type Limits struct {
+	MaxConnections int `json:"maxConnections" default:"100"`
+	TimeoutMS      int `json:"timeoutMS" default:"5000"`
+}
+
+type StructWithDefaultedPointer struct {
+	Limits *Limits `json:"limits" default:"{}"`
+}
+
+func TestPointerDefaultedStruct(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithDefaultedPointer](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.Assert(t, found.Limits != nil)
+	assert.DeepEqual(t, *found.Limits, Limits{MaxConnections: 100, TimeoutMS: 5000})
+
+	found, err = deserializer.DeserializeString(`{"limits": {"timeoutMS": 10}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found.Limits, Limits{MaxConnections: 100, TimeoutMS: 10})
+}
+
+// ------ Test that all the constraints violated by a field are reported together.
+
+type StructWithSeveralConstraints struct {
+	Code  string `json:"code" minlen:"4" pattern:"^[A-Z]+$" oneof:"ABCD,EFGH"`
+	Level int    `json:"level" max:"10" oneof:"1,5,10"`
+}
+
+func TestSeveralViolations(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithSeveralConstraints](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"code": "ab", "level": 5}`)
+	assert.ErrorContains(t, err, "validation error at StructWithSeveralConstraints.code:\n\t * expected at least 4 characters, got 2\n\t * expected a value matching ^[A-Z]+$, got \"ab\"\n\t * expected one of")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"code": "ABCD", "level": 11}`)
+	assert.ErrorContains(t, err, "validation error at StructWithSeveralConstraints.level:\n\t * expected a value <= 10, got 11\n\t * expected one of")
+
+	// A single violation is reported as before.
+	_, err = deserializer.DeserializeString(`{"code": "ABCDE", "level": 5}`)
+	assert.ErrorContains(t, err, "validation error at StructWithSeveralConstraints.code:\n\t * expected one of")
+}
+
+// ------ Test that KVList deserializers support nested structs through dotted keys.
+
+type QueryAddress struct {
+	City    string `query:"city"`
+	Country string `query:"country" default:"FR"`
+}
+
+type QueryGeo struct {
+	Lat float64 `query:"lat"`
+	Lng float64 `query:"lng"`
+}
+
+type QueryLocation struct {
+	Address QueryAddress `query:"address"`
+	Geo     *QueryGeo    `query:"geo" default:"nil"`
+}
+
+type QueryWithNested struct {
+	Name     string        `query:"name"`
+	Tags     []string      `query:"tag"`
+	Location QueryLocation `query:"location" default:"{}"`
+}
+
+func TestKVListNested(t *testing.T) {
+	deserializer, err := deserialize.MakeKVListDeserializer[QueryWithNested](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeKVList(kvlist.KVList{
+		"name":                  []string{"home"},
+		"tag":                   []string{"a", "b"},
+		"location.address.city": []string{"Paris"},
+		"location.geo.lat":      []string{"48.85"},
+		"location.geo.lng":      []string{"2.35"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, QueryWithNested{
+		Name: "home",
+		Tags: []string{"a", "b"},
+		Location: QueryLocation{
+			Address: QueryAddress{City: "Paris", Country: "FR"},
+			Geo:     &QueryGeo{Lat: 48.85, Lng: 2.35},
+		},
+	})
+
+	// Missing nested values.
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{
+		"name": []string{"home"},
+		"tag":  []string{},
+	})
+	assert.ErrorContains(t, err, "missing object value at QueryWithNested.location.address")
+
+	// A key may not be both a value and a prefix.
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{
+		"name":                  []string{"home"},
+		"location.address":      []string{"Paris"},
+		"location.address.city": []string{"Paris"},
+	})
+	assert.ErrorContains(t, err, "key address is used both as a value and as a prefix in field QueryLocation.Address")
+
+	// A value where we expect an object.
+	_, err = deserializer.DeserializeKVList(kvlist.KVList{
+		"name":             []string{"home"},
+		"location.address": []string{"Paris"},
+	})
+	assert.ErrorContains(t, err, "invalid value at QueryWithNested.location.address")
+}
+
+// ------ Test that structs may be read from delimited strings with tag `split`.
+
+type Coordinates struct {
+	Lat float64 `json:"lat" query:"lat"`
+	Lng float64 `json:"lng" query:"lng"`
+}
+
+type StructWithSplit struct {
+	Position Coordinates `json:"position" query:"position" split:","`
+}
+
+func TestSplit(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithSplit](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"position": "12.3,45.6"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithSplit{Position: Coordinates{Lat: 12.3, Lng: 45.6}})
+
+	// Objects are still accepted.
+	found, err = deserializer.DeserializeString(`{"position": {"lat": 1.5, "lng": 2.5}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithSplit{Position: Coordinates{Lat: 1.5, Lng: 2.5}})
+
+	_, err = deserializer.DeserializeString(`{"position": "12.3,45.6,7"}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithSplit.position, expected 2 parts separated by \",\", got 3")
+
+	_, err = deserializer.DeserializeString(`{"position": "12.3,north"}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithSplit.position.lng")
+
+	// Also works with query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithSplit](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	found, err = kvDeserializer.DeserializeKVList(kvlist.KVList{"position": []string{"12.3,45.6"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithSplit{Position: Coordinates{Lat: 12.3, Lng: 45.6}})
+
+	// The tag is checked when setting up the deserializer.
+	type NotAStruct struct {
+		Position string `json:"position" split:","`
+	}
+	_, err = deserialize.MakeMapDeserializer[NotAStruct](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `split` may only be used on structs")
+}
+
+// ------ Test that query strings may split single values into lists.
+
+type QueryWithLists struct {
+	Tags  []string `query:"tags"`
+	RGB   [3]uint8 `query:"rgb"`
+	Title string   `query:"title"`
+}
+
+func TestQueryListSeparator(t *testing.T) {
+	options := deserialize.QueryOptions("")
+	options.ListSeparator = ","
+	deserializer, err := deserialize.MakeKVListDeserializer[QueryWithLists](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeKVList(kvlist.KVList{
+		"tags":  []string{"a,b,c"},
+		"rgb":   []string{"255,128,0"},
+		"title": []string{"hello, world"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, QueryWithLists{
+		Tags:  []string{"a", "b", "c"},
+		RGB:   [3]uint8{255, 128, 0},
+		Title: "hello, world",
+	})
+
+	// Repeated keys are not split.
+	found, err = deserializer.DeserializeKVList(kvlist.KVList{
+		"tags":  []string{"a,b", "c"},
+		"rgb":   []string{"1", "2", "3"},
+		"title": []string{""},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Tags, []string{"a,b", "c"})
+	assert.DeepEqual(t, found.RGB, [3]uint8{1, 2, 3})
+
+	// By default, values are not split.
+	deserializer, err = deserialize.MakeKVListDeserializer[QueryWithLists](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeKVList(kvlist.KVList{
+		"tags":  []string{"a,b,c"},
+		"rgb":   []string{"1", "2", "3"},
+		"title": []string{""},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, found.Tags, []string{"a,b,c"})
+}
+
+// ------ Test that numbers that do not fit in the destination type are rejected.
+
+type StructWithSmallNumbers struct {
+	Small  int8    `json:"small" query:"small"`
+	Count  uint16  `json:"count" query:"count"`
+	ID     int64   `json:"id" query:"id"`
+	Weight float32 `json:"weight" query:"weight"`
+}
+
+func TestNumericOverflow(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithSmallNumbers](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"small": -128, "count": 65535, "id": 9007199254740992, "weight": 1.5}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithSmallNumbers{Small: -128, Count: 65535, ID: 9007199254740992, Weight: 1.5})
+
+	_, err = deserializer.DeserializeString(`{"small": 300, "count": 1, "id": 1, "weight": 1}`)
+	assert.ErrorContains(t, err, "value 300 out of range for int8 at StructWithSmallNumbers.small")
+	mismatchErr := deserialize.TypeMismatchError{}
+	assert.Assert(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, mismatchErr.Path, "StructWithSmallNumbers.small")
+	assert.Equal(t, mismatchErr.Expected, "int8")
+
+	_, err = deserializer.DeserializeString(`{"small": 1, "count": -1, "id": 1, "weight": 1}`)
+	assert.ErrorContains(t, err, "value -1 out of range for uint16 at StructWithSmallNumbers.count")
+
+	_, err = deserializer.DeserializeString(`{"small": 1, "count": 1, "id": 1e20, "weight": 1}`)
+	assert.ErrorContains(t, err, "out of range for int64 at StructWithSmallNumbers.id")
+
+	_, err = deserializer.DeserializeString(`{"small": 1, "count": 1, "id": 1, "weight": 1e39}`)
+	assert.ErrorContains(t, err, "out of range for float32 at StructWithSmallNumbers.weight")
+
+	// Numbers provided as strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithSmallNumbers](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = kvDeserializer.DeserializeKVList(kvlist.KVList{
+		"small":  []string{"300"},
+		"count":  []string{"1"},
+		"id":     []string{"1"},
+		"weight": []string{"1"},
+	})
+	assert.ErrorContains(t, err, "value 300 out of range for int8 at StructWithSmallNumbers.small")
+}
+
+// ------ Test that defaults may be specific to a format.
+
+type PageRequest struct {
+	Limit  int    `json:"limit" query:"limit" default:"10" queryDefault:"25"`
+	Offset int    `json:"offset" query:"offset" default:"0"`
+	Sort   string `json:"sort" query:"sort" queryDefault:"name,asc"`
+}
+
+func TestScopedDefaults(t *testing.T) {
+	jsonDeserializer, err := deserialize.MakeMapDeserializer[PageRequest](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := jsonDeserializer.DeserializeString(`{"sort": "date"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, PageRequest{Limit: 10, Offset: 0, Sort: "date"})
+
+	// `queryDefault` does not apply to JSON.
+	_, err = jsonDeserializer.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "missing value at PageRequest.sort")
+
+	queryDeserializer, err := deserialize.MakeKVListDeserializer[PageRequest](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	found, err = queryDeserializer.DeserializeKVList(kvlist.KVList{})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, PageRequest{Limit: 25, Offset: 0, Sort: "name,asc"})
+
+	found, err = queryDeserializer.DeserializeKVList(kvlist.KVList{"limit": []string{"5"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, PageRequest{Limit: 5, Offset: 0, Sort: "name,asc"})
+}
+
+// ------ Test that a deserializer may be used from several goroutines at once.
+
+type ConcurrentStruct struct {
+	Name     string                `json:"name"`
+	Inner    StructWithInitializer `json:"inner" default:"{}"`
+	Bookings []Booking             `json:"bookings"`
+	Labels   map[string]int        `json:"labels" default:"{}"`
+	Limits   *Limits               `json:"limits" default:"{}"`
+}
+
+func TestConcurrentDeserialization(t *testing.T) {
+	const goroutines = 16
+	const iterations = 50
+
+	deserializer, err := deserialize.MakeMapDeserializer[ConcurrentStruct](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[QueryWithNested](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+
+	errs := make(chan error, goroutines*iterations)
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				name := fmt.Sprintf("%d-%d", g, i)
+				found, err := deserializer.DeserializeString(fmt.Sprintf(`{"name": %q, "bookings": [{"start": %d}], "labels": {%q: %d}}`, name, i, name, g))
+				switch {
+				case err != nil:
+					errs <- err
+				case found.Name != name || found.Bookings[0].EndDate != int64(i)+3600 || found.Labels[name] != g:
+					errs <- fmt.Errorf("unexpected result %v", *found)
+				case found.Inner.SomeString != "text" || found.Limits == nil || found.Limits.MaxConnections != 100:
+					errs <- fmt.Errorf("missing defaults in %v", *found)
+				}
+
+				kvFound, err := kvDeserializer.DeserializeKVList(kvlist.KVList{
+					"name":                  []string{name},
+					"location.address.city": []string{name},
+				})
+				switch {
+				case err != nil:
+					errs <- err
+				case kvFound.Name != name || kvFound.Location.Address.City != name:
+					errs <- fmt.Errorf("unexpected result %v", *kvFound)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NilError(t, err)
+	}
+}
+
+// ------ Test that `StrictIntegers` rejects numbers with a fractional part.
+
+type StructWithQuantity struct {
+	Quantity uint32  `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+func TestStrictIntegers(t *testing.T) {
+	// By default, numbers are truncated.
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithQuantity](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"quantity": 2.5, "price": 2.5}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithQuantity{Quantity: 2, Price: 2.5})
+
+	options := deserialize.JSONOptions("")
+	options.StrictIntegers = true
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithQuantity](options)
+	assert.NilError(t, err)
+
+	_, err = deserializer.DeserializeString(`{"quantity": 2.5, "price": 2.5}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithQuantity.quantity, expected an integer, got 2.5")
+	assert.Assert(t, errors.As(err, &deserialize.TypeMismatchError{}))
+
+	// Integral numbers are accepted.
+	found, err = deserializer.DeserializeString(`{"quantity": 3.0, "price": 2.5}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithQuantity{Quantity: 3, Price: 2.5})
+}
+
+// ------ Test that `UseNumber` preserves large integers.
+
+type StructWithLargeID struct {
+	ID      int64   `json:"id"`
+	Account uint64  `json:"account"`
+	Amount  float64 `json:"amount"`
+	Small   int8    `json:"small"`
+	Raw     any     `json:"raw"`
+}
+
+type StructWithLabel struct {
+	Name string `json:"name"`
+}
+
+func TestUseNumber(t *testing.T) {
+	const sample = `{"id": 9007199254740993, "account": 18446744073709551615, "amount": 1.5, "small": 3.0, "raw": 12345678901234567}`
+
+	// By default, numbers go through `float64` and lose precision.
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithLargeID](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"id": 9007199254740993, "account": 1, "amount": 1.5, "small": 3, "raw": 1}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.ID, int64(9007199254740992))
+
+	options := deserialize.JSONOptions("")
+	options.UseNumber = true
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithLargeID](options)
+	assert.NilError(t, err)
+
+	found, err = deserializer.DeserializeString(sample)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithLargeID{
+		ID:      9007199254740993,
+		Account: 18446744073709551615,
+		Amount:  1.5,
+		Small:   3,
+		Raw:     json.Number("12345678901234567"),
+	})
+
+	// Same thing from a stream.
+	found, err = deserializer.DeserializeReader(strings.NewReader(sample))
+	assert.NilError(t, err)
+	assert.Equal(t, found.ID, int64(9007199254740993))
+
+	// Overflows are still detected.
+	_, err = deserializer.DeserializeString(`{"id": 9223372036854775808, "account": 1, "amount": 1.5, "small": 3, "raw": 1}`)
+	assert.ErrorContains(t, err, "value 9223372036854775808 out of range for int64 at StructWithLargeID.id")
+	_, err = deserializer.DeserializeString(`{"id": 1, "account": 1, "amount": 1.5, "small": 300, "raw": 1}`)
+	assert.ErrorContains(t, err, "value 300 out of range for int8 at StructWithLargeID.small")
+
+	// Numbers are not accepted where strings are expected, with or without `UseNumber`.
+	for _, useNumber := range []bool{false, true} {
+		options := deserialize.JSONOptions("")
+		options.UseNumber = useNumber
+		named, err := deserialize.MakeMapDeserializer[StructWithLabel](options)
+		assert.NilError(t, err)
+		_, err = named.DeserializeString(`{"name": 42}`)
+		assert.ErrorContains(t, err, "invalid value at StructWithLabel.name, expected string, got 42")
+		assert.Assert(t, errors.As(err, &deserialize.TypeMismatchError{}))
+	}
+
+	// Drivers that do not support `UseNumber` are rejected.
+	kvOptions := deserialize.QueryOptions("")
+	kvOptions.UseNumber = true
+	_, err = deserialize.MakeKVListDeserializer[StructWithLargeID](kvOptions)
+	assert.ErrorContains(t, err, "option UseNumber is not supported by this unmarshaler")
+}
+
+// ------ Test that the KVList driver may be reused after building a deserializer.
+
+type KVListReuseFirst struct {
+	Tags  []string `query:"tags"`
+	Limit int      `query:"limit"`
+}
+
+type KVListReuseSecond struct {
+	Address QueryAddress `query:"address"`
+	IDs     []int        `query:"ids"`
+}
+
+type KVListReuseInvalid struct {
+	Values []map[string]int `query:"values"`
+}
+
+func TestKVListDriverReuse(t *testing.T) {
+	// Two deserializers built from the same options.
+	options := deserialize.QueryOptions("")
+	first, err := deserialize.MakeKVListDeserializer[KVListReuseFirst](options)
+	assert.NilError(t, err)
+	second, err := deserialize.MakeKVListDeserializer[KVListReuseSecond](options)
+	assert.NilError(t, err)
+
+	// Even a single driver shared between constructions, including failed ones,
+	// is back to its initial state once construction is complete.
+	driver := kvlist.Driver()
+	sharedOptions := options
+	sharedOptions.Unmarshaler = func() shared.Driver { return driver }
+	_, err = deserialize.MakeKVListDeserializer[KVListReuseInvalid](sharedOptions)
+	assert.ErrorContains(t, err, "KVList deserialization expects a struct of slices of trivially deserializable types")
+	sharedFirst, err := deserialize.MakeKVListDeserializer[KVListReuseFirst](sharedOptions)
+	assert.NilError(t, err)
+	sharedSecond, err := deserialize.MakeKVListDeserializer[KVListReuseSecond](sharedOptions)
+	assert.NilError(t, err)
+
+	check := func(first deserialize.KVListDeserializer[KVListReuseFirst], second deserialize.KVListDeserializer[KVListReuseSecond]) {
+		for i := 0; i < 2; i++ {
+			foundFirst, err := first.DeserializeKVList(kvlist.KVList{"tags": []string{"a", "b"}, "limit": []string{"10"}})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, *foundFirst, KVListReuseFirst{Tags: []string{"a", "b"}, Limit: 10})
+
+			foundSecond, err := second.DeserializeKVList(kvlist.KVList{"address.city": []string{"Paris"}, "ids": []string{"1", "2"}})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, *foundSecond, KVListReuseSecond{Address: QueryAddress{City: "Paris", Country: "FR"}, IDs: []int{1, 2}})
+		}
+	}
+	check(first, second)
+	check(sharedFirst, sharedSecond)
+}
+
+// ------ Test that interface fields support `default:"nil"`.
+
+type Shape interface {
+	Area() float64
+}
+
+type StructWithNilInterfaces struct {
+	Extra any   `json:"extra" default:"nil"`
+	Shape Shape `json:"shape" default:"nil"`
+}
+
+type StructWithInterfaceNoDefault struct {
+	Extra any `json:"extra"`
+}
+
+func TestInterfaceNilDefault(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithNilInterfaces](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// Absent fields are set to `nil`.
+	found, err := deserializer.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Extra, nil)
+	assert.Equal(t, found.Shape, nil)
+
+	// Present fields are deserialized as usual.
+	found, err = deserializer.DeserializeString(`{"extra": "abc", "shape": null}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Extra, "abc")
+	assert.Equal(t, found.Shape, nil)
+
+	_, err = deserializer.DeserializeString(`{"shape": {"radius": 1}}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithNilInterfaces.shape")
+
+	// Without a default, a value is still required.
+	noDefault, err := deserialize.MakeMapDeserializer[StructWithInterfaceNoDefault](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	_, err = noDefault.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "missing value at StructWithInterfaceNoDefault.extra")
+}
+
+// ------ Test that `RejectDuplicateKeys` detects repeated keys.
+
+type DuplicateKeysItem struct {
+	Name string `json:"name"`
+}
+
+type StructWithDuplicateKeys struct {
+	ID    int                 `json:"id"`
+	Items []DuplicateKeysItem `json:"items"`
+}
+
+func TestRejectDuplicateKeys(t *testing.T) {
+	// By default, the last value wins.
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithDuplicateKeys](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"id": 1, "id": 2, "items": []}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.ID, 2)
+
+	options := deserialize.JSONOptions("")
+	options.RejectDuplicateKeys = true
+	options.UseNumber = true
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithDuplicateKeys](options)
+	assert.NilError(t, err)
+
+	found, err = deserializer.DeserializeString(`{"id": 1, "items": [{"name": "a"}, {"name": "b"}]}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithDuplicateKeys{ID: 1, Items: []DuplicateKeysItem{{Name: "a"}, {Name: "b"}}})
+
+	_, err = deserializer.DeserializeString(`{"id": 1, "id": 2, "items": []}`)
+	assert.ErrorContains(t, err, "duplicate key id at $")
+
+	_, err = deserializer.DeserializeString(`{"id": 1, "items": [{"name": "a"}, {"name": "b", "name": "c"}]}`)
+	assert.ErrorContains(t, err, "duplicate key name at $.items[1]")
+
+	_, err = deserializer.DeserializeReader(strings.NewReader(`{"id": 1, "items": [], "items": []}`))
+	assert.ErrorContains(t, err, "duplicate key items at $")
+
+	// Syntax errors are still reported as such.
+	_, err = deserializer.DeserializeString(`{"id": 1,`)
+	assert.ErrorContains(t, err, "unexpected EOF")
+
+	// Drivers that do not support `RejectDuplicateKeys` are rejected.
+	kvOptions := deserialize.QueryOptions("")
+	kvOptions.RejectDuplicateKeys = true
+	_, err = deserialize.MakeKVListDeserializer[StructWithDuplicateKeys](kvOptions)
+	assert.ErrorContains(t, err, "option RejectDuplicateKeys is not supported by this unmarshaler")
+}
+
+// ------ Test polymorphic deserialization with `RegisterUnion`.
+
+type Polygon interface {
+	Sides() int
+}
+
+type Triangle struct {
+	Kind string  `json:"kind"`
+	Base float64 `json:"base"`
+}
+
+func (Triangle) Sides() int {
+	return 3
+}
+
+type Rectangle struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height" default:"1"`
+}
+
+func (*Rectangle) Sides() int {
+	return 4
+}
+
+type Drawing struct {
+	Main    Polygon   `json:"main"`
+	Extra   Polygon   `json:"extra" default:"nil"`
+	Others  []Polygon `json:"others" default:"[]"`
+	Caption string    `json:"caption"`
+}
+
+func TestUnion(t *testing.T) {
+	err := deserialize.RegisterUnion[Polygon](map[string]reflect.Type{
+		"triangle":  reflect.TypeOf(Triangle{}),
+		"rectangle": reflect.TypeOf(Rectangle{}),
+	}, "kind")
+	assert.NilError(t, err)
+
+	deserializer, err := deserialize.MakeMapDeserializer[Drawing](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"main": {"kind": "triangle", "base": 2}, "others": [{"kind": "rectangle", "width": 3}], "caption": "abc"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Drawing{
+		Main:    Triangle{Kind: "triangle", Base: 2},
+		Extra:   nil,
+		Others:  []Polygon{&Rectangle{Width: 3, Height: 1}},
+		Caption: "abc",
+	})
+
+	_, err = deserializer.DeserializeString(`{"main": {"kind": "hexagon"}, "caption": "abc"}`)
+	assert.ErrorContains(t, err, "invalid value at Drawing.main.kind, expected one of rectangle, triangle, got hexagon")
+
+	_, err = deserializer.DeserializeString(`{"main": {"base": 2}, "caption": "abc"}`)
+	assert.ErrorContains(t, err, "missing value at Drawing.main.kind, expected one of rectangle, triangle")
+
+	_, err = deserializer.DeserializeString(`{"main": "triangle", "caption": "abc"}`)
+	assert.ErrorContains(t, err, "invalid value at Drawing.main, expected an object of type Polygon, got string")
+	assert.Assert(t, errors.As(err, &deserialize.TypeMismatchError{}))
+
+	_, err = deserializer.DeserializeString(`{"caption": "abc"}`)
+	assert.ErrorContains(t, err, "missing value at Drawing.main, expected Polygon")
+
+	// Errors in the variant are reported at the path of the variant.
+	_, err = deserializer.DeserializeString(`{"main": {"kind": "rectangle", "width": "wide"}, "caption": "abc"}`)
+	assert.ErrorContains(t, err, "Drawing.main[rectangle].width")
+
+	// Variants must implement the interface.
+	err = deserialize.RegisterUnion[Polygon](map[string]reflect.Type{
+		"circle": reflect.TypeOf(SimpleStruct{}),
+	}, "kind")
+	assert.ErrorContains(t, err, "which does not implement it")
+}
+
+// ------ Test that `readOnly` fields reject input.
+
+type StructWithReadOnly struct {
+	Name   string `json:"name"`
+	Status string `json:"status" default:"pending" readOnly:"true"`
+}
+
+type StructWithReadOnlyAndRequired struct {
+	Status string `json:"status" required:"" readOnly:"true"`
+}
+
+func TestReadOnly(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithReadOnly](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// Absent, the field uses its default.
+	found, err := deserializer.DeserializeString(`{"name": "abc"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithReadOnly{Name: "abc", Status: "pending"})
+
+	// Present, even with the default value, the field is rejected.
+	_, err = deserializer.DeserializeString(`{"name": "abc", "status": "pending"}`)
+	assert.ErrorContains(t, err, "field StructWithReadOnly.status is read-only and cannot be set by clients")
+
+	_, err = deserialize.MakeMapDeserializer[StructWithReadOnlyAndRequired](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "that is both `required` and `readOnly`")
+}
+
+// ------ Test that `transform` applies a pipeline of string transforms.
+
+type StructWithTransforms struct {
+	Username string `json:"username" transform:"trim|lower|collapseSpaces" minlen:"3"`
+	Code     string `json:"code" transform:"trim|upper|noDashes"`
+}
+
+type StructWithUnknownTransform struct {
+	Username string `json:"username" transform:"trim|reverse"`
+}
+
+type StructWithTransformOnNumber struct {
+	Count int `json:"count" transform:"trim"`
+}
+
+func TestTransforms(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.Transforms = map[string]func(string) string{
+		"noDashes": func(source string) string {
+			return strings.ReplaceAll(source, "-", "")
+		},
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithTransforms](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"username": "  John   SMITH ", "code": " ab-12-cd "}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithTransforms{Username: "john smith", Code: "AB12CD"})
+
+	// Validation applies to the transformed value.
+	_, err = deserializer.DeserializeString(`{"username": "  ab   ", "code": "x"}`)
+	assert.ErrorContains(t, err, "validation error at StructWithTransforms.username")
+
+	// Custom transforms are only known to the deserializers built with them.
+	_, err = deserialize.MakeMapDeserializer[StructWithTransforms](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, `at StructWithTransforms.code, unknown transform "noDashes"`)
+
+	_, err = deserialize.MakeMapDeserializer[StructWithUnknownTransform](options)
+	assert.ErrorContains(t, err, `at StructWithUnknownTransform.username, unknown transform "reverse"`)
+
+	_, err = deserialize.MakeMapDeserializer[StructWithTransformOnNumber](options)
+	assert.ErrorContains(t, err, "tag `transform` may only be used on strings")
+}
+
+// ------ Test that embedded pointers to structs are flattened.
+
+type EmbeddedAudit struct {
+	CreatedBy string `json:"createdBy"`
+	Revision  int    `json:"revision" default:"1"`
+}
+
+type EmbeddedOwner struct {
+	*EmbeddedAudit
+	Owner string `json:"owner"`
+}
+
+type StructWithEmbeddedPointers struct {
+	*EmbeddedOwner
+	Name string `json:"name"`
+}
+
+func TestEmbeddedPointer(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.DisallowUnknownFields = true
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithEmbeddedPointers](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"name": "doc", "owner": "alice", "createdBy": "bob"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithEmbeddedPointers{
+		EmbeddedOwner: &EmbeddedOwner{
+			EmbeddedAudit: &EmbeddedAudit{CreatedBy: "bob", Revision: 1},
+			Owner:         "alice",
+		},
+		Name: "doc",
+	})
+
+	// Fields of the embedded structs are still required.
+	_, err = deserializer.DeserializeString(`{"name": "doc", "createdBy": "bob"}`)
+	assert.ErrorContains(t, err, "missing value at StructWithEmbeddedPointers.EmbeddedOwner.owner")
+
+	// Unknown fields are still detected.
+	_, err = deserializer.DeserializeString(`{"name": "doc", "owner": "alice", "createdBy": "bob", "color": "red"}`)
+	assert.ErrorContains(t, err, "unexpected field color at StructWithEmbeddedPointers")
+}
+
+// ------ Test that `MakeMapSerializer` round-trips through the deserializer.
+
+type SerializedAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty" default:""`
+}
+
+type SerializedAudit struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type SerializedOrder struct {
+	*SerializedAudit
+	ID       uuid.UUID            `json:"id"`
+	Customer string               `json:"customer"`
+	Quantity uint8                `json:"quantity"`
+	Price    float64              `json:"price"`
+	Gift     bool                 `json:"gift"`
+	Note     *string              `json:"note"`
+	Tags     []string             `json:"tags"`
+	Address  SerializedAddress    `json:"address"`
+	Previous []SerializedAddress  `json:"previous"`
+	Counts   map[string]int       `json:"counts"`
+	ByID     map[uuid.UUID]string `json:"byId"`
+	Extra    any                  `json:"extra"`
+	Coupon   string               `json:"coupon" omitempty:"" default:""`
+}
+
+type SerializedSecret struct {
+	Name     string `json:"name" yaml:"name"`
+	Password string `json:"-" yaml:"-" initialized:""`
+}
+
+func TestSerializer(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	serializer, err := deserialize.MakeMapSerializer[SerializedOrder](options)
+	assert.NilError(t, err)
+	deserializer, err := deserialize.MakeMapDeserializer[SerializedOrder](options)
+	assert.NilError(t, err)
+
+	note := "ring twice"
+	id := uuid.New()
+	order := SerializedOrder{
+		SerializedAudit: &SerializedAudit{CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		ID:              id,
+		Customer:        "alice",
+		Quantity:        3,
+		Price:           9.5,
+		Gift:            true,
+		Note:            &note,
+		Tags:            []string{"a", "b"},
+		Address:         SerializedAddress{City: "Paris", Zip: "75001"},
+		Previous:        []SerializedAddress{{City: "Lyon", Zip: ""}},
+		Counts:          map[string]int{"x": 1},
+		ByID:            map[uuid.UUID]string{id: "self"},
+		Extra:           "anything",
+		Coupon:          "",
+	}
+
+	// Through dictionaries.
+	dict, err := serializer.SerializeDict(&order)
+	assert.NilError(t, err)
+	_, ok := dict.Lookup("coupon")
+	assert.Assert(t, !ok, "empty fields tagged omitempty should be omitted")
+	found, err := deserializer.DeserializeDict(dict)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, order)
+
+	// Through bytes.
+	buf, err := serializer.SerializeBytes(&order)
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(string(buf), `"createdAt":"2024-01-02T03:04:05Z"`), string(buf))
+	assert.Assert(t, strings.Contains(string(buf), `"previous":[{"city":"Lyon"}]`), string(buf))
+	found, err = deserializer.DeserializeBytes(buf)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, order)
+
+	// Fields renamed to `-` are not serialized.
+	secretSerializer, err := deserialize.MakeMapSerializer[SerializedSecret](options)
+	assert.NilError(t, err)
+	buf, err = secretSerializer.SerializeBytes(&SerializedSecret{Name: "bob", Password: "hunter2"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), `{"name":"bob"}`)
+
+	// Envelopes are added.
+	envelopeOptions := deserialize.JSONOptions("")
+	envelopeOptions.Envelope = "data"
+	secretSerializer, err = deserialize.MakeMapSerializer[SerializedSecret](envelopeOptions)
+	assert.NilError(t, err)
+	buf, err = secretSerializer.SerializeBytes(&SerializedSecret{Name: "bob", Password: "hunter2"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), `{"data":{"name":"bob"}}`)
+
+	// YAML is supported, too.
+	yamlSerializer, err := deserialize.MakeMapSerializer[SerializedSecret](deserialize.YAMLOptions(""))
+	assert.NilError(t, err)
+	buf, err = yamlSerializer.SerializeBytes(&SerializedSecret{Name: "bob", Password: "hunter2"})
+	assert.NilError(t, err)
+	assert.Equal(t, string(buf), "name: bob\n")
+
+	// Query strings cannot be serialized to bytes.
+	querySerializer, err := deserialize.MakeMapSerializer[SerializedSecret](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	_, err = querySerializer.SerializeBytes(&SerializedSecret{Name: "bob", Password: "hunter2"})
+	assert.ErrorContains(t, err, "this unmarshaler does not support serializing to bytes")
+}
+
+// ------ Test deserializing from Protocol Buffers dynamic structs.
+
+type ProtoDimensions struct {
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+type ProtoPayload struct {
+	Name       string           `json:"name" minlen:"1"`
+	Count      int              `json:"count"`
+	Enabled    bool             `json:"enabled"`
+	Labels     []string         `json:"labels"`
+	Dimensions ProtoDimensions  `json:"dimensions"`
+	Parent     *ProtoDimensions `json:"parent"`
+	Extra      any              `json:"extra"`
+}
+
+func (p *ProtoPayload) Validate() error {
+	if p.Count < 0 {
+		return errors.New("count must be positive")
+	}
+	return nil
+}
+
+func TestProtobufStruct(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[ProtoPayload](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	source, err := structpb.NewStruct(map[string]any{
+		"name":       "widget",
+		"count":      3,
+		"enabled":    true,
+		"labels":     []any{"a", "b"},
+		"dimensions": map[string]any{"width": 1.5, "height": 2},
+		"parent":     nil,
+		"extra":      map[string]any{"nested": []any{1, "two"}},
+	})
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeDict(pbstruct.Dict(source))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, ProtoPayload{
+		Name:       "widget",
+		Count:      3,
+		Enabled:    true,
+		Labels:     []string{"a", "b"},
+		Dimensions: ProtoDimensions{Width: 1.5, Height: 2},
+		Parent:     nil,
+		Extra:      map[string]any{"nested": []any{1.0, "two"}},
+	})
+
+	// Validation still applies.
+	source.Fields["count"] = structpb.NewNumberValue(-1)
+	_, err = deserializer.DeserializeDict(pbstruct.Dict(source))
+	assert.ErrorContains(t, err, "count must be positive")
+
+	source.Fields["count"] = structpb.NewNumberValue(1)
+	source.Fields["name"] = structpb.NewStringValue("")
+	_, err = deserializer.DeserializeDict(pbstruct.Dict(source))
+	assert.ErrorContains(t, err, "validation error at ProtoPayload.name")
+
+	source.Fields["name"] = structpb.NewStringValue("widget")
+	source.Fields["labels"] = structpb.NewStringValue("a")
+	_, err = deserializer.DeserializeDict(pbstruct.Dict(source))
+	assert.ErrorContains(t, err, "ProtoPayload.labels")
+}
+
+// ------ Test that named slices and maps may implement `Validator`.
+
+type NonEmptyList []int
+
+func (l *NonEmptyList) Validate() error {
+	if len(*l) == 0 {
+		return errors.New("expected at least one element")
+	}
+	return nil
+}
+
+type LowercaseLabels map[string]string
+
+func (l *LowercaseLabels) Validate() error {
+	for k := range *l {
+		if strings.ToLower(k) != k {
+			return fmt.Errorf("label %s should be lowercase", k)
+		}
+	}
+	return nil
+}
+
+type StructWithValidatedCollections struct {
+	IDs    NonEmptyList    `json:"ids"`
+	Labels LowercaseLabels `json:"labels" default:"{}"`
+}
+
+func TestValidatedCollections(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithValidatedCollections](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"ids": [1, 2], "labels": {"env": "prod"}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithValidatedCollections{IDs: NonEmptyList{1, 2}, Labels: LowercaseLabels{"env": "prod"}})
+
+	_, err = deserializer.DeserializeString(`{"ids": []}`)
+	assert.ErrorContains(t, err, "validation error at StructWithValidatedCollections.ids:\n\t * expected at least one element")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	_, err = deserializer.DeserializeString(`{"ids": [1], "labels": {"Env": "prod"}}`)
+	assert.ErrorContains(t, err, "validation error at StructWithValidatedCollections.labels:\n\t * label Env should be lowercase")
+}
+
+// ------ Test that `AcceptIndexedObjectsAsArrays` accepts objects keyed by index.
+
+type StructWithIndexedArrays struct {
+	Tags   []string  `json:"tags"`
+	Points [2]int    `json:"points"`
+	Nested [][]uint8 `json:"nested"`
+}
+
+func TestAcceptIndexedObjectsAsArrays(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.AcceptIndexedObjectsAsArrays = true
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithIndexedArrays](options)
+	assert.NilError(t, err)
+
+	// Keys are sorted numerically, not lexicographically.
+	found, err := deserializer.DeserializeString(`{"tags": {"10": "k", "2": "c", "0": "a"}, "points": {"1": 2, "0": 1}, "nested": {"0": {"1": 2, "0": 1}, "1": [3]}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithIndexedArrays{
+		Tags:   []string{"a", "c", "k"},
+		Points: [2]int{1, 2},
+		Nested: [][]uint8{{1, 2}, {3}},
+	})
+
+	// Arrays are still accepted.
+	found, err = deserializer.DeserializeString(`{"tags": ["a"], "points": [1, 2], "nested": []}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithIndexedArrays{Tags: []string{"a"}, Points: [2]int{1, 2}, Nested: [][]uint8{}})
+
+	_, err = deserializer.DeserializeString(`{"tags": {"0": "a", "first": "b"}, "points": [1, 2], "nested": []}`)
+	assert.ErrorContains(t, err, `invalid value at StructWithIndexedArrays.tags, expected an array or an object keyed by index, got key "first"`)
+
+	_, err = deserializer.DeserializeString(`{"tags": {"-1": "a"}, "points": [1, 2], "nested": []}`)
+	assert.ErrorContains(t, err, `got key "-1"`)
+
+	_, err = deserializer.DeserializeString(`{"tags": {"1": "a", "01": "b"}, "points": [1, 2], "nested": []}`)
+	assert.ErrorContains(t, err, "both represent index 1")
+
+	// Fixed-length arrays still check their length.
+	_, err = deserializer.DeserializeString(`{"tags": [], "points": {"0": 1}, "nested": []}`)
+	assert.ErrorContains(t, err, "StructWithIndexedArrays.points")
+
+	// Without the option, objects are rejected.
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithIndexedArrays](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeString(`{"tags": {"0": "a"}, "points": [1, 2], "nested": []}`)
+	assert.ErrorContains(t, err, "invalid value at StructWithIndexedArrays.tags, expected an array of type []string")
+}
+
+// ------ Test that `CustomCallTimeout` bounds the duration of custom methods.
+
+type StructWithSlowOrMethod struct {
+	Region string `json:"region" orMethod:"FetchRegion"`
+}
+
+// Simulate a call to a slow service.
+func (StructWithSlowOrMethod) FetchRegion() (string, error) {
+	time.Sleep(time.Second)
+	return "eu-west-1", nil
+}
+
+type StructWithFastOrMethod struct {
+	Region string `json:"region" orMethod:"FetchRegion"`
+}
+
+func (StructWithFastOrMethod) FetchRegion() (string, error) {
+	return "eu-west-1", nil
+}
+
+type StructWithSlowInitializer struct {
+	Region string `json:"region"`
+}
+
+func (s *StructWithSlowInitializer) Initialize() error {
+	time.Sleep(time.Second)
+	s.Region = "eu-west-1"
+	return nil
+}
+
+type StructWithCancellableInitializer struct {
+	Region string `json:"region"`
+}
+
+func (s *StructWithCancellableInitializer) InitializeContext(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Second):
+		s.Region = "eu-west-1"
+		return nil
+	}
+}
+
+func TestCustomCallTimeout(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.CustomCallTimeout = 20 * time.Millisecond
+
+	slowOrMethod, err := deserialize.MakeMapDeserializer[StructWithSlowOrMethod](options)
+	assert.NilError(t, err)
+	_, err = slowOrMethod.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "custom method FetchRegion at StructWithSlowOrMethod.region timed out")
+	assert.Assert(t, errors.As(err, &deserialize.CustomDeserializerError{}))
+
+	// The method is only called if the value is missing.
+	found, err := slowOrMethod.DeserializeString(`{"region": "us-east-1"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Region, "us-east-1")
+
+	fastOrMethod, err := deserialize.MakeMapDeserializer[StructWithFastOrMethod](options)
+	assert.NilError(t, err)
+	found2, err := fastOrMethod.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found2.Region, "eu-west-1")
+
+	slowInitializer, err := deserialize.MakeMapDeserializer[StructWithSlowInitializer](options)
+	assert.NilError(t, err)
+	_, err = slowInitializer.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "custom method Initialize at StructWithSlowInitializer timed out")
+
+	cancellableInitializer, err := deserialize.MakeMapDeserializer[StructWithCancellableInitializer](options)
+	assert.NilError(t, err)
+	_, err = cancellableInitializer.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "custom method InitializeContext at StructWithCancellableInitializer timed out")
+
+	// Without a timeout, we wait.
+	slowOrMethod, err = deserialize.MakeMapDeserializer[StructWithSlowOrMethod](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err = slowOrMethod.DeserializeString(`{}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Region, "eu-west-1")
+}
+
+// ------ Test that flattened fields may not silently read the same key.
+
+type FlattenedAudit struct {
+	CreatedBy string `json:"createdBy"`
+	Version   int    `json:"version"`
+}
+
+type FlattenedRevision struct {
+	Version string `json:"version"`
+}
+
+type StructWithFlattenCollision struct {
+	Audit    FlattenedAudit    `json:"audit" flatten:""`
+	Revision FlattenedRevision `json:"revision" flatten:""`
+}
+
+type StructWithFlattenRegularCollision struct {
+	FlattenedAudit
+	CreatedBy string `json:"createdBy"`
+}
+
+type StructWithNestedFlattenCollision struct {
+	Outer StructWithFlattenCollision `json:"outer" flatten:""`
+}
+
+type StructWithoutFlattenCollision struct {
+	Audit    FlattenedAudit `json:"audit" flatten:""`
+	Name     string         `json:"name"`
+	Original FlattenedAudit `json:"original"`
+}
+
+func TestFlattenCollisions(t *testing.T) {
+	_, err := deserialize.MakeMapDeserializer[StructWithFlattenCollision](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, `struct StructWithFlattenCollision contains fields "Audit.Version" and "Revision.Version" that both read key version`)
+
+	_, err = deserialize.MakeMapDeserializer[StructWithFlattenRegularCollision](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, `struct StructWithFlattenRegularCollision contains fields "FlattenedAudit.CreatedBy" and "CreatedBy" that both read key createdBy`)
+
+	_, err = deserialize.MakeMapDeserializer[StructWithNestedFlattenCollision](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, `that both read key version`)
+
+	// Nested structs have their own keys.
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithoutFlattenCollision](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := deserializer.DeserializeString(`{"createdBy": "alice", "version": 2, "name": "doc", "original": {"createdBy": "bob", "version": 1}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithoutFlattenCollision{
+		Audit:    FlattenedAudit{CreatedBy: "alice", Version: 2},
+		Name:     "doc",
+		Original: FlattenedAudit{CreatedBy: "bob", Version: 1},
+	})
+
+	// If collisions are allowed, the value is deserialized into each field.
+	options := deserialize.JSONOptions("")
+	options.AllowFlattenCollisions = true
+	collisions, err := deserialize.MakeMapDeserializer[StructWithFlattenRegularCollision](options)
+	assert.NilError(t, err)
+	found2, err := collisions.DeserializeString(`{"createdBy": "alice", "version": 2}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found2, StructWithFlattenRegularCollision{
+		FlattenedAudit: FlattenedAudit{CreatedBy: "alice", Version: 2},
+		CreatedBy:      "alice",
+	})
+}
+
+// ------ Test that `allowedKeys` rejects keys outside of the list.
+
+type StructWithAllowedKeys struct {
+	Permissions map[string]bool `json:"permissions" allowedKeys:"read,write,admin"`
+	Quotas      map[uint8]int   `json:"quotas" allowedKeys:"1,2,3" default:"{}"`
+}
+
+func TestAllowedKeys(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithAllowedKeys](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// Allowed keys are not required.
+	found, err := deserializer.DeserializeString(`{"permissions": {"read": true, "admin": false}, "quotas": {"2": 10}}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithAllowedKeys{
+		Permissions: map[string]bool{"read": true, "admin": false},
+		Quotas:      map[uint8]int{2: 10},
+	})
+
+	_, err = deserializer.DeserializeString(`{"permissions": {"read": true, "delete": true}}`)
+	assert.ErrorContains(t, err, "invalid key at StructWithAllowedKeys.permissions[delete], expected one of read, write, admin")
+
+	// Keys are compared as received.
+	_, err = deserializer.DeserializeString(`{"permissions": {}, "quotas": {"02": 10}}`)
+	assert.ErrorContains(t, err, "invalid key at StructWithAllowedKeys.quotas[02]")
+
+	// Allowed keys must be valid keys.
+	type InvalidKey struct {
+		Field map[uint8]int `allowedKeys:"1,300"`
+	}
+	_, err = deserialize.MakeMapDeserializer[InvalidKey](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at InvalidKey.Field, invalid `allowedKeys` value")
+
+	// The tag only makes sense on maps.
+	type Invalid struct {
+		Field []string `allowedKeys:"a,b"`
+	}
+	_, err = deserialize.MakeMapDeserializer[Invalid](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `allowedKeys` may only be used on maps")
+}
+
+// ------ Test that `parsers` tries a chain of parsers in order.
+
+type StructWithParserChain struct {
+	Seen     time.Time  `json:"seen" parsers:"rfc3339,unix,date"`
+	Expires  *time.Time `json:"expires" parsers:"date,unix" default:"nil"`
+	Priority int        `json:"priority" parsers:"level,hex" default:"low"`
+}
+
+func TestParserChain(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.NamedParsers = map[string]shared.Parser{
+		"level": func(source string) (any, error) {
+			switch source {
+			case "low":
+				return 1, nil
+			case "high":
+				return 10, nil
+			}
+			return nil, fmt.Errorf("unknown level %s", source)
+		},
+		"hex": func(source string) (any, error) {
+			return strconv.ParseInt(strings.TrimPrefix(source, "0x"), 16, 64)
+		},
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithParserChain](options)
+	assert.NilError(t, err)
+
+	day := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	// Each member of the chain.
+	for _, seen := range []string{"2024-03-01T00:00:00Z", "1709251200", "2024-03-01"} {
+		found, err := deserializer.DeserializeString(fmt.Sprintf(`{"seen": %q}`, seen))
+		assert.NilError(t, err, seen)
+		assert.Assert(t, found.Seen.Equal(day), seen)
+		assert.Assert(t, found.Expires == nil)
+		assert.Equal(t, found.Priority, 1)
+	}
+
+	found, err := deserializer.DeserializeString(`{"seen": "2024-03-01", "expires": "1709251200", "priority": "0x20"}`)
+	assert.NilError(t, err)
+	assert.Assert(t, found.Expires.Equal(day))
+	assert.Equal(t, found.Priority, 32)
+
+	found, err = deserializer.DeserializeString(`{"seen": "2024-03-01", "priority": "high"}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.Priority, 10)
+
+	// If all parsers fail, we report every error.
+	_, err = deserializer.DeserializeString(`{"seen": "2024-03-01", "priority": "medium"}`)
+	assert.ErrorContains(t, err, `none of the parsers level, hex accepts "medium"`)
+	assert.ErrorContains(t, err, "level: unknown level medium")
+	assert.ErrorContains(t, err, "hex: strconv.ParseInt")
+
+	// Custom parsers take precedence over built-in parsers.
+	options.NamedParsers["date"] = func(source string) (any, error) {
+		return time.Parse("02/01/2006", source)
+	}
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithParserChain](options)
+	assert.NilError(t, err)
+	found, err = deserializer.DeserializeString(`{"seen": "01/03/2024"}`)
+	assert.NilError(t, err)
+	assert.Assert(t, found.Seen.Equal(day))
+
+	// The chain is checked when setting up the deserializer.
+	type UnknownParser struct {
+		Seen time.Time `json:"seen" parsers:"rfc3339,iso8601"`
+	}
+	_, err = deserialize.MakeMapDeserializer[UnknownParser](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "at UnknownParser.seen, unknown parser \"iso8601\", please register it in `Options.NamedParsers`")
+
+	type ParsersAndLayout struct {
+		Seen time.Time `json:"seen" parsers:"rfc3339" layout:"2006-01-02"`
+	}
+	_, err = deserialize.MakeMapDeserializer[ParsersAndLayout](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "tag `parsers` cannot be combined with tags `layout` or `base`")
+}
+
+// ------ Test that `optional` fields default to their zero value.
+
+type OptionalRetry struct {
+	Attempts uint8 `json:"attempts"`
+}
+
+type StructWithOptionalFields struct {
+	Name    string            `json:"name"`
+	Count   int               `json:"count" optional:"" min:"1"`
+	Tags    []string          `json:"tags" optional:""`
+	Labels  map[string]string `json:"labels" optional:""`
+	Retry   OptionalRetry     `json:"retry" optional:""`
+	Comment *string           `json:"comment" optional:""`
+}
+
+type StructWithOptionalFieldsValidated struct {
+	Name  string `json:"name"`
+	Count int    `json:"count" optional:""`
+}
+
+func (s *StructWithOptionalFieldsValidated) Validate() error {
+	if s.Count < 0 {
+		return errors.New("count must not be negative")
+	}
+	return nil
+}
+
+func TestOptional(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithOptionalFields](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	// Missing fields are left to their zero value, without running checks.
+	found, err := deserializer.DeserializeString(`{"name": "abc"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithOptionalFields{Name: "abc"})
+
+	comment := "hello"
+	found, err = deserializer.DeserializeString(`{"name": "abc", "count": 2, "tags": ["a"], "labels": {"b": "c"}, "retry": {"attempts": 3}, "comment": "hello"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, StructWithOptionalFields{
+		Name:    "abc",
+		Count:   2,
+		Tags:    []string{"a"},
+		Labels:  map[string]string{"b": "c"},
+		Retry:   OptionalRetry{Attempts: 3},
+		Comment: &comment,
+	})
+
+	// Values that are present are still checked.
+	_, err = deserializer.DeserializeString(`{"name": "abc", "count": 0}`)
+	assert.ErrorContains(t, err, "StructWithOptionalFields.count")
+	_, err = deserializer.DeserializeString(`{"name": "abc", "retry": {}}`)
+	assert.ErrorContains(t, err, "missing value at StructWithOptionalFields.retry.attempts")
+
+	// Other fields are still required.
+	_, err = deserializer.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "missing value at StructWithOptionalFields.name")
+
+	// Same thing with validation.
+	validated, err := deserialize.MakeMapDeserializer[StructWithOptionalFieldsValidated](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found2, err := validated.DeserializeString(`{"name": "abc"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found2, StructWithOptionalFieldsValidated{Name: "abc"})
+	_, err = validated.DeserializeString(`{"name": "abc", "count": -1}`)
+	assert.ErrorContains(t, err, "count must not be negative")
+
+	// Query strings.
+	queryOptions := deserialize.QueryOptions("")
+	queryOptions.FallbackTagNames = []string{"json"}
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[StructWithOptionalFieldsValidated](queryOptions)
+	assert.NilError(t, err)
+	found3, err := kvDeserializer.DeserializeKVList(kvlist.KVList{"name": []string{"abc"}})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found3, StructWithOptionalFieldsValidated{Name: "abc"})
+
+	// The tag is checked when setting up the deserializer.
+	type OptionalWithDefault struct {
+		Count int `json:"count" optional:"" default:"1"`
+	}
+	_, err = deserialize.MakeMapDeserializer[OptionalWithDefault](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "struct OptionalWithDefault contains a field \"Count\" that is `optional` but also has a `default`, `defaultEnv` or `orMethod` declaration")
+
+	type OptionalAndRequired struct {
+		Count int `json:"count" optional:"" required:""`
+	}
+	_, err = deserialize.MakeMapDeserializer[OptionalAndRequired](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "struct OptionalAndRequired contains a field \"Count\" that is both `optional` and `required`")
+
+	type OptionalFlattened struct {
+		Retry OptionalRetry `optional:"" flatten:""`
+	}
+	_, err = deserialize.MakeMapDeserializer[OptionalFlattened](deserialize.JSONOptions(""))
+	assert.ErrorContains(t, err, "that is both flattened and `optional`")
+}
+
+func TestOptionalPooled(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.Pool = true
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithOptionalFields](options)
+	assert.NilError(t, err)
+
+	for i := 0; i < 3; i++ {
+		pooled, err := deserializer.DeserializeBytesPooled([]byte(`{"name": "abc", "count": 5, "tags": ["a"]}`))
+		assert.NilError(t, err)
+		pooled.Release()
+
+		// Previous values must not leak.
+		pooled, err = deserializer.DeserializeBytesPooled([]byte(`{"name": "def"}`))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, *pooled.Value, StructWithOptionalFields{Name: "def"})
+		pooled.Release()
+	}
+}
+
+// ------ Test that `AccumulateErrors` reports all errors, including the struct validator.
+
+type AccumulatedEmail string
+
+var errInvalidEmail = errors.New("invalid email")
+
+func (e *AccumulatedEmail) Validate() error {
+	if !strings.Contains(string(*e), "@") {
+		return errInvalidEmail
+	}
+	return nil
+}
+
+type StructWithAccumulatedErrors struct {
+	Name  string           `json:"name"`
+	Email AccumulatedEmail `json:"email"`
+	Start int              `json:"start"`
+	End   int              `json:"end"`
+}
+
+func (s *StructWithAccumulatedErrors) Validate() error {
+	if !strings.Contains(string(s.Email), "@") {
+		// Same error as the field itself.
+		return errInvalidEmail
+	}
+	if s.End < s.Start {
+		return fmt.Errorf("end (%d) must not be before start (%d)", s.End, s.Start)
+	}
+	return nil
+}
+
+type StructWithTerseValidator struct {
+	Name string `json:"name"`
+}
+
+func (s *StructWithTerseValidator) Validate() error {
+	if s.Name == "" {
+		return errors.New("expected string")
+	}
+	return nil
+}
+
+func TestAccumulateErrors(t *testing.T) {
+	options := deserialize.JSONOptions("")
+	options.AccumulateErrors = true
+	deserializer, err := deserialize.MakeMapDeserializer[StructWithAccumulatedErrors](options)
+	assert.NilError(t, err)
+
+	found, err := deserializer.DeserializeString(`{"name": "abc", "email": "abc@example.com", "start": 1, "end": 2}`)
+	assert.NilError(t, err)
+	assert.Equal(t, found.End, 2)
+
+	// Both field errors and the validator error are reported.
+	_, err = deserializer.DeserializeString(`{"email": "abc@example.com", "start": 5, "end": "two"}`)
+	assert.Error(t, err, "invalid value at StructWithAccumulatedErrors.end, expected int, got two"+
+		"\n\t * missing value at StructWithAccumulatedErrors.name, expected string"+
+		"\n\t * validation error at StructWithAccumulatedErrors:\n\t * end (0) must not be before start (5)")
+	assert.Assert(t, errors.As(err, &validation.Error{}))
+
+	// The validator is not reported if it repeats the error of a field.
+	_, err = deserializer.DeserializeString(`{"name": "abc", "email": "abc", "start": 1, "end": 2}`)
+	assert.Equal(t, strings.Count(err.Error(), "invalid email"), 1, err.Error())
+
+	// Other errors are reported, even if their message appears in the error of a field.
+	terse, err := deserialize.MakeMapDeserializer[StructWithTerseValidator](options)
+	assert.NilError(t, err)
+	_, err = terse.DeserializeString(`{}`)
+	assert.Error(t, err, "missing value at StructWithTerseValidator.name, expected string"+
+		"\n\t * validation error at StructWithTerseValidator:\n\t * expected string")
+
+	// Structs without validator, too.
+	type Point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	points, err := deserialize.MakeMapDeserializer[Point](options)
+	assert.NilError(t, err)
+	_, err = points.DeserializeString(`{}`)
+	assert.Error(t, err, "missing value at Point.x, expected int\n\t * missing value at Point.y, expected int")
+
+	// By default, we stop at the first error.
+	deserializer, err = deserialize.MakeMapDeserializer[StructWithAccumulatedErrors](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	_, err = deserializer.DeserializeString(`{"start": 5, "end": "two"}`)
+	assert.Assert(t, !strings.Contains(err.Error(), "must not be before start"), err.Error())
+	assert.Assert(t, !strings.Contains(err.Error(), "\n\t * missing value"), err.Error())
+}
+
+// ------ Test that `DeserializeDictInto` overwrites a value provided by the caller.
+
+type ReusedRequest struct {
+	Name   string            `json:"name"`
+	Limit  int               `json:"limit" default:"10"`
+	Tags   []string          `json:"tags" default:"[]"`
+	Labels map[string]string `json:"labels" default:"{}"`
+}
+
+type ReusedQuery struct {
+	Name  string   `query:"name"`
+	Limit int      `query:"limit" default:"10"`
+	Tags  []string `query:"tags" default:"[]"`
+}
+
+type ReusedValidatedRequest struct {
+	Name  string `json:"name"`
+	Limit int    `json:"limit" default:"10"`
+}
+
+func (r *ReusedValidatedRequest) Validate() error {
+	if r.Limit > 100 {
+		return errors.New("limit too large")
+	}
+	return nil
+}
+
+func TestDeserializeDictInto(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[ReusedRequest](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	out := ReusedRequest{}
+	err = deserializer.DeserializeDictInto(jsonPkg.JSON{"name": "abc", "limit": 20.0, "tags": []any{"a"}, "labels": map[string]any{"b": "c"}}, &out)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, ReusedRequest{Name: "abc", Limit: 20, Tags: []string{"a"}, Labels: map[string]string{"b": "c"}})
+
+	// Previous contents do not leak into the next call.
+	err = deserializer.DeserializeDictInto(jsonPkg.JSON{"name": "def"}, &out)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, out, ReusedRequest{Name: "def", Limit: 10, Tags: []string{}, Labels: map[string]string{}})
+
+	_, err = deserializer.DeserializeString(`{}`)
+	assert.ErrorContains(t, err, "missing value at ReusedRequest.name")
+	err = deserializer.DeserializeDictInto(jsonPkg.JSON{}, &out)
+	assert.ErrorContains(t, err, "missing value at ReusedRequest.name")
+
+	err = deserializer.DeserializeDictInto(jsonPkg.JSON{"name": "def"}, nil)
+	assert.ErrorContains(t, err, "cannot deserialize into a nil pointer")
+
+	// Same thing with validation.
+	validated, err := deserialize.MakeMapDeserializer[ReusedValidatedRequest](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	outValidated := ReusedValidatedRequest{Name: "old", Limit: 50}
+	err = validated.DeserializeDictInto(jsonPkg.JSON{"name": "abc"}, &outValidated)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, outValidated, ReusedValidatedRequest{Name: "abc", Limit: 10})
+	err = validated.DeserializeDictInto(jsonPkg.JSON{"name": "abc", "limit": 200.0}, &outValidated)
+	assert.ErrorContains(t, err, "limit too large")
+
+	// Query strings.
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[ReusedQuery](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	outQuery := ReusedQuery{}
+	err = kvDeserializer.DeserializeKVListInto(kvlist.KVList{"name": []string{"abc"}, "limit": []string{"20"}, "tags": []string{"a", "b"}}, &outQuery)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, outQuery, ReusedQuery{Name: "abc", Limit: 20, Tags: []string{"a", "b"}})
+	err = kvDeserializer.DeserializeKVListInto(kvlist.KVList{"name": []string{"def"}}, &outQuery)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, outQuery, ReusedQuery{Name: "def", Limit: 10, Tags: []string{}})
+}
+
+// ------ Test that missing values can be distinguished from invalid values.
+
+type MissingInner struct {
+	Value int `json:"value"`
+}
+
+type MissingOuter struct {
+	Name   string            `json:"name"`
+	Inner  MissingInner      `json:"inner"`
+	Labels map[string]string `json:"labels"`
+	Tags   []string          `json:"tags"`
+}
+
+func TestMissingFieldError(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[MissingOuter](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	full := func() jsonPkg.JSON {
+		return jsonPkg.JSON{
+			"name":   "abc",
+			"inner":  map[string]any{"value": 1.0},
+			"labels": map[string]any{},
+			"tags":   []any{},
+		}
+	}
+	missingErr := deserialize.MissingFieldError{}
+
+	// Flat value.
+	input := full()
+	delete(input, "name")
+	_, err = deserializer.DeserializeDict(input)
+	assert.Equal(t, err.Error(), "missing value at MissingOuter.name, expected string")
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "MissingOuter.name")
+	assert.Equal(t, missingErr.Type, "string")
+
+	// Nested flat value.
+	input = full()
+	input["inner"] = map[string]any{}
+	_, err = deserializer.DeserializeDict(input)
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "MissingOuter.inner.value")
+	assert.Equal(t, missingErr.Type, "int")
+
+	// Struct.
+	input = full()
+	delete(input, "inner")
+	_, err = deserializer.DeserializeDict(input)
+	assert.Equal(t, err.Error(), "missing object value at MissingOuter.inner, expected MissingInner")
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "MissingOuter.inner")
+	assert.Equal(t, missingErr.Type, "MissingInner")
+
+	// Map.
+	input = full()
+	delete(input, "labels")
+	_, err = deserializer.DeserializeDict(input)
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "MissingOuter.labels")
+
+	// Slice.
+	input = full()
+	delete(input, "tags")
+	_, err = deserializer.DeserializeDict(input)
+	assert.Equal(t, err.Error(), "missing value at MissingOuter.tags[], expected an array of MissingOuter.tags")
+	assert.Assert(t, errors.As(err, &missingErr))
+	assert.Equal(t, missingErr.Path, "MissingOuter.tags")
+	assert.Equal(t, missingErr.Type, "[]string")
+
+	// Invalid values are not missing values.
+	input = full()
+	input["name"] = 123.0
+	_, err = deserializer.DeserializeDict(input)
+	assert.ErrorContains(t, err, "MissingOuter.name")
+	assert.Assert(t, !errors.As(err, &missingErr))
+}
+
+// ------ Test that invalid values can be distinguished from missing values.
+
+func TestTypeMismatchError(t *testing.T) {
+	deserializer, err := deserialize.MakeMapDeserializer[MissingOuter](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	full := func() jsonPkg.JSON {
+		return jsonPkg.JSON{
+			"name":   "abc",
+			"inner":  map[string]any{"value": 1.0},
+			"labels": map[string]any{},
+			"tags":   []any{},
+		}
+	}
+	mismatchErr := deserialize.TypeMismatchError{}
+
+	// Flat value.
+	input := full()
+	input["name"] = 123.0
+	_, err = deserializer.DeserializeDict(input)
+	assert.Equal(t, err.Error(), "invalid value at MissingOuter.name, expected string, got 123")
+	assert.Assert(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, mismatchErr.Path, "MissingOuter.name")
+	assert.Equal(t, mismatchErr.Expected, "string")
+	assert.Equal(t, mismatchErr.GotKind, "float64")
+	assert.Equal(t, mismatchErr.GotValue, 123.0)
+
+	// Nested flat value.
+	input = full()
+	input["inner"] = map[string]any{"value": "abc"}
+	_, err = deserializer.DeserializeDict(input)
+	assert.Assert(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, mismatchErr.Path, "MissingOuter.inner.value")
+	assert.Equal(t, mismatchErr.Expected, "int")
+	assert.Equal(t, mismatchErr.GotKind, "string")
+
+	// Struct.
+	input = full()
+	input["inner"] = "abc"
+	_, err = deserializer.DeserializeDict(input)
+	assert.Equal(t, err.Error(), "invalid value at MissingOuter.inner, expected an object of type MissingInner, got string")
+	assert.Assert(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, mismatchErr.Path, "MissingOuter.inner")
+	assert.Equal(t, mismatchErr.Expected, "MissingInner")
+	assert.Equal(t, mismatchErr.GotKind, "string")
+	assert.Equal(t, mismatchErr.GotValue, "abc")
+
+	// Map.
+	input = full()
+	input["labels"] = []any{}
+	_, err = deserializer.DeserializeDict(input)
+	assert.Assert(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, mismatchErr.Path, "MissingOuter.labels")
+	assert.Equal(t, mismatchErr.GotKind, "slice")
+
+	// Slice.
+	input = full()
+	input["tags"] = "abc"
+	_, err = deserializer.DeserializeDict(input)
+	assert.Equal(t, err.Error(), "invalid value at MissingOuter.tags, expected an array of type []string, got abc")
+	assert.Assert(t, errors.As(err, &mismatchErr))
+	assert.Equal(t, mismatchErr.Path, "MissingOuter.tags")
+	assert.Equal(t, mismatchErr.Expected, "[]string")
+	assert.Equal(t, mismatchErr.GotKind, "string")
+
+	// Missing values are not invalid values.
+	input = full()
+	delete(input, "name")
+	_, err = deserializer.DeserializeDict(input)
+	assert.Assert(t, !errors.As(err, &mismatchErr))
+}
@@ -0,0 +1,112 @@
+package deserialize
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// The key of a compiled dynamic field.
+type dynamicFieldKey struct {
+	name string
+	typ  reflect.Type
+}
+
+// Deserializers for the dynamic fields of a struct implementing `shared.DynamicFields`.
+//
+// As the schema is only known at runtime, fields are compiled on first use.
+type dynamicFieldsDeserializer struct {
+	// The human-readable path to the struct, used for error-reporting.
+	path string
+
+	// The keys of the regular fields of the struct, which dynamic fields may not shadow.
+	knownFields map[string]struct{}
+
+	options innerOptions
+
+	// The compiled deserializers, by `dynamicFieldKey`.
+	compiled sync.Map
+
+	// Held while compiling, as drivers are not required to support concurrent compilation.
+	compiling sync.Mutex
+}
+
+func makeDynamicFieldsDeserializer(path string, options innerOptions, knownFields map[string]struct{}) *dynamicFieldsDeserializer {
+	return &dynamicFieldsDeserializer{
+		path:        path,
+		knownFields: knownFields,
+		options:     options,
+		compiled:    sync.Map{},
+		compiling:   sync.Mutex{},
+	}
+}
+
+// Find or compile the deserializer for dynamic field `name` of type `typ`.
+func (d *dynamicFieldsDeserializer) deserializer(name string, typ reflect.Type) (reflectDeserializer, error) {
+	key := dynamicFieldKey{name: name, typ: typ}
+	if found, ok := d.compiled.Load(key); ok {
+		if result, ok := found.(reflectDeserializer); ok {
+			return result, nil
+		}
+	}
+	d.compiling.Lock()
+	defer d.compiling.Unlock()
+	fieldPath := fmt.Sprint(d.path, ".", name)
+	noTags := tagsPkg.Empty()
+	result, err := makeFieldDeserializerFromReflect(fieldPath, typ, d.options, &noTags, reflect.New(typ).Elem(), false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a deserializer for dynamic field %s\n\t * %w", fieldPath, err)
+	}
+	d.compiled.Store(key, result)
+	return result, nil
+}
+
+// Deserialize the dynamic fields declared by `resultPtr` from `inMap`
+// and pass them to `resultPtr`.
+//
+// Return the dynamic keys, so that they are not considered unknown.
+func (d *dynamicFieldsDeserializer) deserialize(resultPtr reflect.Value, inMap shared.Dict, call *callData) (map[string]struct{}, error) {
+	dynamic, ok := resultPtr.Interface().(shared.DynamicFields)
+	if !ok {
+		panic("we have already checked that the result can be converted to `DynamicFields` but conversion has failed")
+	}
+	schema := dynamic.Schema()
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	keys := make(map[string]struct{}, len(schema))
+	values := make(map[string]any, len(schema))
+	for _, name := range names {
+		typ := schema[name]
+		if _, ok := d.knownFields[name]; ok {
+			return nil, fmt.Errorf("at %s, dynamic field %s conflicts with a field of the same name", d.path, name)
+		}
+		if typ == nil {
+			return nil, fmt.Errorf("at %s, dynamic field %s has no type", d.path, name)
+		}
+		deserializer, err := d.deserializer(name, typ)
+		if err != nil {
+			return nil, err
+		}
+		inValue, ok := inMap.Lookup(name)
+		if !ok {
+			inValue = nil
+		}
+		slot := reflect.New(typ).Elem()
+		err = deserializer(&slot, inValue, call)
+		if err != nil {
+			return nil, err
+		}
+		keys[name] = struct{}{}
+		values[name] = slot.Interface()
+	}
+	dynamic.SetDynamicFields(values)
+	return keys, nil
+}
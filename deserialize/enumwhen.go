@@ -0,0 +1,138 @@
+package deserialize
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// A rule specified with tag `enumWhen`, e.g. `unit=temp:[C,F,K]`.
+type enumWhenRule struct {
+	// The public name of the sibling field, e.g. `unit`.
+	sibling string
+
+	// The native name of the sibling field, resolved once all fields are known.
+	siblingNativeName string
+
+	// The value of the sibling field for which this rule applies, e.g. `temp`.
+	when string
+
+	// The values accepted for this field if the rule applies, e.g. `C`, `F`, `K`.
+	allowed []string
+}
+
+// The rules specified with tag `enumWhen` for a field.
+type enumWhenCheck struct {
+	// The native name of the field.
+	fieldNativeName string
+
+	// The human-readable path to the field, used for error-reporting.
+	fieldPath string
+
+	rules []enumWhenRule
+}
+
+// Parse the rules specified with tag `enumWhen`, e.g. `enumWhen:"unit=temp:[C,F,K];unit=length:[m,km]"`.
+//
+// Returns `nil` if there is no such tag.
+//
+// Siblings are not resolved yet, see `resolve`.
+func parseEnumWhen(fieldPath string, fieldNativeName string, tags *tagsPkg.Tags) (*enumWhenCheck, error) {
+	source := tags.EnumWhen()
+	if source == nil {
+		return nil, nil
+	}
+	check := enumWhenCheck{
+		fieldNativeName: fieldNativeName,
+		fieldPath:       fieldPath,
+		rules:           []enumWhenRule{},
+	}
+	for _, ruleSource := range strings.Split(*source, ";") {
+		ruleSource = strings.TrimSpace(ruleSource)
+		if ruleSource == "" {
+			continue
+		}
+		condition, list, ok := strings.Cut(ruleSource, ":")
+		if !ok {
+			return nil, fmt.Errorf("at %s, invalid `enumWhen` rule %q, expected `field=value:[a,b,...]`", fieldPath, ruleSource)
+		}
+		sibling, when, ok := strings.Cut(condition, "=")
+		sibling = strings.TrimSpace(sibling)
+		if !ok || sibling == "" {
+			return nil, fmt.Errorf("at %s, invalid `enumWhen` rule %q, expected `field=value:[a,b,...]`", fieldPath, ruleSource)
+		}
+		list = strings.TrimSpace(list)
+		if !strings.HasPrefix(list, "[") || !strings.HasSuffix(list, "]") {
+			return nil, fmt.Errorf("at %s, invalid `enumWhen` rule %q, expected a list such as `[a,b,...]`", fieldPath, ruleSource)
+		}
+		allowed := []string{}
+		for _, value := range strings.Split(list[1:len(list)-1], ",") {
+			value = strings.TrimSpace(value)
+			if value != "" {
+				allowed = append(allowed, value)
+			}
+		}
+		check.rules = append(check.rules, enumWhenRule{
+			sibling:           sibling,
+			siblingNativeName: "",
+			when:              strings.TrimSpace(when),
+			allowed:           allowed,
+		})
+	}
+	return &check, nil
+}
+
+// Resolve the siblings referenced by the rules.
+//
+// `nativeNames` maps the public name of each (non-flattened) field of the struct to its native name.
+func (check *enumWhenCheck) resolve(nativeNames map[string]string) error {
+	for i, rule := range check.rules {
+		nativeName, ok := nativeNames[rule.sibling]
+		if !ok {
+			return fmt.Errorf("at %s, invalid `enumWhen` rule, there is no field %s in this struct", check.fieldPath, rule.sibling)
+		}
+		if nativeName == check.fieldNativeName {
+			return fmt.Errorf("at %s, invalid `enumWhen` rule, a field cannot depend on itself", check.fieldPath)
+		}
+		check.rules[i].siblingNativeName = nativeName
+	}
+	return nil
+}
+
+// Check the value of the field against the rules, once the struct has been populated.
+func (check *enumWhenCheck) check(result reflect.Value) error {
+	value, ok := enumWhenString(result.FieldByName(check.fieldNativeName))
+	if !ok {
+		// No value, nothing to check.
+		return nil
+	}
+	for _, rule := range check.rules {
+		siblingValue, ok := enumWhenString(result.FieldByName(rule.siblingNativeName))
+		if !ok || siblingValue != rule.when {
+			continue
+		}
+		if !slices.Contains(rule.allowed, value) {
+			return fmt.Errorf("expected one of %s when %s is %s, got %s", strings.Join(rule.allowed, ", "), rule.sibling, rule.when, value)
+		}
+	}
+	return nil
+}
+
+// Represent a value as a string, for comparison with the values of rules.
+//
+// Returns `false` for `nil` pointers and interfaces.
+func enumWhenString(value reflect.Value) (string, bool) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return "", false
+		}
+		value = value.Elem()
+	}
+	if !value.CanInterface() {
+		return "", false
+	}
+	return fmt.Sprint(value.Interface()), true
+}
@@ -0,0 +1,47 @@
+// Code specific to deserializing environment variables.
+package env
+
+import (
+	"strings"
+
+	"github.com/pasqal-io/godasse/deserialize/kvlist"
+	"github.com/pasqal-io/godasse/deserialize/shared"
+)
+
+// The default separator used to split environment variables
+// into lists, e.g. `MYAPP_HOSTS=a,b,c`.
+const DefaultSeparator = ","
+
+// The deserialization driver for environment variables.
+//
+// Environment variables are flat (key, value) pairs, so we follow
+// the same rules as KVList.
+func Driver() shared.Driver {
+	return kvlist.Driver()
+}
+
+// Convert a list of environment variables, as provided by `os.Environ()`,
+// into a KVList.
+//
+// Only variables whose name starts with `prefix` are kept and `prefix`
+// is stripped from their name, so that with prefix `MYAPP_`, variable
+// `MYAPP_PORT` is stored as `PORT`.
+//
+// Each variable is stored as a single value, splitting lists is left
+// to the deserializer.
+func Environ(environ []string, prefix string) kvlist.KVList {
+	result := make(kvlist.KVList)
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			// Malformed entry, skip it.
+			continue
+		}
+		key, ok = strings.CutPrefix(key, prefix)
+		if !ok || key == "" {
+			continue
+		}
+		result[key] = []string{value}
+	}
+	return result
+}
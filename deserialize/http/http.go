@@ -0,0 +1,94 @@
+// Helpers to deserialize the contents of a `net/http` request.
+//
+// Each call builds a deserializer. As deserializers are cached, this is
+// cheap for most options, but if you use e.g. `Options.Parsers`, prefer
+// building your deserializers once with `deserialize.MakeMapDeserializer`
+// or `deserialize.MakeKVListDeserializer`.
+package http
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pasqal-io/godasse/deserialize"
+	jsonPkg "github.com/pasqal-io/godasse/deserialize/json"
+	"github.com/pasqal-io/godasse/deserialize/kvlist"
+	yamlPkg "github.com/pasqal-io/godasse/deserialize/yaml"
+)
+
+// Deserialize the body of a request.
+//
+// If `options.Unmarshaler` is specified, it is used regardless of the
+// `Content-Type` of the request. Otherwise, the unmarshaler is picked from
+// the `Content-Type`:
+//
+//   - JSON for `application/json`, `application/*+json` or if there is no `Content-Type`;
+//   - YAML for `application/yaml`, `application/x-yaml` or `text/yaml`.
+//
+// Other content types are rejected. If `options.MainTagName` is not
+// specified, it defaults to "json" or "yaml" accordingly.
+//
+// Errors raised during deserialization are returned unchanged.
+func FromRequestBody[T any](request *http.Request, options deserialize.Options) (*T, error) {
+	if options.Unmarshaler == nil {
+		err := sniffContentType(request, &options)
+		if err != nil {
+			return nil, err
+		}
+	}
+	deserializer, err := deserialize.MakeMapDeserializer[T](options)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return deserializer.DeserializeReader(request.Body) //nolint:wrapcheck
+}
+
+// Deserialize the query string of a request.
+//
+// If `options.Unmarshaler` is not specified, it defaults to KVList. If
+// `options.MainTagName` is not specified, it defaults to "query", as
+// with `deserialize.QueryOptions`.
+//
+// Errors raised during deserialization are returned unchanged.
+func FromRequestQuery[T any](request *http.Request, options deserialize.Options) (*T, error) {
+	if options.Unmarshaler == nil {
+		options.Unmarshaler = kvlist.Driver
+	}
+	if options.MainTagName == "" {
+		options.MainTagName = "query"
+	}
+	deserializer, err := deserialize.MakeKVListDeserializer[T](options)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	return deserializer.DeserializeKVList(kvlist.KVList(request.URL.Query())) //nolint:wrapcheck
+}
+
+// Pick the unmarshaler and default tag name from the `Content-Type` of `request`.
+func sniffContentType(request *http.Request, options *deserialize.Options) error {
+	contentType := request.Header.Get("Content-Type")
+	tagName := deserialize.JSON
+	if contentType == "" {
+		options.Unmarshaler = jsonPkg.Driver
+	} else {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return fmt.Errorf("invalid Content-Type %s\n\t * %w", contentType, err)
+		}
+		switch {
+		case mediaType == "application/json" || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"):
+			options.Unmarshaler = jsonPkg.Driver
+		case mediaType == "application/yaml" || mediaType == "application/x-yaml" || mediaType == "text/yaml":
+			options.Unmarshaler = yamlPkg.Driver
+			tagName = "yaml"
+		default:
+			return fmt.Errorf("unsupported Content-Type %s, expected JSON or YAML", mediaType)
+		}
+	}
+	if options.MainTagName == "" {
+		options.MainTagName = tagName
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package http_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pasqal-io/godasse/deserialize"
+	httpPkg "github.com/pasqal-io/godasse/deserialize/http"
+	"gotest.tools/v3/assert"
+)
+
+type Body struct {
+	Name  string `json:"name" yaml:"name"`
+	Count int    `json:"count" yaml:"count" default:"1"`
+}
+
+type Query struct {
+	Page  int    `query:"page" default:"1"`
+	Order string `query:"order"`
+}
+
+func TestFromRequestBody(t *testing.T) {
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "abc", "count": 3}`))
+	request.Header.Set("Content-Type", "application/json; charset=utf-8")
+	found, err := httpPkg.FromRequestBody[Body](request, deserialize.Options{}) //nolint:exhaustruct
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Body{Name: "abc", Count: 3})
+
+	// Without a Content-Type, we assume JSON.
+	request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "abc"}`))
+	found, err = httpPkg.FromRequestBody[Body](request, deserialize.Options{}) //nolint:exhaustruct
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Body{Name: "abc", Count: 1})
+
+	request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name: abc\ncount: 4\n"))
+	request.Header.Set("Content-Type", "application/yaml")
+	found, err = httpPkg.FromRequestBody[Body](request, deserialize.Options{}) //nolint:exhaustruct
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Body{Name: "abc", Count: 4})
+
+	request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "abc"}`))
+	request.Header.Set("Content-Type", "application/vnd.api+json")
+	_, err = httpPkg.FromRequestBody[Body](request, deserialize.Options{}) //nolint:exhaustruct
+	assert.NilError(t, err)
+
+	// An explicit unmarshaler takes precedence over the Content-Type.
+	request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name": "abc"}`))
+	request.Header.Set("Content-Type", "text/plain")
+	_, err = httpPkg.FromRequestBody[Body](request, deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+
+	request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`name=abc`))
+	request.Header.Set("Content-Type", "text/plain")
+	_, err = httpPkg.FromRequestBody[Body](request, deserialize.Options{}) //nolint:exhaustruct
+	assert.Error(t, err, "unsupported Content-Type text/plain, expected JSON or YAML")
+
+	// Errors are returned unchanged.
+	request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"count": 3}`))
+	_, err = httpPkg.FromRequestBody[Body](request, deserialize.JSONOptions("POST /"))
+	assert.Error(t, err, "missing value at POST /.Body.name, expected string")
+}
+
+func TestFromRequestQuery(t *testing.T) {
+	request := httptest.NewRequest(http.MethodGet, "/?page=3&order=asc", nil)
+	found, err := httpPkg.FromRequestQuery[Query](request, deserialize.Options{}) //nolint:exhaustruct
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Query{Page: 3, Order: "asc"})
+
+	request = httptest.NewRequest(http.MethodGet, "/?order=desc", nil)
+	found, err = httpPkg.FromRequestQuery[Query](request, deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, Query{Page: 1, Order: "desc"})
+
+	request = httptest.NewRequest(http.MethodGet, "/?page=three&order=asc", nil)
+	_, err = httpPkg.FromRequestQuery[Query](request, deserialize.QueryOptions("GET /"))
+	assert.ErrorContains(t, err, "GET /.Query.page")
+}
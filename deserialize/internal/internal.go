@@ -35,3 +35,75 @@ func (empty EmptyDict) Keys() []string {
 }
 
 var _ shared.Dict = EmptyDict{}
+
+// An implementation of shared.Dict on top of a list of
+// dictionaries, by order of priority.
+//
+// Looking up a key returns the value from the first dictionary
+// that contains this key. Values are never merged, so if the
+// value is itself a dictionary, it is taken as a whole from that
+// first dictionary.
+type LayeredDict struct {
+	layers []shared.Dict
+}
+
+func MakeLayeredDict(layers []shared.Dict) LayeredDict {
+	return LayeredDict{
+		layers: layers,
+	}
+}
+
+func (layered LayeredDict) Lookup(key string) (shared.Value, bool) {
+	for _, layer := range layered.layers {
+		if value, ok := layer.Lookup(key); ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+func (layered LayeredDict) AsValue() shared.Value {
+	return LayeredValue{
+		dict: layered,
+	}
+}
+func (layered LayeredDict) Keys() []string {
+	keys := []string{}
+	seen := make(map[string]struct{})
+	for _, layer := range layered.layers {
+		for _, key := range layer.Keys() {
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+var _ shared.Dict = LayeredDict{} //nolint:exhaustruct
+
+// The shared.Value for a LayeredDict.
+type LayeredValue struct {
+	dict LayeredDict
+}
+
+func (value LayeredValue) AsDict() (shared.Dict, bool) {
+	return value.dict, true
+}
+func (value LayeredValue) AsSlice() ([]shared.Value, bool) {
+	return nil, false
+}
+
+// Flatten the layers into a single map, by order of priority.
+func (value LayeredValue) Interface() any {
+	result := make(map[string]any)
+	for _, key := range value.dict.Keys() {
+		if found, ok := value.dict.Lookup(key); ok {
+			result[key] = found.Interface()
+		}
+	}
+	return result
+}
+
+var _ shared.Value = LayeredValue{} //nolint:exhaustruct
@@ -2,20 +2,69 @@
 package json
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
 
 	"github.com/pasqal-io/godasse/deserialize/shared"
 )
 
 // The deserialization driver for JSON.
-type driver struct{}
+type driver struct {
+	// If `true`, decode numbers as `json.Number` rather than `float64`.
+	useNumber bool
+
+	// If `true`, reject objects that contain the same key several times.
+	rejectDuplicateKeys bool
+
+	// If `true`, decoded values remember the bytes they were decoded from.
+	keepRaw bool
+}
 
 func Driver() shared.Driver {
-	return driver{}
+	return driver{
+		useNumber:           false,
+		rejectDuplicateKeys: false,
+		keepRaw:             false,
+	}
+}
+
+// Return a driver that decodes numbers as `json.Number`.
+//
+// This preserves integers that do not fit in a `float64`, e.g. IDs
+// above 2^53.
+//
+// You probably won't ever need to call this method, use `Options.UseNumber`.
+func (u driver) WithUseNumber() shared.Driver {
+	u.useNumber = true
+	return u
+}
+
+// Return a driver that rejects objects containing the same key several times.
+//
+// By default, as `encoding/json`, the last value wins, which may hide bugs
+// or let a proxy and a backend disagree on the contents of a request.
+//
+// You probably won't ever need to call this method, use `Options.RejectDuplicateKeys`.
+func (u driver) WithRejectDuplicateKeys() shared.Driver {
+	u.rejectDuplicateKeys = true
+	return u
+}
+
+// Return a driver whose decoded values remember the bytes they were
+// decoded from, see `Value.Raw`.
+//
+// This is slower than the default decoding, so deserializers only use
+// it if they contain fields of type `json.RawMessage` or `shared.RawCapture`.
+//
+// You probably won't ever need to call this method.
+func (u driver) WithKeepRaw() shared.Driver {
+	u.keepRaw = true
+	return u
 }
 
 // A JSON value.
@@ -28,6 +77,8 @@ type JSON map[string]any
 
 func (v Value) AsDict() (shared.Dict, bool) {
 	switch t := v.wrapped.(type) {
+	case *node:
+		return Value{wrapped: t.value}.AsDict()
 	case JSON:
 		return t, true
 	case map[string]any:
@@ -41,6 +92,9 @@ func (v Value) AsDict() (shared.Dict, bool) {
 	}
 }
 func (v Value) AsSlice() ([]shared.Value, bool) {
+	if n, ok := v.wrapped.(*node); ok {
+		return Value{wrapped: n.value}.AsSlice()
+	}
 	// We can't simply cast to `[]any`, as this doesn't work for e.g. `[]string`.
 	reflected := reflect.ValueOf(v.wrapped)
 	switch reflected.Type().Kind() {
@@ -59,10 +113,20 @@ func (v Value) AsSlice() ([]shared.Value, bool) {
 	}
 }
 func (v Value) Interface() any {
-	return v.wrapped
+	return stripNodes(v.wrapped)
 }
 
-var _ shared.Value = Value{} //nolint:exhaustruct
+// The bytes this value was decoded from.
+//
+// Only available for values decoded by a driver built with `WithKeepRaw`.
+func (v Value) Raw() ([]byte, bool) {
+	if n, ok := v.wrapped.(*node); ok {
+		return n.raw, true
+	}
+	return nil, false
+}
+
+var _ shared.RawValue = Value{} //nolint:exhaustruct
 
 func (json JSON) Lookup(key string) (shared.Value, bool) {
 	if val, ok := json[key]; ok {
@@ -133,6 +197,8 @@ func (u driver) Unmarshal(in any, out *any) (err error) {
 		buf = []byte(typed)
 	case []byte:
 		buf = typed
+	case *node:
+		buf = typed.raw
 	// Unwrap Value.
 	case Value:
 		return u.Unmarshal(typed.wrapped, out)
@@ -151,9 +217,22 @@ func (u driver) Unmarshal(in any, out *any) (err error) {
 		return fmt.Errorf("expected a string, got %s", in)
 	}
 
+	if u.rejectDuplicateKeys {
+		if err = checkDuplicateKeys(buf); err != nil {
+			return fmt.Errorf("failed to unmarshal '%s': \n\t * %w", buf, err)
+		}
+	}
+
 	// Attempt to deserialize as a `json.Unmarshaler`.
 	if unmarshal, ok := (*out).(json.Unmarshaler); ok {
 		err = unmarshal.UnmarshalJSON(buf)
+	} else if u.keepRaw && *out == nil {
+		var decoded *node
+		if decoded, err = u.decodeKeepingRaw(buf); err == nil {
+			*out = decoded
+		}
+	} else if u.useNumber {
+		err = u.decode(bytes.NewReader(buf), out)
 	} else {
 		err = json.Unmarshal(buf, out)
 	}
@@ -175,6 +254,124 @@ func (u driver) Unmarshal(in any, out *any) (err error) {
 	return fmt.Errorf("failed to unmarshal '%s': \n\t * %w", buf, err)
 }
 
+// Perform unmarshaling from a stream.
+//
+// By opposition to `Unmarshal`, this does not require buffering
+// the entire input.
+//
+// You probably won't ever need to call this method.
+func (u driver) UnmarshalReader(in io.Reader, out *any) error {
+	if u.keepRaw {
+		// The raw bytes are slices of the input, so we need to buffer it.
+		buf, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("failed to read stream: \n\t * %w", err)
+		}
+		return u.Unmarshal(buf, out)
+	}
+	if u.rejectDuplicateKeys {
+		// Detecting duplicate keys requires a pass of its own, so we need
+		// to buffer the input.
+		buf, err := io.ReadAll(in)
+		if err != nil {
+			return fmt.Errorf("failed to read stream: \n\t * %w", err)
+		}
+		if err = checkDuplicateKeys(buf); err != nil {
+			return fmt.Errorf("failed to unmarshal stream: \n\t * %w", err)
+		}
+		in = bytes.NewReader(buf)
+	}
+	if err := u.decode(in, out); err != nil {
+		return fmt.Errorf("failed to unmarshal stream: \n\t * %w", err)
+	}
+	return nil
+}
+
+// Decode a single value from a stream, rejecting trailing data.
+func (u driver) decode(in io.Reader, out *any) error {
+	decoder := json.NewDecoder(in)
+	if u.useNumber {
+		decoder.UseNumber()
+	}
+	if err := decoder.Decode(out); err != nil {
+		return err //nolint:wrapcheck
+	}
+	// Just as `json.Unmarshal`, reject trailing data.
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
+
+// The error returned when an object contains the same key several times.
+var errDuplicateKey = errors.New("duplicate key")
+
+// Check that no object in `buf` contains the same key several times.
+//
+// Syntax errors are ignored, they are reported by the actual decoding.
+func checkDuplicateKeys(buf []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	err := checkDuplicateKeysAt(decoder, "$")
+	if errors.Is(err, errDuplicateKey) {
+		return err
+	}
+	return nil
+}
+
+// Check a single value from `decoder`, recursively.
+//
+// `path` is the JSONPath of the value, used for error-reporting.
+func checkDuplicateKeysAt(decoder *json.Decoder, path string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		// A scalar, nothing to check.
+		return nil
+	}
+	switch delim {
+	case '{':
+		seen := make(map[string]struct{})
+		for decoder.More() {
+			token, err = decoder.Token()
+			if err != nil {
+				return err //nolint:wrapcheck
+			}
+			key, ok := token.(string)
+			if !ok {
+				return fmt.Errorf("at %s, expected a key, got %v", path, token)
+			}
+			if _, ok := seen[key]; ok {
+				return fmt.Errorf("%w %s at %s", errDuplicateKey, key, path)
+			}
+			seen[key] = struct{}{}
+			if err = checkDuplicateKeysAt(decoder, path+"."+key); err != nil {
+				return err
+			}
+		}
+	case '[':
+		for i := 0; decoder.More(); i++ {
+			if err = checkDuplicateKeysAt(decoder, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("at %s, unexpected %v", path, delim)
+	}
+	// Consume the closing delimiter.
+	_, err = decoder.Token()
+	return err //nolint:wrapcheck
+}
+
+// Perform marshaling.
+//
+// You probably won't ever need to call this method.
+func (driver) Marshal(in any) ([]byte, error) {
+	return json.Marshal(in) //nolint:wrapcheck
+}
+
 func (driver) WrapValue(wrapped any) shared.Value {
 	return Value{
 		wrapped: wrapped,
@@ -189,4 +386,8 @@ func (driver) Exit(reflect.Type) {
 	// No particular protocol to follow.
 }
 
-var _ shared.Driver = driver{} // Type assertion.
+var _ shared.StreamingDriver = driver{}     //nolint:exhaustruct // Type assertion.
+var _ shared.NumberDriver = driver{}        //nolint:exhaustruct // Type assertion.
+var _ shared.DuplicateKeysDriver = driver{} //nolint:exhaustruct // Type assertion.
+var _ shared.MarshalingDriver = driver{}    //nolint:exhaustruct // Type assertion.
+var _ shared.RawDriver = driver{}           //nolint:exhaustruct // Type assertion.
@@ -0,0 +1,124 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// A decoded value, along with the bytes it was decoded from.
+//
+// Objects contain a `JSON` of `*node` and arrays a `[]any` of `*node`.
+type node struct {
+	value any
+	raw   json.RawMessage
+}
+
+// Encode the value exactly as it was received.
+func (n *node) MarshalJSON() ([]byte, error) {
+	return n.raw, nil
+}
+
+// Convert a tree of `*node` into the values `json.Unmarshal` would have produced.
+func stripNodes(value any) any {
+	n, ok := value.(*node)
+	if !ok {
+		return value
+	}
+	switch typed := n.value.(type) {
+	case JSON:
+		result := make(map[string]any, len(typed))
+		for k, v := range typed {
+			result[k] = stripNodes(v)
+		}
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for i, v := range typed {
+			result[i] = stripNodes(v)
+		}
+		return result
+	default:
+		return typed
+	}
+}
+
+// Decode `buf` into a tree of `*node`, rejecting trailing data.
+func (u driver) decodeKeepingRaw(buf []byte) (*node, error) {
+	decoder := json.NewDecoder(bytes.NewReader(buf))
+	if u.useNumber {
+		decoder.UseNumber()
+	}
+	result, err := decodeNode(decoder, buf)
+	if err != nil {
+		return nil, err
+	}
+	// Just as `json.Unmarshal`, reject trailing data.
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return result, nil
+}
+
+// Decode a single value from `decoder`, recursively.
+//
+// `buf` is the entire input of `decoder`.
+func decodeNode(decoder *json.Decoder, buf []byte) (*node, error) {
+	// The decoder stands at the end of the previous token, skip
+	// whitespace and separators to find the start of this value.
+	start := int(decoder.InputOffset())
+	for start < len(buf) && bytes.IndexByte([]byte(" \t\r\n,:"), buf[start]) != -1 {
+		start++
+	}
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	var value any
+	switch token {
+	case json.Delim('{'):
+		dict := make(JSON)
+		for decoder.More() {
+			token, err = decoder.Token()
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+			key, ok := token.(string)
+			if !ok {
+				return nil, fmt.Errorf("expected a key, got %v", token)
+			}
+			child, err := decodeNode(decoder, buf)
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = child
+		}
+		value = dict
+		// Consume the closing delimiter.
+		if _, err = decoder.Token(); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	case json.Delim('['):
+		slice := make([]any, 0)
+		for decoder.More() {
+			child, err := decodeNode(decoder, buf)
+			if err != nil {
+				return nil, err
+			}
+			slice = append(slice, child)
+		}
+		value = slice
+		// Consume the closing delimiter.
+		if _, err = decoder.Token(); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	default:
+		value = token
+	}
+	return &node{
+		value: value,
+		raw:   buf[start:decoder.InputOffset()],
+	}, nil
+}
@@ -0,0 +1,97 @@
+// Code specific to deserializing JSON5, i.e. JSON extended with comments,
+// trailing commas, unquoted keys, etc.
+//
+// Once decoded, JSON5 values are represented exactly as JSON values.
+package json5
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+
+	jsonPkg "github.com/pasqal-io/godasse/deserialize/json"
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	"github.com/titanous/json5"
+)
+
+// The deserialization driver for JSON5.
+type driver struct {
+	// The JSON driver, to which we delegate everything
+	// that doesn't require parsing JSON5.
+	json shared.Driver
+}
+
+func Driver() shared.Driver {
+	return driver{
+		json: jsonPkg.Driver(),
+	}
+}
+
+// Determine whether we should call the driver to unmarshal values
+// of this type from []byte.
+//
+// Same rules as JSON.
+//
+// You probably won't ever need to call this method.
+func (d driver) ShouldUnmarshal(typ reflect.Type) bool {
+	return d.json.ShouldUnmarshal(typ)
+}
+
+// Perform unmarshaling.
+//
+// You probably won't ever need to call this method.
+func (d driver) Unmarshal(in any, out *any) error {
+	var buf []byte
+	switch typed := in.(type) {
+	// Normalize string, []byte into []byte.
+	case string:
+		buf = []byte(typed)
+	case []byte:
+		buf = typed
+	default:
+		// Already decoded, this is the same thing as JSON.
+		return d.json.Unmarshal(in, out) //nolint:wrapcheck
+	}
+	err := json5.Unmarshal(buf, out)
+	if err == nil {
+		return nil
+	}
+	// As with JSON, some types serialize themselves as unencoded strings.
+	if textUnmarshaler, ok := (*out).(encoding.TextUnmarshaler); ok {
+		err2 := textUnmarshaler.UnmarshalText(buf)
+		if err2 == nil {
+			// Success! Let's use that result.
+			return nil
+		}
+		return fmt.Errorf("failed to unmarshal '%s' either from JSON5 or from text: \n\t * %w\n\t * and %w", buf, err, err2)
+	}
+	return fmt.Errorf("failed to unmarshal '%s': \n\t * %w", buf, err)
+}
+
+// Perform marshaling.
+//
+// JSON is valid JSON5, so this produces JSON.
+//
+// You probably won't ever need to call this method.
+func (d driver) Marshal(in any) ([]byte, error) {
+	marshaler, ok := d.json.(shared.MarshalingDriver)
+	if !ok {
+		return nil, errors.New("internal error: the JSON driver should support marshaling")
+	}
+	return marshaler.Marshal(in) //nolint:wrapcheck
+}
+
+func (d driver) WrapValue(wrapped any) shared.Value {
+	return d.json.WrapValue(wrapped)
+}
+
+func (driver) Enter(string, reflect.Type) error {
+	// No particular protocol to follow.
+	return nil
+}
+func (driver) Exit(reflect.Type) {
+	// No particular protocol to follow.
+}
+
+var _ shared.MarshalingDriver = driver{} //nolint:exhaustruct
@@ -13,9 +13,10 @@ import (
 //
 // The fields of this value are used only while building the deserializer.
 type driver struct {
-	// If non-nil, we have entered the root struct while building the deserializer
-	// and this points to the type of the root struct.
-	enteredStructAt *reflect.Type
+	// The structs we have entered while building the deserializer, starting
+	// with the root struct, followed by any nested struct, e.g. for keys
+	// `address.city`.
+	enteredStructs []reflect.Type
 
 	// If non-nil, we have entered a slice or array field within the root struct
 	// while building the deserializer and this points to the type of the slice or
@@ -30,9 +31,9 @@ type driver struct {
 
 func Driver() shared.Driver {
 	return &driver{
-		enteredStructAt: nil,
-		enteredSliceAt:  nil,
-		enteredLeafAt:   nil,
+		enteredStructs: []reflect.Type{},
+		enteredSliceAt: nil,
+		enteredLeafAt:  nil,
 	}
 }
 
@@ -241,34 +242,31 @@ func (u *driver) Enter(at string, typ reflect.Type) error {
 	}
 	switch {
 	// Initial state.
-	case u.enteredStructAt == nil:
+	case len(u.enteredStructs) == 0:
 		if kind != reflect.Struct {
 			return fmt.Errorf("KVList deserialization expects a struct, got %s", typ.String())
 		}
-		u.enteredStructAt = &typ
+		u.enteredStructs = append(u.enteredStructs, typ)
 	case u.enteredSliceAt == nil && u.enteredLeafAt == nil:
-		if u.enteredStructAt == nil {
-			panic("internal error: inconsistent state")
-		}
 		switch {
 		case canBeALeaf(typ):
 			u.enteredLeafAt = &typ
 		case kind == reflect.Array || kind == reflect.Slice:
 			u.enteredSliceAt = &typ
+		case kind == reflect.Struct:
+			// A nested struct, read from dotted keys.
+			u.enteredStructs = append(u.enteredStructs, typ)
 		default:
 			return fmt.Errorf("KVList deserialization expects a struct of slices of trivially deserializable types, but at %s, got %s", at, typ.String())
 		}
 	case u.enteredLeafAt == nil && u.enteredSliceAt != nil:
-		if u.enteredStructAt == nil {
-			panic("internal error: inconsistent state")
-		}
 		if canBeALeaf(typ) {
-			u.enteredSliceAt = &typ
+			u.enteredLeafAt = &typ
 		} else {
 			return fmt.Errorf("KVList deserialization expects a struct of slices of trivially deserializable types, but at %s, got %s", at, typ.String())
 		}
 	default:
-		if u.enteredStructAt == nil || u.enteredLeafAt == nil {
+		if u.enteredLeafAt == nil {
 			panic("internal error: inconsistent state")
 		}
 		// We're in a leaf, there isn't anything we can check.
@@ -288,8 +286,8 @@ func (u *driver) Exit(typ reflect.Type) {
 		u.enteredLeafAt = nil
 	case u.enteredSliceAt != nil && *u.enteredSliceAt == typ:
 		u.enteredSliceAt = nil
-	case u.enteredStructAt != nil && *u.enteredStructAt == typ:
-		u.enteredStructAt = nil
+	case len(u.enteredStructs) != 0 && u.enteredStructs[len(u.enteredStructs)-1] == typ:
+		u.enteredStructs = u.enteredStructs[:len(u.enteredStructs)-1]
 	}
 }
 
@@ -0,0 +1,49 @@
+package deserialize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The presets used by `LoadFile`, by file extension.
+var loadFileOptions = map[string]func(root string) Options{
+	".json":  JSONOptions,
+	".json5": JSON5Options,
+	".yaml":  YAMLOptions,
+	".yml":   YAMLOptions,
+}
+
+// Load a file, e.g. a configuration file, picking the format from its extension.
+//
+// Supported extensions are `.json` (with `JSONOptions`), `.json5` (with
+// `JSON5Options`) and `.yaml` or `.yml` (with `YAMLOptions`). Other
+// extensions are rejected.
+//
+// Note that the tag name depends on the format, so a type meant to be loaded
+// from both JSON and YAML needs both `json` and `yaml` tags.
+//
+// This builds a new deserializer at each call. If you load files of the same
+// type repeatedly, prefer building a deserializer once and calling
+// `DeserializeReader`.
+func LoadFile[T any](path string) (*T, error) {
+	makeOptions, ok := loadFileOptions[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return nil, fmt.Errorf("cannot load %s, unsupported file extension %q", path, filepath.Ext(path))
+	}
+	deserializer, err := MakeMapDeserializer[T](makeOptions(""))
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load %s\n\t * %w", path, err)
+	}
+	defer file.Close()
+	result, err := deserializer.DeserializeReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load %s\n\t * %w", path, err)
+	}
+	return result, nil
+}
@@ -0,0 +1,68 @@
+package deserialize
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/number"
+)
+
+// Construct a replacer converting numbers formatted for `locale`, e.g.
+// `1.234,5` in German, into numbers as expected by `strconv`, e.g. `1234.5`.
+//
+// We don't hardcode any separator: we ask `x/text` to format a few sample
+// numbers and deduce the digits, the minus sign and the separators.
+//
+// Returns `nil` for `language.Und`, i.e. no localization.
+func makeNumberLocalizer(locale language.Tag) *strings.Replacer {
+	if locale == language.Und {
+		return nil
+	}
+	printer := message.NewPrinter(locale)
+	pairs := []string{}
+
+	// Digits, e.g. `١` for `1` in Arabic.
+	digits := make(map[rune]rune, 10)
+	for d := 0; d <= 9; d++ {
+		formatted := []rune(printer.Sprint(number.Decimal(d)))
+		native := formatted[len(formatted)-1]
+		digits[native] = rune('0' + d)
+		if native != rune('0'+d) {
+			pairs = append(pairs, string(native), string(rune('0'+d)))
+		}
+	}
+	isDigit := func(r rune) bool {
+		_, ok := digits[r]
+		return ok
+	}
+
+	// The minus sign, i.e. whatever precedes `1` in `-1`.
+	minus := strings.TrimRightFunc(printer.Sprint(number.Decimal(-1)), isDigit)
+	if minus != "-" && minus != "" {
+		pairs = append(pairs, minus, "-")
+	}
+
+	// Separators, i.e. runs of non-digits in `1234567.5`. The last one
+	// is the decimal separator, the others are group separators.
+	separators := strings.FieldsFunc(printer.Sprint(number.Decimal(1234567.5)), isDigit)
+	if len(separators) == 0 {
+		return strings.NewReplacer(pairs...)
+	}
+	decimal := separators[len(separators)-1]
+	for _, group := range separators[:len(separators)-1] {
+		if group == decimal {
+			continue
+		}
+		pairs = append(pairs, group, "")
+		if strings.IndexFunc(group, unicode.IsSpace) != -1 {
+			// Users rarely type non-breaking spaces, accept any space.
+			pairs = append(pairs, " ", "", "\u00a0", "", "\u202f", "")
+		}
+	}
+	if decimal != "." {
+		pairs = append(pairs, decimal, ".")
+	}
+	return strings.NewReplacer(pairs...)
+}
@@ -0,0 +1,87 @@
+package deserialize
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// A value that may be absent, present but `null` or present with a value.
+//
+// This matches OpenAPI's `nullable` semantics:
+//
+//   - absent: `Set` is `false`;
+//   - present and `null`: `Set` is `true`, `Null` is `true`;
+//   - present with a value: `Set` is `true`, `Null` is `false` and `Value` holds the value.
+//
+// Values are deserialized as any other `T`, with the same options and driver,
+// so tags such as `default` or validators within `T` apply.
+type Nullable[T any] struct {
+	// `true` if the key was present in the input.
+	Set bool
+
+	// `true` if the key was present in the input with value `null`.
+	Null bool
+
+	// The value, if `Set && !Null`.
+	Value T
+}
+
+func (Nullable[T]) nullableValueType() reflect.Type {
+	return reflect.TypeOf(new(T)).Elem()
+}
+
+// Implemented by `Nullable`.
+type nullable interface {
+	// The type of the value, stored in field `Value`.
+	nullableValueType() reflect.Type
+}
+
+var nullableInterface = reflect.TypeOf((*nullable)(nil)).Elem()
+
+// Construct a dynamically-typed deserializer for a `Nullable`.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `fieldType` the dynamic type of the `Nullable` being compiled;
+//   - `tags` the table of tags for this field.
+func makeNullableDeserializer(fieldPath string, fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags, wasPreinitialized bool) (reflectDeserializer, error) {
+	if tags.Default() != nil || tags.MethodName() != nil {
+		return nil, fmt.Errorf("at %s, type %s is a Nullable, it cannot have a `default` or `orMethod`", fieldPath, typeName(fieldType))
+	}
+	valueType := reflect.Zero(fieldType).Interface().(nullable).nullableValueType() //nolint:forcetypeassert
+	// Tags `layout`, `base`, `parsers`, `oneof` and `enumCase` apply to the value.
+	subTags := tags.Only(valueTags...)
+	valueDeserializer, err := makeFieldDeserializerFromReflect(fieldPath, valueType, options, &subTags, reflect.New(fieldType).Elem(), false, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate a deserializer for %s\n\t * %w", fieldPath, err)
+	}
+	valueIndex := fieldType.NumField() - 1
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue == nil {
+			if wasPreinitialized {
+				// No value? That's ok, we got a value from preinitialization.
+				return nil
+			}
+			// Absent.
+			outPtr.SetZero()
+			return nil
+		}
+		result := reflect.New(fieldType).Elem()
+		result.Field(0).SetBool(true)
+		if inValue.Interface() == nil {
+			result.Field(1).SetBool(true)
+		} else {
+			slot := result.Field(valueIndex)
+			err := valueDeserializer(&slot, inValue, call)
+			if err != nil {
+				return fmt.Errorf("at %s, expected to be able to parse a %s:\n\t * %w", fieldPath, typeName(fieldType), err)
+			}
+		}
+		outPtr.Set(result)
+		return nil
+	}
+	return result, nil
+}
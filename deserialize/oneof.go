@@ -0,0 +1,101 @@
+package deserialize
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+	"github.com/pasqal-io/godasse/validation"
+)
+
+// A value that may be deserialized either as an `A` or as a `B`, e.g. a
+// field that accepts both a string and an object.
+//
+// Deserialization first attempts to deserialize the value as an `A`. If this
+// fails, it attempts to deserialize the value as a `B`. If both fail, the
+// errors of both attempts are reported.
+//
+// Note that if the value is deserialized as an `A` but rejected by `Validate()`,
+// this validation error is reported immediately, without attempting `B`.
+type OneOf2[A any, B any] struct {
+	// The variant that matched, i.e. 1 for `A`, 2 for `B`.
+	//
+	// 0 if the value was not deserialized, e.g. if it was pre-initialized.
+	Which int
+
+	// The value, if `Which == 1`.
+	A A
+
+	// The value, if `Which == 2`.
+	B B
+}
+
+func (OneOf2[A, B]) oneOfVariants() []reflect.Type {
+	return []reflect.Type{reflect.TypeOf(new(A)).Elem(), reflect.TypeOf(new(B)).Elem()}
+}
+
+// Implemented by `OneOf2`.
+type oneOf interface {
+	// The types to attempt, by order of precedence.
+	//
+	// The value for variant `i` is stored in field `i + 1`.
+	oneOfVariants() []reflect.Type
+}
+
+var oneOfInterface = reflect.TypeOf((*oneOf)(nil)).Elem()
+
+// Construct a dynamically-typed deserializer for a `OneOf2`.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `fieldType` the dynamic type of the `OneOf2` being compiled;
+//   - `tags` the table of tags for this field.
+func makeOneOfDeserializer(fieldPath string, fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags, wasPreinitialized bool) (reflectDeserializer, error) {
+	if tags.Default() != nil || tags.MethodName() != nil {
+		return nil, fmt.Errorf("at %s, type %s is a OneOf2, it cannot have a `default` or `orMethod`", fieldPath, typeName(fieldType))
+	}
+	variantTypes := reflect.Zero(fieldType).Interface().(oneOf).oneOfVariants() //nolint:forcetypeassert
+	variants := make([]reflectDeserializer, len(variantTypes))
+	names := make([]string, len(variantTypes))
+	for i, variantType := range variantTypes {
+		subTags := tagsPkg.Empty()
+		deserializer, err := makeFieldDeserializerFromReflect(fieldPath, variantType, options, &subTags, reflect.New(variantType).Elem(), false, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a deserializer for %s as %s\n\t * %w", fieldPath, typeName(variantType), err)
+		}
+		variants[i] = deserializer
+		names[i] = typeName(variantType)
+	}
+	expected := strings.Join(names, " or ")
+	failureFormat := "invalid value at %s, expected %s" + strings.Repeat("\n\t * %w", len(variants))
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue == nil {
+			if wasPreinitialized {
+				// No value? That's ok, we got a value from preinitialization.
+				return nil
+			}
+			return MissingFieldError{Path: fieldPath, Type: expected, description: missingValue}
+		}
+		errs := make([]any, len(variants))
+		for i, deserializer := range variants {
+			slot := reflect.New(variantTypes[i]).Elem()
+			err := deserializer(&slot, inValue, call)
+			if err == nil {
+				outPtr.SetZero()
+				outPtr.Field(0).SetInt(int64(i + 1))
+				outPtr.Field(i + 1).Set(slot)
+				return nil
+			}
+			if errors.As(err, &validation.Error{}) { //nolint:exhaustruct
+				// Don't try to recover from a validation error by switching to the next variant!
+				return err
+			}
+			errs[i] = err
+		}
+		return fmt.Errorf(failureFormat, append([]any{fieldPath, expected}, errs...)...)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+// Code specific to deserializing Protocol Buffers dynamic structs, i.e.
+// `structpb.Struct`, without marshaling them to JSON and back.
+//
+// Protocol Buffers dynamic structs follow the data model of JSON, so use
+// these values with JSON options, e.g.
+//
+//	deserializer.DeserializeDict(pbstruct.Dict(message.GetPayload()))
+package pbstruct
+
+import (
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Expose a `structpb.Struct` as a dictionary.
+//
+// A `nil` struct is handled as an empty dictionary.
+func Dict(wrapped *structpb.Struct) shared.Dict {
+	return dict{wrapped: wrapped}
+}
+
+// Expose a `structpb.Value` as a value.
+func WrapValue(wrapped *structpb.Value) shared.Value {
+	return Value{wrapped: wrapped}
+}
+
+// A `structpb.Struct`, as a dictionary.
+type dict struct {
+	wrapped *structpb.Struct
+}
+
+func (d dict) Lookup(key string) (shared.Value, bool) {
+	value, ok := d.wrapped.GetFields()[key]
+	if !ok {
+		return nil, false
+	}
+	return Value{wrapped: value}, true
+}
+func (d dict) AsValue() shared.Value {
+	return Value{wrapped: structpb.NewStructValue(d.wrapped)}
+}
+func (d dict) Keys() []string {
+	fields := d.wrapped.GetFields()
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ shared.Dict = dict{} //nolint:exhaustruct
+
+// A `structpb.Value`.
+type Value struct {
+	wrapped *structpb.Value
+}
+
+func (v Value) AsDict() (shared.Dict, bool) {
+	switch kind := v.wrapped.GetKind().(type) {
+	case *structpb.Value_StructValue:
+		return dict{wrapped: kind.StructValue}, true
+	case *structpb.Value_NullValue, nil:
+		// As in JSON, `null` is handled as an empty dictionary.
+		return dict{wrapped: nil}, true
+	default:
+		return nil, false
+	}
+}
+func (v Value) AsSlice() ([]shared.Value, bool) {
+	list, ok := v.wrapped.GetKind().(*structpb.Value_ListValue)
+	if !ok {
+		return nil, false
+	}
+	values := list.ListValue.GetValues()
+	result := make([]shared.Value, len(values))
+	for i, value := range values {
+		result[i] = Value{wrapped: value}
+	}
+	return result, true
+}
+
+// Return the value as a Go value, i.e. `nil`, a `float64`, a `string`,
+// a `bool`, a `map[string]any` or a `[]any`, as `encoding/json` would.
+func (v Value) Interface() any {
+	if v.wrapped == nil {
+		return nil
+	}
+	return v.wrapped.AsInterface()
+}
+
+var _ shared.Value = Value{} //nolint:exhaustruct
@@ -0,0 +1,82 @@
+package deserialize
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+)
+
+// A value deserialized with `DeserializeDictPooled` or `DeserializeBytesPooled`.
+//
+// Once you are done with the value, call `Release()` to return it to the
+// pool. After this call, neither `Value` nor anything it contains may be
+// used, as it may be handed to another call. Do not copy a `Pooled` that
+// has not been released, as releasing both copies would put the same
+// value twice in the pool.
+type Pooled[T any] struct {
+	// The deserialized value.
+	//
+	// `nil` after `Release()`.
+	Value *T
+
+	// The pool to which the value is returned, or `nil` if pooling is disabled.
+	pool *sync.Pool
+}
+
+// Return the value to the pool.
+//
+// The value is zeroed. Calling `Release()` several times is harmless.
+func (p *Pooled[T]) Release() {
+	if p.Value == nil {
+		return
+	}
+	value := p.Value
+	p.Value = nil
+	if p.pool == nil {
+		return
+	}
+	var zero T
+	*value = zero
+	p.pool.Put(value)
+}
+
+// Fetch a zeroed value from the pool, if any, or allocate one.
+func (me mapDeserializer[T]) acquire() *T {
+	if me.pool == nil {
+		return new(T)
+	}
+	result, ok := me.pool.Get().(*T)
+	if !ok {
+		panic("the pool should only contain values of type *T")
+	}
+	return result
+}
+
+func (me mapDeserializer[T]) DeserializeDictPooled(value shared.Dict) (Pooled[T], error) {
+	out := me.acquire()
+	result := Pooled[T]{
+		Value: out,
+		pool:  me.pool,
+	}
+	err := me.deserializer(value, out, newCallData())
+	if err != nil {
+		result.Release()
+		return Pooled[T]{}, err //nolint:exhaustruct
+	}
+	return result, nil
+}
+
+func (me mapDeserializer[T]) DeserializeBytesPooled(source []byte) (Pooled[T], error) {
+	unmarshaler := me.options.bytesDriver()
+	dict := new(any)
+	if err := unmarshaler.Unmarshal(source, dict); err != nil {
+		return Pooled[T]{}, fmt.Errorf("failed to deserialize source: \n\t * %w", err) //nolint:exhaustruct
+	}
+	asDict, ok := unmarshaler.WrapValue(*dict).AsDict()
+	if !ok {
+		return Pooled[T]{}, errors.New("failed to deserialize as a dictionary") //nolint:exhaustruct
+	}
+	return me.DeserializeDictPooled(asDict)
+}
@@ -0,0 +1,82 @@
+package deserialize
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// The types of fields that capture the encoded bytes of their value
+// instead of deserializing it.
+var rawCaptureType = reflect.TypeOf(shared.RawCapture(nil))
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// Construct a dynamically-typed deserializer for a `shared.RawCapture` or
+// a `json.RawMessage`.
+//
+// Both require a driver that implements `shared.RawDriver`, so that values
+// decoded from bytes are captured verbatim. Values built in memory are
+// re-encoded, as JSON for a `json.RawMessage`, by the driver for a
+// `shared.RawCapture`.
+//
+// If specified, tag `default` is the encoded default value, used verbatim.
+func makeRawCaptureDeserializer(fieldPath string, fieldType reflect.Type, options innerOptions, tags *tagsPkg.Tags, wasPreinitialized bool) (reflectDeserializer, error) {
+	// Depending on the version of Go, `json.RawMessage` may be an alias with another name.
+	name := typeName(fieldType)
+	if fieldType == rawMessageType {
+		name = "json.RawMessage"
+	}
+	if _, ok := options.unmarshaler.(shared.RawDriver); !ok {
+		return nil, fmt.Errorf("at %s, cannot capture raw values of type %s as this unmarshaler does not keep the bytes it decodes", fieldPath, name)
+	}
+	// From now on, the driver needs to keep the bytes it decodes.
+	options.capturesRaw.Store(true)
+	var encode func(any) ([]byte, error)
+	if fieldType == rawMessageType {
+		encode = json.Marshal
+	} else {
+		marshaler, ok := options.unmarshaler.(shared.MarshalingDriver)
+		if !ok {
+			return nil, fmt.Errorf("at %s, cannot capture raw values of type %s as this unmarshaler does not support serializing to bytes", fieldPath, name)
+		}
+		encode = marshaler.Marshal
+	}
+
+	var defaultValue []byte
+	if defaultSource := tags.Default(); defaultSource != nil {
+		defaultValue = []byte(*defaultSource)
+	}
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		var raw []byte
+		switch {
+		case inValue != nil:
+			if rawValue, ok := inValue.(shared.RawValue); ok {
+				if found, ok := rawValue.Raw(); ok {
+					// Copy, so that callers may not retain the entire input.
+					raw = append([]byte{}, found...)
+					break
+				}
+			}
+			encoded, err := encode(inValue.Interface())
+			if err != nil {
+				return fmt.Errorf("invalid value at %s, cannot capture it:\n\t * %w", fieldPath, err)
+			}
+			raw = encoded
+		case defaultValue != nil:
+			// Copy, so that callers may not alter the default.
+			raw = append([]byte{}, defaultValue...)
+		case wasPreinitialized:
+			// No value? That's ok, we got a value from preinitialization.
+			return nil
+		default:
+			return MissingFieldError{Path: fieldPath, Type: name, description: missingValue}
+		}
+		outPtr.Set(reflect.ValueOf(raw).Convert(fieldType))
+		return nil
+	}
+	return result, nil
+}
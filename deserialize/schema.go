@@ -0,0 +1,75 @@
+package deserialize
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	"github.com/pasqal-io/godasse/validation"
+)
+
+// Construct a dynamically-typed deserializer for a field whose value must match a JSON Schema.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `schemaName` the name of the schema in `options.schemas`;
+//   - `wrapped` the deserializer for the value, once it has been validated.
+func makeSchemaDeserializer(fieldPath string, schemaName string, options innerOptions, wrapped reflectDeserializer) (reflectDeserializer, error) {
+	schema, ok := options.schemas[schemaName]
+	if !ok || schema == nil {
+		return nil, fmt.Errorf("at %s, unknown schema %s, please register it in `Options.Schemas`", fieldPath, schemaName)
+	}
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue != nil {
+			err := schema.Validate(schemaValue(inValue))
+			if err != nil {
+				return validation.WrapError(fieldPath, fmt.Errorf("value does not match schema %s\n\t * %w", schemaName, err))
+			}
+		}
+		return wrapped(outPtr, inValue, call)
+	}
+	return result, nil
+}
+
+// Convert a value into the representation expected by the JSON Schema validator,
+// i.e. the representation produced by `encoding/json`.
+func schemaValue(value shared.Value) any {
+	raw := value.Interface()
+	if raw == nil {
+		return nil
+	}
+	if _, ok := raw.(string); ok {
+		return raw
+	}
+	if dict, ok := value.AsDict(); ok {
+		result := make(map[string]any)
+		for _, key := range dict.Keys() {
+			if entry, ok := dict.Lookup(key); ok {
+				result[key] = schemaValue(entry)
+			}
+		}
+		return result
+	}
+	if slice, ok := value.AsSlice(); ok {
+		result := make([]any, len(slice))
+		for i, entry := range slice {
+			result[i] = schemaValue(entry)
+		}
+		return result
+	}
+	reflected := reflect.ValueOf(raw)
+	switch {
+	case reflected.Kind() == reflect.Bool:
+		return reflected.Bool()
+	case reflected.CanInt():
+		return reflected.Int()
+	case reflected.CanUint():
+		return reflected.Uint()
+	case reflected.CanFloat():
+		return reflected.Float()
+	case reflected.Kind() == reflect.String:
+		return reflected.String()
+	default:
+		// Not a JSON value, e.g. a `time.Time` decoded by the YAML driver.
+		return fmt.Sprint(raw)
+	}
+}
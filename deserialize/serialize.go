@@ -0,0 +1,404 @@
+package deserialize
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// A serializer into dictionaries, the counterpart of `MapDeserializer`.
+//
+// A serializer follows the same tags as the deserializer built from the
+// same options, so that its output may be deserialized back:
+//
+//   - fields are renamed with `Options.MainTagName`, then `Options.FallbackTagNames`;
+//   - private fields and fields renamed to `-` are not serialized;
+//   - flattened and anonymous structs are serialized into the outer dictionary;
+//   - if `Options.Envelope` is specified, the result is wrapped in the envelope.
+//
+// Fields tagged with `omitempty:""` (or e.g. `json:"name,omitempty"`) are
+// not serialized if they hold a zero value, an empty slice or an empty map.
+// To deserialize them back, give them a `default`.
+//
+// Values that implement `encoding.TextMarshaler` are serialized as strings.
+//
+// Once built, a serializer is safe for concurrent use by multiple goroutines.
+type MapSerializer[From any] interface {
+	// Serialize a single value into a dict.
+	SerializeDict(*From) (shared.Dict, error)
+	// Serialize a single value into bytes, in the format of `Options.Unmarshaler`.
+	//
+	// Only supported by unmarshalers that implement `shared.MarshalingDriver`,
+	// e.g. JSON or YAML.
+	SerializeBytes(*From) ([]byte, error)
+}
+
+// Create a serializer from a struct into dictionaries.
+//
+// `From` MUST be a struct.
+func MakeMapSerializer[From any](options Options) (MapSerializer[From], error) {
+	innerOptions, err := makeInnerOptions(options)
+	if err != nil {
+		return nil, err
+	}
+	typ := reflect.TypeOf(new(From)).Elem()
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %s", typ)
+	}
+	serializer, err := makeStructSerializer(fmt.Sprint(options.RootPath, typeName(typ)), typ, innerOptions)
+	if err != nil {
+		return nil, err
+	}
+	return mapSerializer[From]{
+		serializer: serializer,
+		options:    innerOptions,
+	}, nil
+}
+
+// ----------------- Private
+
+// A serializer for values of a given type.
+//
+// The result is built from `map[string]any`, `[]any` and flat values.
+type reflectSerializer func(value reflect.Value) (any, error)
+
+type mapSerializer[From any] struct {
+	// The serializer for `From`, which returns a `map[string]any`.
+	serializer reflectSerializer
+	options    innerOptions
+}
+
+func (me mapSerializer[From]) serialize(value *From) (any, error) {
+	if value == nil {
+		return nil, errors.New("cannot serialize a nil value")
+	}
+	result, err := me.serializer(reflect.ValueOf(value).Elem())
+	if err != nil {
+		return nil, err
+	}
+	if me.options.envelope != "" {
+		result = map[string]any{
+			me.options.envelope: result,
+		}
+	}
+	return result, nil
+}
+
+func (me mapSerializer[From]) SerializeDict(value *From) (shared.Dict, error) {
+	result, err := me.serialize(value)
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := me.options.unmarshaler.WrapValue(result).AsDict()
+	if !ok {
+		return nil, errors.New("this unmarshaler does not support dictionaries")
+	}
+	return dict, nil
+}
+
+func (me mapSerializer[From]) SerializeBytes(value *From) ([]byte, error) {
+	marshaler, ok := me.options.unmarshaler.(shared.MarshalingDriver)
+	if !ok {
+		return nil, errors.New("this unmarshaler does not support serializing to bytes")
+	}
+	result, err := me.serialize(value)
+	if err != nil {
+		return nil, err
+	}
+	buf, err := marshaler.Marshal(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize:\n\t * %w", err)
+	}
+	return buf, nil
+}
+
+// The interfaces for `encoding.TextMarshaler` and `json.Marshaler`.
+var textMarshalerInterface = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+var jsonMarshalerInterface = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Construct a serializer for values of type `typ`.
+//
+//   - `path` the human-readable path into the data structure, used for error-reporting;
+//   - `typ` the dynamic type for the value being compiled.
+func makeSerializerFromReflect(path string, typ reflect.Type, options innerOptions) (reflectSerializer, error) {
+	if typ.Kind() != reflect.Pointer && typ.Kind() != reflect.Interface {
+		// Types that know how to serialize themselves take precedence.
+		if typ.Implements(textMarshalerInterface) || reflect.PointerTo(typ).Implements(textMarshalerInterface) {
+			return serializeText, nil
+		}
+		if typ.Implements(jsonMarshalerInterface) || reflect.PointerTo(typ).Implements(jsonMarshalerInterface) {
+			// Leave the value as is, the unmarshaler will call `MarshalJSON`.
+			return serializeAddressable, nil
+		}
+	}
+	switch typ.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return serializeFlat, nil
+	case reflect.Pointer:
+		elemSerializer, err := makeSerializerFromReflect(path, typ.Elem(), options)
+		if err != nil {
+			return nil, err
+		}
+		return func(value reflect.Value) (any, error) {
+			if value.IsNil() {
+				return nil, nil
+			}
+			return elemSerializer(value.Elem())
+		}, nil
+	case reflect.Interface:
+		return func(value reflect.Value) (any, error) {
+			if value.IsNil() {
+				return nil, nil
+			}
+			// We only know the concrete type now.
+			elem := value.Elem()
+			elemSerializer, err := makeSerializerFromReflect(path, elem.Type(), options)
+			if err != nil {
+				return nil, err
+			}
+			return elemSerializer(elem)
+		}, nil
+	case reflect.Slice, reflect.Array:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			// Leave []byte as is, formats typically have a specific representation.
+			return serializeFlat, nil
+		}
+		elemSerializer, err := makeSerializerFromReflect(fmt.Sprint(path, "[]"), typ.Elem(), options)
+		if err != nil {
+			return nil, err
+		}
+		return func(value reflect.Value) (any, error) {
+			result := make([]any, value.Len())
+			for i := range result {
+				elem, err := elemSerializer(value.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				result[i] = elem
+			}
+			return result, nil
+		}, nil
+	case reflect.Map:
+		keySerializer, err := makeMapKeySerializer(path, typ.Key())
+		if err != nil {
+			return nil, err
+		}
+		elemSerializer, err := makeSerializerFromReflect(fmt.Sprint(path, "[]"), typ.Elem(), options)
+		if err != nil {
+			return nil, err
+		}
+		return func(value reflect.Value) (any, error) {
+			result := make(map[string]any, value.Len())
+			iter := value.MapRange()
+			for iter.Next() {
+				key, err := keySerializer(iter.Key())
+				if err != nil {
+					return nil, err
+				}
+				elem, err := elemSerializer(iter.Value())
+				if err != nil {
+					return nil, err
+				}
+				result[key] = elem
+			}
+			return result, nil
+		}, nil
+	case reflect.Struct:
+		return makeStructSerializer(path, typ, options)
+	default:
+		return nil, fmt.Errorf("at %s, cannot serialize values of type %s", path, typ)
+	}
+}
+
+// A field of a struct, as seen by the serializer.
+type serializedField struct {
+	// The index of the field in the struct.
+	index int
+
+	// The public name of the field, unless it is flattened.
+	publicName string
+
+	// If `true`, the contents of this field are serialized into the outer dictionary.
+	flattened bool
+
+	// If `true`, skip this field if it is empty.
+	omitEmpty bool
+
+	serializer reflectSerializer
+}
+
+// Construct a serializer for structs, which returns a `map[string]any`.
+//
+//   - `path` the human-readable path into the data structure, used for error-reporting;
+//   - `typ` the dynamic type for the struct being compiled.
+func makeStructSerializer(path string, typ reflect.Type, options innerOptions) (reflectSerializer, error) {
+	fields := []serializedField{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			// Private fields never leave the server.
+			continue
+		}
+		tags, err := tagsPkg.Parse(field.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse tags at %s.%s:\n\t * %w", path, field.Name, err)
+		}
+		if tags.IsFlattened() || field.Anonymous {
+			contentType := field.Type
+			if contentType.Kind() == reflect.Pointer {
+				contentType = contentType.Elem()
+			}
+			if contentType.Kind() != reflect.Struct {
+				return nil, fmt.Errorf("at %s.%s, cannot flatten values of type %s, expected a struct", path, field.Name, field.Type)
+			}
+			serializer, err := makeSerializerFromReflect(fmt.Sprint(path, ".", field.Name), field.Type, options)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, serializedField{
+				index:      i,
+				publicName: "",
+				flattened:  true,
+				omitEmpty:  false,
+				serializer: serializer,
+			})
+			continue
+		}
+		publicName := tags.PublicFieldName(options.renamingTagNames...)
+		if publicName == nil {
+			publicName = &field.Name
+		}
+		if *publicName == "-" {
+			continue
+		}
+		fieldPath := fmt.Sprint(path, ".", *publicName)
+		serializer, err := makeSerializerFromReflect(fieldPath, field.Type, options)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, serializedField{
+			index:      i,
+			publicName: *publicName,
+			flattened:  false,
+			omitEmpty:  isOmitEmpty(&tags, options),
+			serializer: serializer,
+		})
+	}
+
+	return func(value reflect.Value) (any, error) {
+		result := make(map[string]any, len(fields))
+		for _, field := range fields {
+			fieldValue := value.Field(field.index)
+			if field.omitEmpty && isEmptyValue(fieldValue) {
+				continue
+			}
+			serialized, err := field.serializer(fieldValue)
+			if err != nil {
+				return nil, err
+			}
+			if !field.flattened {
+				result[field.publicName] = serialized
+				continue
+			}
+			// `nil` if this is an embedded pointer that was never allocated.
+			if contents, ok := serialized.(map[string]any); ok {
+				for k, v := range contents {
+					result[k] = v
+				}
+			}
+		}
+		return result, nil
+	}, nil
+}
+
+// Determine whether a field should be omitted when empty, as specified by
+// tag `omitempty` or by option `omitempty` in the renaming tag, e.g.
+// `json:"name,omitempty"`.
+func isOmitEmpty(tags *tagsPkg.Tags, options innerOptions) bool {
+	if _, ok := tags.Lookup("omitempty"); ok {
+		return true
+	}
+	for _, tagName := range options.renamingTagNames {
+		if values, ok := tags.Lookup(tagName); ok {
+			return slices.Contains(values, "omitempty")
+		}
+	}
+	return false
+}
+
+// Determine whether a value is empty, in the sense of `omitempty`.
+func isEmptyValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		return value.Len() == 0
+	default:
+		return value.IsZero()
+	}
+}
+
+// Construct a serializer for the keys of maps.
+func makeMapKeySerializer(path string, typ reflect.Type) (func(reflect.Value) (string, error), error) {
+	switch {
+	case typ.Implements(textMarshalerInterface) || reflect.PointerTo(typ).Implements(textMarshalerInterface):
+		return func(value reflect.Value) (string, error) {
+			text, err := serializeText(value)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprint(text), nil
+		}, nil
+	case typ.Kind() == reflect.String:
+		return func(value reflect.Value) (string, error) {
+			return value.String(), nil
+		}, nil
+	case isNumericKind(typ.Kind()) || typ.Kind() == reflect.Bool:
+		return func(value reflect.Value) (string, error) {
+			return fmt.Sprint(value.Interface()), nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("at %s, cannot serialize map keys of type %s", path, typ)
+	}
+}
+
+// Serialize a flat value, as is.
+func serializeFlat(value reflect.Value) (any, error) {
+	return value.Interface(), nil
+}
+
+// Serialize a value as is, but addressable, so that methods implemented
+// on the pointer, e.g. `MarshalJSON`, may be called.
+func serializeAddressable(value reflect.Value) (any, error) {
+	return addressable(value).Interface(), nil
+}
+
+// Return a pointer to `value`, copying it if it is not addressable.
+func addressable(value reflect.Value) reflect.Value {
+	if value.CanAddr() {
+		return value.Addr()
+	}
+	ptr := reflect.New(value.Type())
+	ptr.Elem().Set(value)
+	return ptr
+}
+
+// Serialize a value that implements `encoding.TextMarshaler` as a string.
+func serializeText(value reflect.Value) (any, error) {
+	marshaler, ok := addressable(value).Interface().(encoding.TextMarshaler)
+	if !ok {
+		return nil, fmt.Errorf("internal error: %s should implement encoding.TextMarshaler", value.Type())
+	}
+	text, err := marshaler.MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s:\n\t * %w", typeName(value.Type()), err)
+	}
+	return string(text), nil
+}
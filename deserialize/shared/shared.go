@@ -1,6 +1,7 @@
 package shared
 
 import (
+	"io"
 	"reflect"
 	"strconv"
 )
@@ -47,6 +48,82 @@ type Driver interface {
 	WrapValue(any) Value
 }
 
+// A driver that supports deserializing directly from a stream,
+// without having to buffer the entire input first.
+//
+// This is optional. Drivers that do not implement it fall back
+// to reading the entire stream and calling `Unmarshal`.
+type StreamingDriver interface {
+	Driver
+
+	// Perform unmarshaling for a stream.
+	UnmarshalReader(io.Reader, *any) error
+}
+
+// A driver that can decode numbers without loss of precision, e.g.
+// as `json.Number` rather than `float64`.
+//
+// This is optional. It is required by `Options.UseNumber`.
+type NumberDriver interface {
+	Driver
+
+	// Return a driver that decodes numbers without loss of precision.
+	WithUseNumber() Driver
+}
+
+// A driver that can reject objects containing the same key several times.
+//
+// This is optional. It is required by `Options.RejectDuplicateKeys`.
+type DuplicateKeysDriver interface {
+	Driver
+
+	// Return a driver that rejects duplicate keys.
+	WithRejectDuplicateKeys() Driver
+}
+
+// A driver that can also serialize values, e.g. for `MakeMapSerializer`.
+//
+// This is optional. Drivers that do not implement it cannot serialize
+// to bytes.
+type MarshalingDriver interface {
+	Driver
+
+	// Serialize a value, typically a dictionary, into bytes.
+	Marshal(any) ([]byte, error)
+}
+
+// A driver that can keep the bytes it decodes values from.
+//
+// This is optional. It is required to capture raw values, i.e. fields of
+// type `RawCapture` or `json.RawMessage`.
+type RawDriver interface {
+	Driver
+
+	// Return a driver whose decoded values implement `RawValue`.
+	WithKeepRaw() Driver
+}
+
+// A value that knows the bytes it was decoded from.
+type RawValue interface {
+	Value
+
+	// The bytes this value was decoded from, verbatim, or `false` if
+	// the value was not decoded from bytes, e.g. if it was built in memory.
+	Raw() ([]byte, bool)
+}
+
+// Unmarshal from a stream, using streaming if the driver supports it.
+func UnmarshalReader(driver Driver, reader io.Reader, out *any) error {
+	if streaming, ok := driver.(StreamingDriver); ok {
+		return streaming.UnmarshalReader(reader, out) //nolint:wrapcheck
+	}
+	buf, err := io.ReadAll(reader)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+	return driver.Unmarshal(buf, out) //nolint:wrapcheck
+}
+
 // A parser for strings into primitive values.
 type Parser func(source string) (any, error)
 
@@ -95,8 +172,8 @@ func LookupParser(fieldType reflect.Type) *Parser {
 		result = &p
 	case reflect.Uint:
 		var p Parser = func(source string) (any, error) {
-			// `uint` size is not specified, we'll try with 64 bits.
-			return strconv.ParseUint(source, 10, 64) //nolint:wrapcheck
+			// `uint` size is platform-dependent.
+			return strconv.ParseUint(source, 10, strconv.IntSize) //nolint:wrapcheck
 		}
 		result = &p
 	case reflect.Uint8:
@@ -134,3 +211,66 @@ func LookupParser(fieldType reflect.Type) *Parser {
 type UnmarshalDict interface {
 	UnmarshalDict(Dict) error
 }
+
+// A type that declares, at runtime, fields that are not part of its Go type,
+// e.g. the fields of a dynamic form.
+//
+// Once the regular fields of the struct have been deserialized, `Schema()`
+// is called to determine the additional keys expected in the input and the
+// type of their values. These values are deserialized, then passed to
+// `SetDynamicFields()`, before `ComputeDefaults()` and `Validate()`.
+//
+// Important: We expect `DynamicFields` to be implemented on **pointers**,
+// rather than on structs.
+type DynamicFields interface {
+	// The additional keys expected in the input, with the type of their values.
+	//
+	// The regular fields are already deserialized, so the schema may
+	// depend on them.
+	Schema() map[string]reflect.Type
+
+	// Receive the values of the additional keys.
+	//
+	// Each value has the type specified by `Schema()`.
+	SetDynamicFields(map[string]any)
+}
+
+// A decoder for values of arbitrary types, selected per field
+// with tag `codec`, e.g. `codec:"money"`.
+//
+// By opposition to `UnmarshalValue`, this does not require the type
+// to implement any interface, which makes it possible to decode types
+// defined in other packages.
+//
+// If the value is missing, `Decode` is called with `nil`, which lets
+// the codec decide whether it accepts missing values.
+type Codec interface {
+	// Decode `value` into a value of type `typ`.
+	//
+	// The result must be assignable to `typ`.
+	Decode(value Value, typ reflect.Type) (reflect.Value, error)
+}
+
+// The encoded bytes of a value, captured verbatim rather than deserialized.
+//
+// Fields of this type receive the value provided in the input, encoded
+// in the format of the driver (e.g. JSON for the JSON driver), so that it
+// may be deserialized later, e.g. once we know its type. This requires a
+// driver that implements `RawDriver` and `MarshalingDriver`.
+//
+// When deserializing from bytes, the value is captured verbatim, including
+// whitespace, the order of keys and numbers that do not fit in a `float64`.
+// Values that were not decoded from bytes, e.g. a `Dict` built in memory,
+// are re-encoded by the driver.
+type RawCapture []byte
+
+// A type that can be deserialized from any shared.Value.
+//
+// By opposition to `UnmarshalDict`, this lets a type accept several
+// shapes of input, e.g. both a string and an object.
+//
+// If the value is missing, `UnmarshalValue` is called with `nil`,
+// which lets the type decide whether it accepts missing values.
+type UnmarshalValue interface {
+	UnmarshalValue(Value) error
+}
@@ -0,0 +1,235 @@
+// Support for HTTP structured fields (RFC 8941), such as
+//
+//	Accept-Encoding: gzip;q=1.0, br;q=0.8
+//
+// As of this writing, we only support the subset of lists of items
+// with parameters. Inner lists are rejected.
+package structuredheader
+
+import (
+	"encoding"
+	"fmt"
+	"strings"
+)
+
+// An item in a structured list, e.g. `gzip;q=1.0`.
+type Item struct {
+	// The value of the item, e.g. `gzip`.
+	//
+	// Strings are unquoted, other values (tokens, numbers, booleans,
+	// byte sequences) are kept as they appear in the source.
+	Value string
+
+	// The parameters of the item, e.g. `q` -> `1.0`.
+	//
+	// A parameter without value (e.g. `;secure`) is a boolean
+	// and is represented as `?1`.
+	Params map[string]string
+}
+
+// A structured list, e.g. `gzip;q=1.0, br;q=0.8`.
+//
+// This type may be used as a field type, in which case it is
+// deserialized from a string.
+type List struct {
+	Items []Item
+}
+
+// Parse a list from text.
+func (list *List) UnmarshalText(source []byte) error {
+	items, err := ParseList(string(source))
+	if err != nil {
+		return err
+	}
+	list.Items = items
+	return nil
+}
+
+var _ encoding.TextUnmarshaler = &List{} //nolint:exhaustruct
+
+// Parse a structured list, e.g. `gzip;q=1.0, br;q=0.8`.
+func ParseList(source string) ([]Item, error) {
+	p := parser{
+		source: source,
+		pos:    0,
+	}
+	items := []Item{}
+	p.skipOWS()
+	if p.eof() {
+		return items, nil
+	}
+	for {
+		item, err := p.parseItem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipOWS()
+		if p.eof() {
+			return items, nil
+		}
+		if p.next() != ',' {
+			return nil, p.errorf("expected `,`")
+		}
+		p.skipOWS()
+		if p.eof() {
+			return nil, p.errorf("trailing `,`")
+		}
+	}
+}
+
+type parser struct {
+	source string
+	pos    int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.source)
+}
+
+func (p *parser) peek() byte {
+	return p.source[p.pos]
+}
+
+func (p *parser) next() byte {
+	c := p.source[p.pos]
+	p.pos++
+	return c
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("invalid structured header at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// Skip optional whitespace, i.e. spaces and tabs.
+func (p *parser) skipOWS() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *parser) parseItem() (Item, error) {
+	value, err := p.parseBareItem()
+	if err != nil {
+		return Item{}, err //nolint:exhaustruct
+	}
+	params := make(map[string]string)
+	for !p.eof() && p.peek() == ';' {
+		p.pos++
+		for !p.eof() && p.peek() == ' ' {
+			p.pos++
+		}
+		key, err := p.parseKey()
+		if err != nil {
+			return Item{}, err //nolint:exhaustruct
+		}
+		paramValue := "?1"
+		if !p.eof() && p.peek() == '=' {
+			p.pos++
+			paramValue, err = p.parseBareItem()
+			if err != nil {
+				return Item{}, err //nolint:exhaustruct
+			}
+		}
+		params[key] = paramValue
+	}
+	return Item{
+		Value:  value,
+		Params: params,
+	}, nil
+}
+
+func (p *parser) parseBareItem() (string, error) {
+	if p.eof() {
+		return "", p.errorf("expected an item")
+	}
+	c := p.peek()
+	switch {
+	case c == '"':
+		return p.parseString()
+	case c == '(':
+		return "", p.errorf("inner lists are not supported")
+	case c == '-' || isDigit(c):
+		return p.consume(func(c byte) bool { return c == '-' || c == '.' || isDigit(c) }), nil
+	case c == '?':
+		start := p.pos
+		p.pos++
+		if p.eof() || (p.peek() != '0' && p.peek() != '1') {
+			return "", p.errorf("invalid boolean")
+		}
+		p.pos++
+		return p.source[start:p.pos], nil
+	case c == ':':
+		start := p.pos
+		p.pos++
+		end := strings.IndexByte(p.source[p.pos:], ':')
+		if end < 0 {
+			return "", p.errorf("unterminated byte sequence")
+		}
+		p.pos += end + 1
+		return p.source[start:p.pos], nil
+	case isAlpha(c) || c == '*':
+		return p.consume(func(c byte) bool { return isTChar(c) || c == ':' || c == '/' }), nil
+	default:
+		return "", p.errorf("unexpected character %q", c)
+	}
+}
+
+func (p *parser) parseString() (string, error) {
+	p.pos++ // Skip opening quote.
+	var buf strings.Builder
+	for !p.eof() {
+		c := p.next()
+		switch c {
+		case '\\':
+			if p.eof() {
+				return "", p.errorf("unterminated escape")
+			}
+			escaped := p.next()
+			if escaped != '"' && escaped != '\\' {
+				return "", p.errorf("invalid escape %q", escaped)
+			}
+			buf.WriteByte(escaped)
+		case '"':
+			return buf.String(), nil
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) parseKey() (string, error) {
+	if p.eof() || !(isLCAlpha(p.peek()) || p.peek() == '*') {
+		return "", p.errorf("expected a parameter name")
+	}
+	return p.consume(func(c byte) bool {
+		return isLCAlpha(c) || isDigit(c) || c == '_' || c == '-' || c == '.' || c == '*'
+	}), nil
+}
+
+// Consume characters as long as `accept` returns `true`.
+func (p *parser) consume(accept func(byte) bool) string {
+	start := p.pos
+	for !p.eof() && accept(p.peek()) {
+		p.pos++
+	}
+	return p.source[start:p.pos]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLCAlpha(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
+
+func isAlpha(c byte) bool {
+	return isLCAlpha(c) || (c >= 'A' && c <= 'Z')
+}
+
+// See RFC 9110, `tchar`.
+func isTChar(c byte) bool {
+	return isAlpha(c) || isDigit(c) || strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
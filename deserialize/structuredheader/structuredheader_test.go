@@ -0,0 +1,75 @@
+package structuredheader_test
+
+import (
+	"testing"
+
+	"github.com/pasqal-io/godasse/deserialize"
+	"github.com/pasqal-io/godasse/deserialize/structuredheader"
+	"gotest.tools/v3/assert"
+)
+
+func TestParseList(t *testing.T) {
+	items, err := structuredheader.ParseList(`gzip;q=1.0, br;q=0.8,  *;q=0, "quoted \"value\"";charset=utf-8;secure, ?0, -12.5;unit="ms"`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, items, []structuredheader.Item{
+		{Value: "gzip", Params: map[string]string{"q": "1.0"}},
+		{Value: "br", Params: map[string]string{"q": "0.8"}},
+		{Value: "*", Params: map[string]string{"q": "0"}},
+		{Value: `quoted "value"`, Params: map[string]string{"charset": "utf-8", "secure": "?1"}},
+		{Value: "?0", Params: map[string]string{}},
+		{Value: "-12.5", Params: map[string]string{"unit": "ms"}},
+	})
+
+	items, err = structuredheader.ParseList("   ")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, items, []structuredheader.Item{})
+}
+
+func TestParseListErrors(t *testing.T) {
+	_, err := structuredheader.ParseList(`gzip, `)
+	assert.ErrorContains(t, err, "trailing `,`")
+
+	_, err = structuredheader.ParseList(`gzip br`)
+	assert.ErrorContains(t, err, "expected `,`")
+
+	_, err = structuredheader.ParseList(`(gzip br), deflate`)
+	assert.ErrorContains(t, err, "inner lists are not supported")
+
+	_, err = structuredheader.ParseList(`"unterminated`)
+	assert.ErrorContains(t, err, "unterminated string")
+
+	_, err = structuredheader.ParseList(`gzip;Q=1`)
+	assert.ErrorContains(t, err, "expected a parameter name")
+}
+
+type Request struct {
+	AcceptEncoding structuredheader.List `json:"acceptEncoding" query:"accept-encoding"`
+}
+
+func TestDeserializeList(t *testing.T) {
+	expected := Request{
+		AcceptEncoding: structuredheader.List{
+			Items: []structuredheader.Item{
+				{Value: "gzip", Params: map[string]string{"q": "1.0"}},
+				{Value: "br", Params: map[string]string{"q": "0.8"}},
+			},
+		},
+	}
+
+	jsonDeserializer, err := deserialize.MakeMapDeserializer[Request](deserialize.JSONOptions(""))
+	assert.NilError(t, err)
+	found, err := jsonDeserializer.DeserializeString(`{"acceptEncoding": "gzip;q=1.0, br;q=0.8"}`)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, expected)
+
+	_, err = jsonDeserializer.DeserializeString(`{"acceptEncoding": "gzip;q=1.0,"}`)
+	assert.ErrorContains(t, err, "trailing `,`")
+
+	kvDeserializer, err := deserialize.MakeKVListDeserializer[Request](deserialize.QueryOptions(""))
+	assert.NilError(t, err)
+	found, err = kvDeserializer.DeserializeKVList(map[string][]string{
+		"accept-encoding": {"gzip;q=1.0, br;q=0.8"},
+	})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *found, expected)
+}
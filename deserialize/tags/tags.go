@@ -84,9 +84,26 @@ func Parse(tag reflect.StructTag) (Tags, error) {
 		case "default":
 			fallthrough
 		case "orMethod":
+			fallthrough
+		case "trimPrefix":
+			fallthrough
+		case "trimSuffix":
+			fallthrough
+		case "pattern":
+			fallthrough
+		case "enumWhen":
+			fallthrough
+		case "split":
+			fallthrough
+		case "layout":
 			// don't pre-process
 			tags[name] = []string{list}
 		default:
+			if strings.HasSuffix(name, "Default") {
+				// A scoped default, e.g. `queryDefault`, don't pre-process.
+				tags[name] = []string{list}
+				continue
+			}
 			split := strings.Split(list, ",")
 			trimmed := make([]string, 0)
 			for _, s := range split {
@@ -108,6 +125,20 @@ func Parse(tag reflect.StructTag) (Tags, error) {
 	}, nil
 }
 
+// Return the name of an environment variable that may be used to
+// initialize a field if no value is provided.
+//
+// This is tag `defaultEnv`, e.g. `defaultEnv:"PORT"`. If the variable
+// is not set, we fall back to `default` or `orMethod`, if specified.
+func (tags Tags) DefaultEnv() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["defaultEnv"]
+	if !ok || len(result) == 0 || result[0] == "" {
+		return nil
+	}
+	return &result[0]
+}
+
 // Return the a default value that may be used to initialize a
 // field if no value is provided.
 //
@@ -135,19 +166,297 @@ func (tags Tags) MethodName() *string {
 	return &result[0]
 }
 
-// Return the public field name for a field.
+// Return a prefix to remove from string values, if present.
 //
-// e.g. for json, if there's a tag `json:"foo"`, this means
-// that the field should be imported as `foo`.
-func (tags Tags) PublicFieldName(key string) *string {
+// This is tag `trimPrefix`, e.g. `trimPrefix:"Bearer "`.
+func (tags Tags) TrimPrefix() *string {
 	tags.witness.Assert()
-	result, ok := tags.tags[key]
+	result, ok := tags.tags["trimPrefix"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return a suffix to remove from string values, if present.
+//
+// This is tag `trimSuffix`.
+func (tags Tags) TrimSuffix() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["trimSuffix"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the format in which the value of this field is escaped, if any.
+//
+// This is tag `unescape`, e.g. `unescape:"json"` for a field whose
+// value is provided as a string containing JSON.
+func (tags Tags) Unescape() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["unescape"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the name of a JSON Schema that the value of this field must match, if any.
+//
+// This is tag `schema`, e.g. `schema:"config"`.
+func (tags Tags) Schema() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["schema"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the name of the codec used to decode this field, if any.
+//
+// This is tag `codec`, e.g. `codec:"money"`.
+func (tags Tags) Codec() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["codec"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the pipeline of named string transforms to apply to this field, if any.
+//
+// This is tag `transform`, e.g. `transform:"trim|lower"`.
+func (tags Tags) Transform() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["transform"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the minimal value accepted for this numeric field, if any.
+//
+// This is tag `min`, e.g. `min:"0"`.
+func (tags Tags) Min() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["min"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the maximal value accepted for this numeric field, if any.
+//
+// This is tag `max`, e.g. `max:"100"`.
+func (tags Tags) Max() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["max"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the minimal length accepted for this string field, if any.
+//
+// This is tag `minlen`, e.g. `minlen:"1"`.
+func (tags Tags) MinLen() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["minlen"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the maximal length accepted for this string field, if any.
+//
+// This is tag `maxlen`, e.g. `maxlen:"255"`.
+func (tags Tags) MaxLen() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["maxlen"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return a regular expression that this string field must match, if any.
+//
+// This is tag `pattern`, e.g. `pattern:"^[a-z]+$"`.
+func (tags Tags) Pattern() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["pattern"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the rules constraining the values of this field depending on
+// the value of its siblings, if any.
+//
+// This is tag `enumWhen`, e.g. `enumWhen:"unit=temp:[C,F,K];unit=length:[m,km]"`.
+func (tags Tags) EnumWhen() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["enumWhen"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the case-sensitivity of `oneof` for this field, if specified.
+//
+// This is tag `enumCase`, e.g. `enumCase:"insensitive"`.
+func (tags Tags) EnumCase() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["enumCase"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the behavior to adopt when this field is provided as an
+// empty string, if specified.
+//
+// This is tag `coerceEmpty`, e.g. `coerceEmpty:"default"`.
+func (tags Tags) CoerceEmpty() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["coerceEmpty"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the list of keys, at least one of which must be present
+// in this nested object, if specified.
+//
+// This is tag `atLeastOne`, e.g. `atLeastOne:"email,phone"`.
+func (tags Tags) AtLeastOne() []string {
+	tags.witness.Assert()
+	result, ok := tags.tags["atLeastOne"]
+	if !ok || len(result) == 0 || result[0] == "" {
+		return nil
+	}
+	return result
+}
+
+// Return the minimal number of fields that must be present in this
+// nested object, if specified.
+//
+// This is tag `atLeast`, e.g. `atLeast:"2"`.
+func (tags Tags) AtLeast() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["atLeast"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return how to handle duplicate elements in this slice, if specified.
+//
+// This is tag `unique`, e.g. `unique:"error"` or `unique:"drop"`.
+func (tags Tags) Unique() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["unique"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the name of the field of the elements of this slice that must be
+// unique, if specified.
+//
+// This is tag `uniqueBy`, e.g. `uniqueBy:"Email"` on a `[]User`.
+func (tags Tags) UniqueBy() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["uniqueBy"]
 	if !ok || len(result) == 0 {
 		return nil
 	}
 	return &result[0]
 }
 
+// Return the public names of the sibling fields covered by the checksum
+// stored in this field, if specified.
+//
+// This is tag `checksumOf`, e.g. `checksumOf:"amount,currency"`. Requires tag `hash`.
+func (tags Tags) ChecksumOf() []string {
+	tags.witness.Assert()
+	result, ok := tags.tags["checksumOf"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Return the hash algorithm used to compute the checksum stored in this
+// field, if specified.
+//
+// This is tag `hash`, e.g. `hash:"sha256"`.
+func (tags Tags) Hash() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["hash"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the separator used to split a string into the fields of this nested
+// object, if specified.
+//
+// This is tag `split`, e.g. `split:","` to read `{Lat, Lng}` from `"12.3,45.6"`.
+func (tags Tags) Split() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["split"]
+	if !ok || len(result) == 0 || result[0] == "" {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the list of values accepted for this field, if specified.
+//
+// This is tag `oneof`, e.g. `oneof:"red,green,blue"`.
+func (tags Tags) OneOf() []string {
+	tags.witness.Assert()
+	result, ok := tags.tags["oneof"]
+	if !ok {
+		return nil
+	}
+	return result
+}
+
+// Return the public field name for a field.
+//
+// e.g. for json, if there's a tag `json:"foo"`, this means
+// that the field should be imported as `foo`.
+//
+// If several keys are provided, they are tried in order and
+// we use the first one that is present.
+func (tags Tags) PublicFieldName(keys ...string) *string {
+	tags.witness.Assert()
+	for _, key := range keys {
+		result, ok := tags.tags[key]
+		if ok && len(result) != 0 {
+			return &result[0]
+		}
+	}
+	return nil
+}
+
 // Return `true` if this field should be considered pre-initialized
 // (i.e. the parser should not complain of any fields immediately within
 // that field), `false` otherwise.
@@ -161,6 +470,109 @@ func (tags Tags) IsPreinitialized() bool {
 	return ok
 }
 
+// Return `true` if this field may be missing from the input, in which
+// case it is left to its zero value, `false` otherwise.
+//
+// This is tag `optional`.
+func (tags Tags) IsOptional() bool {
+	tags.witness.Assert()
+	_, ok := tags.tags["optional"]
+	return ok
+}
+
+// Return `true` if this field must be present in the input, even
+// if it has been pre-initialized, `false` otherwise.
+//
+// This is tag `required`. Conflicts with `default` and `orMethod`.
+func (tags Tags) IsRequired() bool {
+	tags.witness.Assert()
+	_, ok := tags.tags["required"]
+	return ok
+}
+
+// Return the base used to parse this integer field from a string, if specified.
+//
+// This is tag `base`, e.g. `base:"16"`, or `base:"0"` to detect the base from
+// prefixes such as `0x`.
+func (tags Tags) Base() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["base"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the layout used to parse this time field from a string, if specified.
+//
+// This is tag `layout`, e.g. `layout:"2006-01-02"` or `layout:"Jan 2, 2006"`.
+func (tags Tags) Layout() *string {
+	tags.witness.Assert()
+	result, ok := tags.tags["layout"]
+	if !ok || len(result) == 0 || result[0] == "" {
+		return nil
+	}
+	return &result[0]
+}
+
+// Return the name of the flag that enables this field, optionally followed
+// by how to handle values provided while the flag is disabled, if specified.
+//
+// This is tag `when`, e.g. `when:"featureX"` or `when:"featureX,reject"`.
+func (tags Tags) When() []string {
+	tags.witness.Assert()
+	result, ok := tags.tags["when"]
+	if !ok || len(result) == 0 || result[0] == "" {
+		return nil
+	}
+	return result
+}
+
+// Return `true` if this field may only be set by the server, e.g. through
+// `default`, `orMethod` or `Initializer`, `false` otherwise.
+//
+// This is tag `readOnly`. Conflicts with `required`.
+func (tags Tags) IsReadOnly() bool {
+	tags.witness.Assert()
+	_, ok := tags.tags["readOnly"]
+	return ok
+}
+
+// Return `true` if this map field should reject empty-string keys,
+// `false` otherwise.
+//
+// This is tag `rejectEmptyKeys`.
+func (tags Tags) RejectEmptyKeys() bool {
+	tags.witness.Assert()
+	_, ok := tags.tags["rejectEmptyKeys"]
+	return ok
+}
+
+// Return the names of the parsers to try, in order, on string inputs,
+// if specified.
+//
+// This is tag `parsers`, e.g. `parsers:"rfc3339,unix,date"`.
+func (tags Tags) Parsers() []string {
+	tags.witness.Assert()
+	result, ok := tags.tags["parsers"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
+// Return the only keys accepted by this map field, if specified.
+//
+// This is tag `allowedKeys`, e.g. `allowedKeys:"read,write,admin"`.
+func (tags Tags) AllowedKeys() []string {
+	tags.witness.Assert()
+	result, ok := tags.tags["allowedKeys"]
+	if !ok || len(result) == 0 {
+		return nil
+	}
+	return result
+}
+
 // Return `true` if this field is marked as `flatten`, e.g.
 //
 //	type Flattening struct {
@@ -191,3 +603,55 @@ func (tags Tags) Lookup(key string) ([]string, bool) {
 	result, ok := tags.tags[key]
 	return result, ok
 }
+
+// Return a copy of these tags in which `default` is replaced by the default
+// scoped to `scope`, if any.
+//
+// For instance, with scope `query`, tags `default:"10" queryDefault:"25"`
+// are resolved into `default:"25"`.
+func (tags Tags) WithScopedDefault(scope string) Tags {
+	tags.witness.Assert()
+	scoped, ok := tags.tags[scope+"Default"]
+	if !ok {
+		return tags
+	}
+	result := make(map[string][]string, len(tags.tags))
+	for k, v := range tags.tags {
+		result[k] = v
+	}
+	result["default"] = scoped
+	return Tags{
+		tags:    result,
+		witness: initialized.Make(),
+	}
+}
+
+// Return a copy of these tags, containing only `keys`, if present.
+func (tags Tags) Only(keys ...string) Tags {
+	tags.witness.Assert()
+	result := make(map[string][]string, len(keys))
+	for _, key := range keys {
+		if v, ok := tags.tags[key]; ok {
+			result[key] = v
+		}
+	}
+	return Tags{
+		tags:    result,
+		witness: initialized.Make(),
+	}
+}
+
+// Return a copy of these tags, without `key`.
+func (tags Tags) Without(key string) Tags {
+	tags.witness.Assert()
+	result := make(map[string][]string, len(tags.tags))
+	for k, v := range tags.tags {
+		if k != key {
+			result[k] = v
+		}
+	}
+	return Tags{
+		tags:    result,
+		witness: initialized.Make(),
+	}
+}
@@ -15,6 +15,10 @@ type RandomStruct struct {
 	DefaultStruct string  `default:"{}"`
 	Repeat        string  `abc:"" abc:""` //lint:ignore SA5008 we're testing for this
 	Interesting   string  `default:"abc, def" orMethod:"SomeMethod" renaming:"interesting" initialized:"arbitrary content"`
+	Trimmed       string  `trimPrefix:"Bearer " trimSuffix:", "`
+	Escaped       string  `unescape:"json" renaming:"escaped"`
+	Required      string  `required:""`
+	ReadOnly      string  `readOnly:"true"`
 }
 
 func TestReadTags(t *testing.T) {
@@ -160,4 +164,168 @@ func TestInteresting(t *testing.T) {
 
 	publicName := parsed.PublicFieldName("renaming")
 	assert.Equal(t, *publicName, "interesting", "We should have returned the correct renaming")
+
+	publicName = parsed.PublicFieldName("absent", "renaming")
+	assert.Equal(t, *publicName, "interesting", "We should have fallen back to the second renaming")
+
+	publicName = parsed.PublicFieldName("absent", "also absent")
+	assert.Assert(t, publicName == nil, "We should have found no renaming")
+}
+
+// Trimming tags should not be pre-processed.
+func TestTrim(t *testing.T) {
+	reflectT := reflect.TypeOf(RandomStruct{}) //nolint:exhaustruct
+	reflectField, _ := reflectT.FieldByName("Trimmed")
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.TrimPrefix(), "Bearer ", "Prefix should have remained untrimmed")
+	assert.Equal(t, *parsed.TrimSuffix(), ", ", "Suffix should have remained untrimmed")
+}
+
+func TestWithout(t *testing.T) {
+	reflectT := reflect.TypeOf(RandomStruct{}) //nolint:exhaustruct
+	reflectField, _ := reflectT.FieldByName("Escaped")
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.Unescape(), "json", "We should have found tag unescape")
+
+	without := parsed.Without("unescape")
+	assert.Assert(t, without.Unescape() == nil, "We should have removed tag unescape")
+	assert.Equal(t, *without.PublicFieldName("renaming"), "escaped", "We should have kept the other tags")
+	assert.Equal(t, *parsed.Unescape(), "json", "The original tags should not have changed")
+}
+
+func TestRequired(t *testing.T) {
+	reflectT := reflect.TypeOf(RandomStruct{}) //nolint:exhaustruct
+	for _, name := range []string{"Required", "Escaped"} {
+		reflectField, _ := reflectT.FieldByName(name)
+		parsed, err := tags.Parse(reflectField.Tag)
+		if err != nil {
+			t.Error("Failed to parse tags ", err)
+
+			return
+		}
+		assert.Equal(t, parsed.IsRequired(), name == "Required", "Only field Required should be required")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	reflectT := reflect.TypeOf(RandomStruct{}) //nolint:exhaustruct
+	for _, name := range []string{"ReadOnly", "Required"} {
+		reflectField, _ := reflectT.FieldByName(name)
+		parsed, err := tags.Parse(reflectField.Tag)
+		if err != nil {
+			t.Error("Failed to parse tags ", err)
+
+			return
+		}
+		assert.Equal(t, parsed.IsReadOnly(), name == "ReadOnly", "Only field ReadOnly should be read-only")
+	}
+}
+
+// Patterns should not be pre-processed.
+func TestPattern(t *testing.T) {
+	type PatternStruct struct {
+		Field string `pattern:"^[a-z]{1, 3}$" minlen:"1" maxlen:"3"`
+	}
+	reflectField, _ := reflect.TypeOf(PatternStruct{}).FieldByName("Field") //nolint:exhaustruct
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.Pattern(), "^[a-z]{1, 3}$", "Pattern should have remained untrimmed")
+	assert.Equal(t, *parsed.MinLen(), "1")
+	assert.Equal(t, *parsed.MaxLen(), "3")
+	assert.Assert(t, parsed.Min() == nil)
+}
+
+func TestEnumWhen(t *testing.T) {
+	type EnumWhenStruct struct {
+		Field string `enumWhen:"unit=temp:[C,F,K];unit=length:[m,km]"`
+	}
+	reflectField, _ := reflect.TypeOf(EnumWhenStruct{}).FieldByName("Field") //nolint:exhaustruct
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.EnumWhen(), "unit=temp:[C,F,K];unit=length:[m,km]", "EnumWhen should not have been split")
+}
+
+func TestWhen(t *testing.T) {
+	type WhenStruct struct {
+		Field string `when:"featureX,reject"`
+	}
+	reflectField, _ := reflect.TypeOf(WhenStruct{}).FieldByName("Field") //nolint:exhaustruct
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.DeepEqual(t, parsed.When(), []string{"featureX", "reject"})
+}
+
+func TestLayout(t *testing.T) {
+	type LayoutStruct struct {
+		Field string `layout:"Jan 2, 2006"`
+	}
+	reflectField, _ := reflect.TypeOf(LayoutStruct{}).FieldByName("Field") //nolint:exhaustruct
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.Layout(), "Jan 2, 2006", "Layout should not have been split")
+}
+
+func TestSplit(t *testing.T) {
+	type SplitStruct struct {
+		Field struct{} `split:","`
+	}
+	reflectField, _ := reflect.TypeOf(SplitStruct{}).FieldByName("Field") //nolint:exhaustruct
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.Split(), ",", "Split should not have been split")
+}
+
+func TestWithScopedDefault(t *testing.T) {
+	type ScopedDefaultStruct struct {
+		Field string `default:"a,b" queryDefault:"c,d"`
+	}
+	reflectField, _ := reflect.TypeOf(ScopedDefaultStruct{}).FieldByName("Field") //nolint:exhaustruct
+	parsed, err := tags.Parse(reflectField.Tag)
+	if err != nil {
+		t.Error("Failed to parse tags ", err)
+
+		return
+	}
+
+	assert.Equal(t, *parsed.Default(), "a,b", "Default should not have been split")
+	assert.Equal(t, *parsed.WithScopedDefault("query").Default(), "c,d", "We should have used the scoped default")
+	assert.Equal(t, *parsed.WithScopedDefault("json").Default(), "a,b", "We should have kept the default")
+	assert.Equal(t, *parsed.Default(), "a,b", "The original tags should not have changed")
 }
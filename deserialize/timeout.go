@@ -0,0 +1,55 @@
+package deserialize
+
+import (
+	"fmt"
+	"time"
+)
+
+// The outcome of a custom call running in a goroutine.
+type customCallOutcome[T any] struct {
+	result T
+	err    error
+
+	// If non-nil, the value with which the call panicked.
+	panicked any
+}
+
+// Run a custom call, e.g. an `orMethod` or an initializer, giving up
+// after `timeout`.
+//
+// If `timeout` is 0, simply run `call`.
+//
+// Otherwise, `call` runs in a goroutine. If it doesn't complete in time,
+// we return an error but `call` keeps running in the background, as Go
+// offers no way to interrupt it. Panics in `call` are propagated to the
+// caller, as they would be without a timeout.
+func callWithTimeout[T any](timeout time.Duration, name string, path string, call func() (T, error)) (T, error) {
+	if timeout <= 0 {
+		return call()
+	}
+	// Buffered, so that a late call can complete without blocking forever.
+	done := make(chan customCallOutcome[T], 1)
+	go func() {
+		var outcome customCallOutcome[T]
+		defer func() {
+			if panicked := recover(); panicked != nil {
+				outcome.panicked = panicked
+			}
+			done <- outcome
+		}()
+		outcome.result, outcome.err = call()
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case outcome := <-done:
+		if outcome.panicked != nil {
+			panic(outcome.panicked)
+		}
+		return outcome.result, outcome.err
+	case <-timer.C:
+		var zero T
+		return zero, fmt.Errorf("custom method %s at %s timed out", name, path)
+	}
+}
@@ -0,0 +1,188 @@
+package deserialize
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// A union registered with `RegisterUnion`.
+type union struct {
+	// The key holding the name of the variant, e.g. "type".
+	discriminator string
+
+	// The concrete types, by name.
+	variants map[string]reflect.Type
+}
+
+// Registered unions, by interface type.
+var unions sync.Map
+
+// Register the concrete types that may be deserialized into fields of
+// interface type `T`.
+//
+// When deserializing such a field, the value must be an object, and its key
+// `discriminator` (e.g. "type") the name of one of the `variants`, e.g. with
+//
+//	RegisterUnion[Shape](map[string]reflect.Type{
+//		"circle": reflect.TypeOf(Circle{}),
+//		"square": reflect.TypeOf(Square{}),
+//	}, "type")
+//
+// `{"type": "circle", "radius": 3}` is deserialized as a `Circle` and
+// stored in the field. If `Circle` implements `T` only through its pointer,
+// a `*Circle` is stored instead.
+//
+// Note that the discriminator is passed to the concrete deserializer along
+// with the other keys, so if you use `DisallowUnknownFields`, the concrete
+// types must declare a field for it.
+//
+// Call this before building any deserializer, typically from `init()`.
+// Registering `T` again replaces the previous registration.
+func RegisterUnion[T any](variants map[string]reflect.Type, discriminator string) error {
+	interfaceType := reflect.TypeOf(new(T)).Elem()
+	if interfaceType.Kind() != reflect.Interface {
+		return fmt.Errorf("cannot register union %s, expected an interface", interfaceType)
+	}
+	if discriminator == "" {
+		return fmt.Errorf("cannot register union %s, missing discriminator", interfaceType)
+	}
+	if len(variants) == 0 {
+		return fmt.Errorf("cannot register union %s, missing variants", interfaceType)
+	}
+	registered := make(map[string]reflect.Type, len(variants))
+	for name, variant := range variants {
+		if variant == nil {
+			return fmt.Errorf("cannot register union %s, variant %s is nil", interfaceType, name)
+		}
+		if !variant.Implements(interfaceType) && !reflect.PointerTo(variant).Implements(interfaceType) {
+			return fmt.Errorf("cannot register union %s, variant %s has type %s, which does not implement it", interfaceType, name, variant)
+		}
+		registered[name] = variant
+	}
+	unions.Store(interfaceType, union{
+		discriminator: discriminator,
+		variants:      registered,
+	})
+
+	// Previously compiled deserializers may have been compiled without this union.
+	deserializerCache.Range(func(key, _ any) bool {
+		deserializerCache.Delete(key)
+		return true
+	})
+	return nil
+}
+
+// Find the union registered for interface type `typ`, if any.
+func lookupUnion(typ reflect.Type) (union, bool) {
+	found, ok := unions.Load(typ)
+	if !ok {
+		return union{}, false
+	}
+	result, ok := found.(union)
+	return result, ok
+}
+
+// A compiled variant of a union.
+type unionVariant struct {
+	// The concrete type to deserialize.
+	typ reflect.Type
+
+	// If `true`, store a pointer to the concrete value.
+	byPointer bool
+
+	// The deserializer for `typ`.
+	deserializer reflectDeserializer
+}
+
+// Construct a dynamically-typed deserializer for an interface field registered with `RegisterUnion`.
+//
+//   - `fieldPath` the human-readable path into the data structure, used for error-reporting;
+//   - `fieldType` the dynamic type for the interface being compiled;
+//   - `union` the registration for `fieldType`;
+//   - `tags` the table of tags for this field.
+func makeUnionDeserializer(fieldPath string, fieldType reflect.Type, union union, options innerOptions, tags *tagsPkg.Tags, wasPreinitialized bool) (reflectDeserializer, error) {
+	isNilDefault := false
+	if defaultSource := tags.Default(); defaultSource != nil {
+		if *defaultSource != "nil" {
+			return nil, fmt.Errorf("at %s, invalid `default` value. The only supported `default` value for unions is \"nil\", got: %s", fieldPath, *defaultSource)
+		}
+		isNilDefault = true
+	}
+	if tags.MethodName() != nil {
+		return nil, fmt.Errorf("at %s, field is a union, it cannot have an `orMethod`", fieldPath)
+	}
+
+	variants := make(map[string]unionVariant, len(union.variants))
+	names := make([]string, 0, len(union.variants))
+	for name, typ := range union.variants {
+		variantPath := fmt.Sprint(fieldPath, "[", name, "]")
+		subTags := tagsPkg.Empty()
+		subContainer := reflect.New(typ).Elem()
+		deserializer, err := makeFieldDeserializerFromReflect(variantPath, typ, options, &subTags, subContainer, false, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate a deserializer for %s\n\t * %w", variantPath, err)
+		}
+		variants[name] = unionVariant{
+			typ:          typ,
+			byPointer:    !typ.Implements(fieldType),
+			deserializer: deserializer,
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	expected := strings.Join(names, ", ")
+
+	result := func(outPtr *reflect.Value, inValue shared.Value, call *callData) error {
+		if inValue == nil {
+			switch {
+			case wasPreinitialized:
+				// No value? That's ok, we got a value from preinitialization.
+				return nil
+			case isNilDefault:
+				outPtr.SetZero()
+				return nil
+			default:
+				return MissingFieldError{Path: fieldPath, Type: typeName(fieldType), description: missingValue}
+			}
+		}
+		if inValue.Interface() == nil && isNilDefault {
+			outPtr.SetZero()
+			return nil
+		}
+		dict, ok := inValue.AsDict()
+		if !ok {
+			return newTypeMismatchError(fieldPath, typeName(fieldType), inValue.Interface(), mismatchObject)
+		}
+		discriminator, ok := dict.Lookup(union.discriminator)
+		if !ok {
+			return MissingFieldError{Path: fieldPath + "." + union.discriminator, Type: "one of " + expected, description: missingValue}
+		}
+		name, ok := discriminator.Interface().(string)
+		if !ok {
+			return newTypeMismatchError(fieldPath+"."+union.discriminator, "string", discriminator.Interface(), mismatchValue)
+		}
+		variant, ok := variants[name]
+		if !ok {
+			return fmt.Errorf("invalid value at %s.%s, expected one of %s, got %s", fieldPath, union.discriminator, expected, name)
+		}
+		slot := reflect.New(variant.typ)
+		value := slot.Elem()
+		err := variant.deserializer(&value, inValue, call)
+		if err != nil {
+			return err
+		}
+		if variant.byPointer {
+			outPtr.Set(slot)
+		} else {
+			outPtr.Set(value)
+		}
+		return nil
+	}
+	return result, nil
+}
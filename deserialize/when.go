@@ -0,0 +1,55 @@
+package deserialize
+
+import (
+	"fmt"
+	"strconv"
+
+	tagsPkg "github.com/pasqal-io/godasse/deserialize/tags"
+)
+
+// A guard specified with tag `when`, e.g. `when:"featureX,reject"`.
+type whenGuard struct {
+	// The name of the flag in the bag of values, e.g. `featureX`.
+	flag string
+
+	// If `true`, reject values provided while the flag is disabled.
+	// Otherwise, ignore them.
+	reject bool
+}
+
+// Parse tag `when`, if specified.
+func parseWhen(fieldPath string, tags *tagsPkg.Tags) (*whenGuard, error) {
+	when := tags.When()
+	if when == nil {
+		return nil, nil
+	}
+	guard := whenGuard{
+		flag:   when[0],
+		reject: false,
+	}
+	switch {
+	case len(when) == 1:
+	case len(when) == 2 && when[1] == "ignore":
+	case len(when) == 2 && when[1] == "reject":
+		guard.reject = true
+	default:
+		return nil, fmt.Errorf("at %s, invalid `when` value, expected a flag name optionally followed by \"ignore\" or \"reject\"", fieldPath)
+	}
+	return &guard, nil
+}
+
+// Determine whether the flag is enabled in the bag of values.
+//
+// The flag is enabled if its value is `true` or a string that parses as `true`,
+// e.g. "true" or "1". Missing flags are disabled.
+func (guard *whenGuard) isEnabled(call *callData) bool {
+	switch value := call.values[guard.flag].(type) {
+	case bool:
+		return value
+	case string:
+		enabled, err := strconv.ParseBool(value)
+		return err == nil && enabled
+	default:
+		return false
+	}
+}
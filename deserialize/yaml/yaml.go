@@ -0,0 +1,194 @@
+// Code specific to deserializing YAML.
+package yaml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/pasqal-io/godasse/deserialize/shared"
+	"gopkg.in/yaml.v3"
+)
+
+// The deserialization driver for YAML.
+type driver struct{}
+
+func Driver() shared.Driver {
+	return driver{}
+}
+
+// A YAML value.
+type Value struct {
+	wrapped any
+}
+
+// A YAML mapping.
+type YAML map[string]any
+
+func (v Value) AsDict() (shared.Dict, bool) {
+	switch t := v.wrapped.(type) {
+	case YAML:
+		return t, true
+	case map[string]any:
+		var yaml YAML = t
+		return yaml, true
+	case map[any]any:
+		// YAML supports non-string keys, e.g. `1: abc`. Since our
+		// dictionaries are keyed by strings, we convert them back.
+		yaml := make(YAML, len(t))
+		for k, v := range t {
+			yaml[fmt.Sprint(k)] = v
+		}
+		return yaml, true
+	case nil:
+		var yaml YAML = map[string]any{}
+		return yaml, true
+	default:
+		return nil, false
+	}
+}
+func (v Value) AsSlice() ([]shared.Value, bool) {
+	// We can't simply cast to `[]any`, as this doesn't work for e.g. `[]string`.
+	reflected := reflect.ValueOf(v.wrapped)
+	if !reflected.IsValid() {
+		return nil, false
+	}
+	switch reflected.Type().Kind() {
+	case reflect.Array:
+		fallthrough
+	case reflect.Slice:
+		length := reflected.Len()
+		result := make([]shared.Value, length)
+		for i := 0; i < length; i++ {
+			value := reflected.Index(i)
+			result[i] = Value{wrapped: value.Interface()}
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+func (v Value) Interface() any {
+	return v.wrapped
+}
+
+var _ shared.Value = Value{} //nolint:exhaustruct
+
+func (yaml YAML) Lookup(key string) (shared.Value, bool) {
+	if val, ok := yaml[key]; ok {
+		value := Value{
+			wrapped: val,
+		}
+		return value, true
+	}
+	return nil, false
+}
+func (yaml YAML) AsValue() shared.Value {
+	return Value{
+		wrapped: yaml,
+	}
+}
+func (yaml YAML) Keys() []string {
+	keys := make([]string, 0)
+	for k := range yaml {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ shared.Dict = YAML{} //nolint:exhaustruct
+
+// The type of a YAML/Dictionary.
+var dictionary = reflect.TypeOf(make(YAML, 0))
+
+// The interface for `yaml.Unmarshaler`.
+var unmarshaler = reflect.TypeOf(new(yaml.Unmarshaler)).Elem()
+var textUnmarshaler = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
+
+// Determine whether we should call the driver to unmarshal values
+// of this type from []byte.
+//
+// For YAML, this is the case if:
+// - `typ` represents a dictionary; and/or
+// - `typ` implements `yaml.Unmarshaler` or `encoding.TextUnmarshaler`.
+//
+// You probably won't ever need to call this method.
+func (driver) ShouldUnmarshal(typ reflect.Type) bool {
+	if typ.ConvertibleTo(dictionary) {
+		return true
+	}
+	ptr := reflect.PointerTo(typ)
+	return ptr.ConvertibleTo(unmarshaler) || ptr.ConvertibleTo(textUnmarshaler)
+}
+
+// Perform unmarshaling.
+//
+// You probably won't ever need to call this method.
+func (u driver) Unmarshal(in any, out *any) (err error) {
+	var buf []byte
+	switch typed := in.(type) {
+	// Normalize string, []byte into []byte.
+	case string:
+		buf = []byte(typed)
+	case []byte:
+		buf = typed
+	// Unwrap Value.
+	case Value:
+		return u.Unmarshal(typed.wrapped, out)
+	case YAML, map[string]any, map[any]any, []any:
+		// Sadly, at this stage, we need to reserialize.
+		buf, err = yaml.Marshal(typed)
+		if err != nil {
+			return fmt.Errorf("internal error while deserializing: \n\t * %w", err)
+		}
+	default:
+		return fmt.Errorf("expected a string, got %s", in)
+	}
+
+	// If `out` already contains a pointer, e.g. `*time.Time`, deserialize
+	// into that pointer. Otherwise, deserialize into `out` itself.
+	var target any = out
+	if *out != nil && reflect.TypeOf(*out).Kind() == reflect.Pointer {
+		target = *out
+	}
+
+	// Note: `yaml.Unmarshal` takes care of `yaml.Unmarshaler`.
+	err = yaml.Unmarshal(buf, target)
+	if err == nil {
+		// Basic YAML decoding worked, let's go with it.
+		return nil
+	}
+	// YAML scalars are often unquoted, so let's try again with UnmarshalText.
+	if textUnmarshaler, ok := target.(encoding.TextUnmarshaler); ok {
+		err2 := textUnmarshaler.UnmarshalText(buf)
+		if err2 == nil {
+			// Success! Let's use that result.
+			return nil
+		}
+		return fmt.Errorf("failed to unmarshal '%s' either from YAML or from text: \n\t * %w\n\t * and %w", buf, err, err2)
+	}
+	return fmt.Errorf("failed to unmarshal '%s': \n\t * %w", buf, err)
+}
+
+// Perform marshaling.
+//
+// You probably won't ever need to call this method.
+func (driver) Marshal(in any) ([]byte, error) {
+	return yaml.Marshal(in) //nolint:wrapcheck
+}
+
+func (driver) WrapValue(wrapped any) shared.Value {
+	return Value{
+		wrapped: wrapped,
+	}
+}
+
+func (driver) Enter(string, reflect.Type) error {
+	// No particular protocol to follow.
+	return nil
+}
+func (driver) Exit(reflect.Type) {
+	// No particular protocol to follow.
+}
+
+var _ shared.MarshalingDriver = driver{} // Type assertion.
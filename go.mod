@@ -3,7 +3,15 @@ module github.com/pasqal-io/godasse
 go 1.21
 
 require (
+	github.com/fxamacker/cbor/v2 v2.7.0
 	github.com/google/go-cmp v0.5.9
 	github.com/google/uuid v1.6.0
+	github.com/santhosh-tekuri/jsonschema/v5 v5.3.1
+	github.com/titanous/json5 v1.0.0
+	golang.org/x/text v0.14.0
+	google.golang.org/protobuf v1.36.1
+	gopkg.in/yaml.v3 v3.0.1
 	gotest.tools/v3 v3.5.1
 )
+
+require github.com/x448/float16 v0.8.4 // indirect
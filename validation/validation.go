@@ -5,8 +5,11 @@
 package validation
 
 import (
+	"context"
 	"fmt"
 	"reflect"
+	"slices"
+	"strings"
 )
 
 // A type that supports initialization.
@@ -35,6 +38,9 @@ type Initializer interface {
 // This lets `Validate()` perform any necessary changes to the data
 // structure. In particular, if necessary, it may be used to populate
 // private fields from the contents of public fields.
+//
+// `Validator` may be implemented by structs but also by named types
+// such as `type Email string`.
 type Validator interface {
 	// Confirm that the data is valid.
 	//
@@ -44,6 +50,122 @@ type Validator interface {
 	Validate() error
 }
 
+// A type that supports a last step once the entire value has been built.
+//
+// Our deserialization library calls `Finalize()` only on the top-level value,
+// once, **after** every node of the tree has been built, i.e. after the
+// `Validate()` of the top-level value and of all its children. This is the
+// place to compute derived state that spans the whole tree and may assume
+// that every node is valid. `Finalize()` is not called on nested values.
+//
+// If `Finalize()` returns an error, deserialization fails with a
+// validation error.
+//
+// Important: We expect `Finalizer` to be implemented on **pointers**,
+// rather than on structs.
+type Finalizer interface {
+	// Complete the value.
+	//
+	// Return an error if it is invalid.
+	Finalize() error
+}
+
+// A variant of `Validator` that receives the path of the value being
+// validated, e.g. `Request.items[2]`, so that error messages may
+// reference it.
+//
+// Note that during deserialization, the path is computed once per type,
+// so the elements of slices and maps are denoted `items[]`, without
+// their index or key.
+//
+// If a type implements both `PathAwareValidator` and `Validator`, only
+// `ValidateAt()` is called. If it also implements `ContextValidator`,
+// only `ValidateContext()` is called.
+//
+// Important: We expect `PathAwareValidator` to be implemented on **pointers**,
+// rather than on structs.
+type PathAwareValidator interface {
+	// Confirm that the data at `path` is valid.
+	//
+	// Return an error if it is invalid.
+	//
+	// If necessary, this method may alter the contents of the struct.
+	ValidateAt(path string) error
+}
+
+// A variant of `Initializer` that receives a `context.Context`.
+//
+// When deserialization is invoked with a context (e.g. with
+// `DeserializeDictContext`), this context is passed to `InitializeContext()`.
+// Otherwise, `context.Background()` is passed.
+//
+// If a type implements both `ContextInitializer` and `Initializer`, only
+// `InitializeContext()` is called.
+//
+// Important: We expect `ContextInitializer` to be implemented on **pointers**,
+// rather than on structs.
+type ContextInitializer interface {
+	// Setup the contents of the struct.
+	InitializeContext(context.Context) error
+}
+
+// A variant of `Validator` that receives a `context.Context`, e.g. to access
+// request-scoped data such as a database handle or a tenant.
+//
+// When deserialization is invoked with a context (e.g. with
+// `DeserializeDictContext`), this context is passed to `ValidateContext()`.
+// Otherwise, `context.Background()` is passed.
+//
+// If a type implements both `ContextValidator` and `Validator`, only
+// `ValidateContext()` is called.
+//
+// Important: We expect `ContextValidator` to be implemented on **pointers**,
+// rather than on structs.
+type ContextValidator interface {
+	// Confirm that the data is valid.
+	//
+	// Return an error if it is invalid.
+	//
+	// If necessary, this method may alter the contents of the struct.
+	ValidateContext(context.Context) error
+}
+
+// A type that supports computing derived fields.
+//
+// Our deserialization library automatically runs any call to `ComputeDefaults()`,
+// at every depth of the tree, **after** having populated all the fields of
+// the node and **before** calling `Validate()`. This is the place to fill
+// fields whose default value depends on other fields.
+//
+// By opposition to `Initialize()`, which runs before the fields are populated,
+// `ComputeDefaults()` can read the fields provided by the data.
+//
+// Important: We expect `Defaulter` to be implemented on **pointers**,
+// rather than on structs.
+type Defaulter interface {
+	// Fill derived fields.
+	ComputeDefaults() error
+}
+
+// A type that accepts a bag of values provided by the caller, e.g.
+// a tenant or a locale.
+//
+// When deserialization is invoked with a bag of values (e.g. with
+// `DeserializeDictWithValues`), our deserialization library calls
+// `SetValues()` at every depth of the tree, immediately after creating
+// the node, i.e. **before** `Initialize()` and `Validate()`. Otherwise,
+// `SetValues()` is not called.
+//
+// Each call receives its own copy of the bag, so modifying it has no effect
+// on other nodes. Consider the bag as read-only.
+//
+// Important: We expect `Values` to be implemented on **pointers**,
+// rather than on structs.
+type Values interface {
+	// Receive the bag of values.
+	SetValues(map[string]any)
+}
+
 // A validation error.
 //
 // Use errors.As() or Unwrap() to expose the error returned by Validate().
@@ -72,34 +194,36 @@ func WrapError(at string, wrapped error) Error {
 }
 
 // Extract a human-readable string.
+//
+// The path is rendered as `Root.field[index].field[key]`. Errors raised
+// while validating a map key, rather than a map value, are rendered as
+// `Root.field[>> key <<]`.
 func (v Error) Error() string {
-	serialized := v.unstructedPath
+	return fmt.Sprintf("validation error at %s:\n\t * %s", v.renderPath(), v.wrapped.Error())
+}
 
-	buf := []string{}
-	cursor := v.structuredPath
-	for cursor != nil {
-		switch cursor.kind {
-		case kindField:
-			buf = append(buf, fmt.Sprint(".", cursor.entry))
-		case kindIndex:
-			buf = append(buf, fmt.Sprintf("[%d]", cursor.entry))
-		case kindKey:
-			buf = append(buf, fmt.Sprintf("[>> %v <<]", cursor.entry))
-		case kindValue:
-			buf = append(buf, fmt.Sprintf("[%v]", cursor.entry))
-		case kindRoot:
-			buf = append(buf, fmt.Sprint(cursor.entry))
-		case kindInterface:
-			// Keep buf unchanged.
-		case kindDereference:
-			// Keep buf unchanged.
+// Render the path as a human-readable string, e.g. `Root.field[index]`.
+func (v Error) renderPath() string {
+	var buf strings.Builder
+	buf.WriteString(v.unstructedPath)
+	for cursor := v.structuredPath; cursor != nil; cursor = cursor.prev {
+		if cursor.kind == kindRoot {
+			buf.WriteString(fmt.Sprint(cursor.entry))
 		}
-		cursor = cursor.prev
 	}
-	for i := len(buf) - 1; i >= 0; i-- {
-		serialized += buf[i]
+	for _, segment := range v.Path() {
+		switch segment.Kind {
+		case Field:
+			buf.WriteString(fmt.Sprint(".", segment.Entry))
+		case Index:
+			buf.WriteString(fmt.Sprintf("[%d]", segment.Entry))
+		case Key:
+			buf.WriteString(fmt.Sprintf("[>> %v <<]", segment.Entry))
+		case Value:
+			buf.WriteString(fmt.Sprintf("[%v]", segment.Entry))
+		}
 	}
-	return fmt.Sprintf("validation error at %s:\n\t * %s", serialized, v.wrapped.Error())
+	return buf.String()
 }
 
 // Unwrap the underlying validation error.
@@ -107,6 +231,77 @@ func (v Error) Unwrap() error {
 	return v.wrapped
 }
 
+// The kind of a segment in the path of a validation error.
+type PathSegmentKind string
+
+const (
+	// A field within a struct. The entry is the name of the field, as a `string`.
+	Field PathSegmentKind = "FIELD"
+
+	// An element within a slice or array. The entry is the index, as an `int`.
+	Index PathSegmentKind = "INDEX"
+
+	// A key within a map, i.e. the error was raised while validating the key itself.
+	// The entry is the key.
+	Key PathSegmentKind = "KEY"
+
+	// A value within a map. The entry is the key leading to that value.
+	Value PathSegmentKind = "VALUE"
+)
+
+// A segment in the path of a validation error.
+type PathSegment struct {
+	// The kind of segment.
+	Kind PathSegmentKind
+
+	// The field name, index or map key.
+	Entry any
+}
+
+// The path at which the validation error happened, from the root
+// of the data structure to the faulty node.
+//
+// The root itself, pointer dereferences and interfaces are not part of
+// the path, so that e.g. `Slice[0].Kind` is represented as
+//
+//	[{Field Slice} {Index 0} {Field Kind}]
+//
+// As of this writing, the structured path is only available if the
+// error was created by calling `validation.Validate`. Errors created
+// with `WrapError` return `nil`.
+func (v Error) Path() []PathSegment {
+	if v.structuredPath == nil {
+		return nil
+	}
+	result := []PathSegment{}
+	for cursor := v.structuredPath; cursor != nil; cursor = cursor.prev {
+		var kind PathSegmentKind
+		switch cursor.kind {
+		case kindField:
+			kind = Field
+		case kindIndex:
+			kind = Index
+		case kindKey:
+			kind = Key
+		case kindValue:
+			kind = Value
+		case kindRoot, kindInterface, kindDereference:
+			continue
+		}
+		entry := cursor.entry
+		if reflected, ok := entry.(reflect.Value); ok && reflected.CanInterface() {
+			// Map keys are stored as `reflect.Value`.
+			entry = reflected.Interface()
+		}
+		result = append(result, PathSegment{
+			Kind:  kind,
+			Entry: entry,
+		})
+	}
+	slices.Reverse(result)
+	return result
+}
+
 // A type of entry in a path.
 //
 // Used to simplify path management.
@@ -154,7 +349,11 @@ func (v *path) push(entry any, kind entryKind) *path {
 	return result
 }
 
-func validateReflect(path *path, value reflect.Value) error {
+// Visit `value` and call `Validate()` at every depth of the tree.
+//
+// If `all` is `nil`, stop at the first error. Otherwise, append every
+// error to `all` and keep visiting.
+func validateReflect(path *path, value reflect.Value, all *[]error) error {
 	if !value.IsValid() {
 		// We're dealing with the unwrapped nil value, which cannot implement
 		// Validator in any way.
@@ -163,7 +362,7 @@ func validateReflect(path *path, value reflect.Value) error {
 	switch value.Type().Kind() {
 	case reflect.Interface:
 		elem := value.Elem()
-		err := validateReflect(path.push("", kindInterface), elem)
+		err := validateReflect(path.push("", kindInterface), elem, all)
 		if err != nil {
 			return err
 		}
@@ -171,7 +370,7 @@ func validateReflect(path *path, value reflect.Value) error {
 		fallthrough
 	case reflect.Slice:
 		for i := 0; i < value.Len(); i++ {
-			err := validateReflect(path.push(i, kindIndex), value.Index(i))
+			err := validateReflect(path.push(i, kindIndex), value.Index(i), all)
 			if err != nil {
 				return err
 			}
@@ -180,18 +379,18 @@ func validateReflect(path *path, value reflect.Value) error {
 		iter := value.MapRange()
 		for iter.Next() {
 			k := iter.Key()
-			err := validateReflect(path.push(k, kindKey), k)
+			err := validateReflect(path.push(k, kindKey), k, all)
 			if err != nil {
 				return err
 			}
 
-			err = validateReflect(path.push(k, kindValue), iter.Value())
+			err = validateReflect(path.push(k, kindValue), iter.Value(), all)
 			if err != nil {
 				return err
 			}
 		}
 	case reflect.Pointer:
-		err := validateReflect(path.push(0, kindDereference), value.Elem())
+		err := validateReflect(path.push(0, kindDereference), value.Elem(), all)
 		if err != nil {
 			return err
 		}
@@ -199,7 +398,7 @@ func validateReflect(path *path, value reflect.Value) error {
 		reflectedType := value.Type()
 		for i := 0; i < value.NumField(); i++ {
 			subPath := path.push(reflectedType.Field(i).Name, kindField)
-			err := validateReflect(subPath, value.Field(i))
+			err := validateReflect(subPath, value.Field(i), all)
 			if err != nil {
 				return err
 			}
@@ -227,14 +426,23 @@ func validateReflect(path *path, value reflect.Value) error {
 			}
 		}
 		asAny := toValidate.Interface()
-		if validator, ok := asAny.(Validator); ok {
-			if err := validator.Validate(); err != nil {
-				return Error{
-					wrapped:        err,
-					structuredPath: path,
-					unstructedPath: "",
-				}
+		var err error
+		if validator, ok := asAny.(PathAwareValidator); ok {
+			err = validator.ValidateAt(Error{structuredPath: path, unstructedPath: "", wrapped: nil}.renderPath())
+		} else if validator, ok := asAny.(Validator); ok {
+			err = validator.Validate()
+		}
+		if err != nil {
+			validationErr := Error{
+				wrapped:        err,
+				structuredPath: path,
+				unstructedPath: "",
+			}
+			if all != nil {
+				*all = append(*all, validationErr)
+				return nil
 			}
+			return validationErr
 		}
 	}
 	return nil
@@ -246,5 +454,26 @@ func Validate[T any](value *T) error {
 		entry: fmt.Sprintf("%T", *value),
 	}
 	reflected := reflect.ValueOf(value)
-	return validateReflect(&root, reflected)
+	return validateReflect(&root, reflected, nil)
+}
+
+// Call `Validate()` at every depth of the tree, as `Validate`, but
+// instead of stopping at the first error, return all the errors.
+//
+// Each error is a `validation.Error` with its own path. Errors are returned
+// in the order in which the tree is visited, i.e. children before their
+// parent, with the entries of maps in unspecified order. Note that a parent
+// is validated even if some of its children are invalid.
+//
+// Return `nil` if the value is valid.
+func ValidateAll[T any](value *T) []error {
+	root := path{
+		prev:  nil,
+		kind:  kindRoot,
+		entry: fmt.Sprintf("%T", *value),
+	}
+	var all []error
+	reflected := reflect.ValueOf(value)
+	_ = validateReflect(&root, reflected, &all)
+	return all
 }
@@ -353,3 +353,135 @@ func TestValidate(t *testing.T) {
 		t.Fatal("invalid error, expected a validation.Error, got", err)
 	}
 }
+
+// Tests for the structured path of validation errors.
+func TestErrorPath(t *testing.T) {
+	type Inner struct {
+		Validators []ExampleValidator
+	}
+	type Outer struct {
+		Inner   *Inner
+		ByKey   map[string]ExampleValidator
+		Wrapped any
+	}
+	validError := validation.Error{}
+
+	err := validation.Validate(&Outer{ // nolint:exhaustruct
+		Inner: &Inner{
+			Validators: []ExampleValidator{{Kind: "one"}, {Kind: "turee"}}, // nolint:exhaustruct
+		},
+	})
+	if ok := errors.As(err, &validError); !ok {
+		t.Fatal("invalid error, expected a validation.Error, got", err)
+	}
+	assert.DeepEqual(t, validError.Path(), []validation.PathSegment{
+		{Kind: validation.Field, Entry: "Inner"},
+		{Kind: validation.Field, Entry: "Validators"},
+		{Kind: validation.Index, Entry: 1},
+	})
+
+	err = validation.Validate(&Outer{ // nolint:exhaustruct
+		ByKey: map[string]ExampleValidator{"abc": {Kind: "turee"}}, // nolint:exhaustruct
+	})
+	if ok := errors.As(err, &validError); !ok {
+		t.Fatal("invalid error, expected a validation.Error, got", err)
+	}
+	assert.DeepEqual(t, validError.Path(), []validation.PathSegment{
+		{Kind: validation.Field, Entry: "ByKey"},
+		{Kind: validation.Value, Entry: "abc"},
+	})
+
+	err = validation.Validate(&Outer{ // nolint:exhaustruct
+		Wrapped: ExampleValidator{Kind: "turee"}, // nolint:exhaustruct
+	})
+	if ok := errors.As(err, &validError); !ok {
+		t.Fatal("invalid error, expected a validation.Error, got", err)
+	}
+	assert.DeepEqual(t, validError.Path(), []validation.PathSegment{
+		{Kind: validation.Field, Entry: "Wrapped"},
+	})
+
+	// Manually wrapped errors do not have a structured path.
+	validError = validation.WrapError("somewhere", errors.New("some error"))
+	assert.Check(t, validError.Path() == nil)
+}
+
+// Tests for collecting all validation errors.
+func TestValidateAll(t *testing.T) {
+	type Inner struct {
+		Validators []ExampleValidator
+	}
+	type Outer struct {
+		Inner   *Inner
+		Kind    ValidatableString
+		Wrapped any
+	}
+
+	errs := validation.ValidateAll(&Outer{
+		Inner: &Inner{
+			Validators: []ExampleValidator{{Kind: "turee"}, {Kind: "one"}, {Kind: "for"}}, // nolint:exhaustruct
+		},
+		Kind:    "fyve",
+		Wrapped: ExampleValidator{Kind: "sixe"}, // nolint:exhaustruct
+	})
+	assert.Equal(t, len(errs), 4)
+	assert.Error(t, errs[0], "validation error at validation_test.Outer.Inner.Validators[0]:\n\t * Invalid schema kind turee")
+	assert.Error(t, errs[1], "validation error at validation_test.Outer.Inner.Validators[2]:\n\t * Invalid schema kind for")
+	assert.Error(t, errs[2], "validation error at validation_test.Outer.Kind:\n\t * Invalid schema kind fyve")
+	assert.Error(t, errs[3], "validation error at validation_test.Outer.Wrapped:\n\t * Invalid schema kind sixe")
+
+	validError := validation.Error{}
+	for _, err := range errs {
+		if ok := errors.As(err, &validError); !ok {
+			t.Fatal("invalid error, expected a validation.Error, got", err)
+		}
+	}
+	assert.DeepEqual(t, validError.Path(), []validation.PathSegment{
+		{Kind: validation.Field, Entry: "Wrapped"},
+	})
+
+	// Valid values produce no error.
+	errs = validation.ValidateAll(&Outer{
+		Inner: &Inner{
+			Validators: []ExampleValidator{{Kind: "one"}}, // nolint:exhaustruct
+		},
+		Kind:    "two",
+		Wrapped: nil,
+	})
+	assert.Check(t, errs == nil)
+}
+
+// Tests for the human-readable rendering of validation paths.
+func TestErrorString(t *testing.T) {
+	type Inner struct {
+		Validator ExampleValidator
+	}
+	type Outer struct {
+		Map map[string][]Inner
+	}
+	type Keys struct {
+		Map map[ExampleValidator]int
+	}
+
+	err := validation.Validate(&Outer{
+		Map: map[string][]Inner{
+			"abc": {
+				{Validator: ExampleValidator{Kind: "one"}},   // nolint:exhaustruct
+				{Validator: ExampleValidator{Kind: "turee"}}, // nolint:exhaustruct
+			},
+		},
+	})
+	assert.Error(t, err, "validation error at validation_test.Outer.Map[abc][1].Validator:\n\t * Invalid schema kind turee")
+
+	err = validation.Validate(&Keys{
+		Map: map[ExampleValidator]int{{Kind: "turee"}: 0}, // nolint:exhaustruct
+	})
+	assert.Error(t, err, "validation error at validation_test.Keys.Map[>> {turee 0} <<]:\n\t * Invalid schema kind turee")
+
+	slice := []Inner{{Validator: ExampleValidator{Kind: "turee"}}} // nolint:exhaustruct
+	err = validation.Validate(&slice)
+	assert.Error(t, err, "validation error at []validation_test.Inner[0].Validator:\n\t * Invalid schema kind turee")
+
+	err = validation.WrapError("Outer.field", errors.New("some error"))
+	assert.Error(t, err, "validation error at Outer.field:\n\t * some error")
+}